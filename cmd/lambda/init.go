@@ -13,9 +13,16 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
 	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/budget"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
 	"github.com/kkstas/tener/internal/server"
 )
 
@@ -54,10 +61,32 @@ func initApplication(ctx context.Context, w io.Writer) (*server.Application, err
 	}
 
 	expenseStore := expense.NewDDBStore(tableName, client)
+	if os.Getenv("STREAM_AGGREGATION") == "true" {
+		expenseStore = expense.NewDDBStoreWithStreamAggregation(tableName, client)
+	}
 	expenseCategoryStore := expensecategory.NewDDBStore(tableName, client)
+	expenseRuleStore := expenserule.NewDDBStore(tableName, client)
+	budgetStore := budget.NewDDBStore(tableName, client)
+	notificationStore := notification.NewDDBStore(tableName, client)
+	savingsGoalStore := savingsgoal.NewDDBStore(tableName, client)
+	idempotencyStore := idempotency.NewDDBStore(tableName, client)
+	undoStore := undo.NewDDBStore(tableName, client)
 	userStore := user.NewDDBStore(tableName, client)
 
-	return server.NewApplication(logger, expenseStore, expenseCategoryStore, userStore), nil
+	return server.NewApplication(logger, expenseStore, expenseCategoryStore, expenseRuleStore, budgetStore, notificationStore, savingsGoalStore, idempotencyStore, undoStore, userStore, initNotifyChannels()...), nil
+}
+
+// Returns notification channels configured with environment variables, next
+// to in-app notifications. Email is sent before responding, as Lambda freezes
+// the environment afterwards, so it gives up after 5 seconds to not hold the
+// response up for long.
+func initNotifyChannels() []notify.Channel {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	smtpChannel := notify.NewSMTPChannel(addr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	return []notify.Channel{notify.NewTimeoutChannel(smtpChannel, 5*time.Second)}
 }
 
 func initLogger(w io.Writer) *slog.Logger {
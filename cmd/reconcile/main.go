@@ -0,0 +1,111 @@
+// Command reconcile recomputes sums of every granularity and grouping from
+// expenses, reports the ones that don't match and, with -repair, fixes them.
+// With -members, it also stores vault memberships of users registered before
+// they were stored.
+//
+//	DDB_TABLE_NAME=tener go run ./cmd/reconcile [-vault ID] [-repair] [-members]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+func main() {
+	vaultID := flag.String("vault", "", "reconcile only given vault instead of all vaults")
+	repair := flag.Bool("repair", false, "overwrite mismatched sums and create missing ones")
+	members := flag.Bool("members", false, "store vault memberships of all users")
+	flag.Parse()
+
+	ctx := context.Background()
+	if err := run(ctx, os.Stdout, *vaultID, *repair, *members); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, w io.Writer, vaultID string, repair, members bool) error {
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	defer cancel()
+
+	tableName := os.Getenv("DDB_TABLE_NAME")
+
+	client, err := database.CreateDynamoDBClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating DDB client failed: %w", err)
+	}
+
+	userStore := user.NewDDBStore(tableName, client)
+
+	if members {
+		if err := putVaultMembers(ctx, w, userStore); err != nil {
+			return err
+		}
+	}
+
+	vaults := []string{vaultID}
+	if vaultID == "" {
+		users, err := userStore.FindAll(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to find users: %w", err)
+		}
+		vaults = []string{}
+		for _, u := range users {
+			vaults = append(vaults, u.Vaults...)
+		}
+		slices.Sort(vaults)
+		vaults = slices.Compact(vaults)
+	}
+
+	store := expense.NewDDBStore(tableName, client)
+
+	for _, vaultID := range vaults {
+		result, err := store.ReconcileSums(ctx, vaultID, repair)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile vault %s: %w", vaultID, err)
+		}
+		report(w, result, repair)
+	}
+
+	return nil
+}
+
+func putVaultMembers(ctx context.Context, w io.Writer, store *user.DDBStore) error {
+	users, err := store.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find users: %w", err)
+	}
+	for _, u := range users {
+		if err := store.PutVaultMembers(ctx, u); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "stored vault memberships of %d users\n", len(users))
+	return nil
+}
+
+func report(w io.Writer, result expense.ReconcileResult, repair bool) {
+	fmt.Fprintf(w, "vault %s: %d sums checked, %d discrepancies\n", result.VaultID, result.Checked, len(result.Discrepancies))
+	for _, d := range result.Discrepancies {
+		switch {
+		case d.Missing:
+			fmt.Fprintf(w, "  %s %s %q: missing, actual %.2f\n", d.Sums, d.Period, d.Category, d.Actual)
+		case d.Actual == 0:
+			fmt.Fprintf(w, "  %s %s %q: stored %.2f, no expenses left\n", d.Sums, d.Period, d.Category, d.Stored)
+		default:
+			fmt.Fprintf(w, "  %s %s %q: stored %.2f, actual %.2f\n", d.Sums, d.Period, d.Category, d.Stored, d.Actual)
+		}
+	}
+	if repair {
+		fmt.Fprintf(w, "  repaired %d of %d\n", result.Repaired, len(result.Discrepancies))
+	}
+}
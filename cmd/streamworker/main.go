@@ -0,0 +1,125 @@
+// Command streamworker maintains monthly sums and name stats of expenses from
+// the table stream, and sends budget alerts once sums are up to date. It runs
+// as a Lambda handler of the stream's event source mapping, or outside Lambda
+// polls the stream itself, e.g. of dynamodb-local.
+//
+// Run it only together with the app started with STREAM_AGGREGATION=true,
+// otherwise derived data would be updated twice.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
+	"github.com/kkstas/tener/internal/server"
+	"github.com/kkstas/tener/internal/stream"
+)
+
+const pollInterval = time.Second
+
+func main() {
+	ctx := context.Background()
+	if err := run(ctx, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, w io.Writer) error {
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	defer cancel()
+
+	if os.Getenv("STREAM_AGGREGATION") != "true" {
+		return errors.New("STREAM_AGGREGATION is not enabled, expense writes already maintain derived data")
+	}
+
+	logger := initLogger(w)
+	tableName := os.Getenv("DDB_TABLE_NAME")
+
+	client, err := database.CreateDynamoDBClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating DDB client failed: %w", err)
+	}
+
+	expenseStore := expense.NewDDBStoreWithStreamAggregation(tableName, client)
+	app := server.NewApplication(
+		logger,
+		expenseStore,
+		expensecategory.NewDDBStore(tableName, client),
+		expenserule.NewDDBStore(tableName, client),
+		budget.NewDDBStore(tableName, client),
+		notification.NewDDBStore(tableName, client),
+		savingsgoal.NewDDBStore(tableName, client),
+		idempotency.NewDDBStore(tableName, client),
+		undo.NewDDBStore(tableName, client),
+		user.NewDDBStore(tableName, client),
+		initNotifyChannels()...,
+	)
+	worker := stream.NewWorker(logger, expenseStore, app)
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		lambda.Start(worker.HandleLambdaEvent)
+		return nil
+	}
+
+	streamARN, err := database.LatestStreamARN(ctx, client, tableName)
+	if err != nil {
+		return err
+	}
+
+	streamsClient, err := database.CreateDynamoDBStreamsClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating DDB streams client failed: %w", err)
+	}
+
+	logger.Info("polling stream", "streamARN", streamARN)
+	return stream.NewPoller(streamsClient, streamARN, worker, pollInterval).Run(ctx)
+}
+
+// Returns notification channels for budget alerts configured with environment
+// variables, next to in-app notifications. Email gives up after 5 seconds, so
+// that a slow SMTP server doesn't hold up processing of the stream.
+func initNotifyChannels() []notify.Channel {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	smtpChannel := notify.NewSMTPChannel(addr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	return []notify.Channel{notify.NewTimeoutChannel(smtpChannel, 5*time.Second)}
+}
+
+func initLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		level = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
@@ -14,9 +14,16 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 
 	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/budget"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
 	"github.com/kkstas/tener/internal/server"
 )
 
@@ -61,10 +68,19 @@ func initApplicationAndDDB(ctx context.Context, w io.Writer) (*server.Applicatio
 	}
 
 	expenseStore := expense.NewDDBStore(tableName, client)
+	if os.Getenv("STREAM_AGGREGATION") == "true" {
+		expenseStore = expense.NewDDBStoreWithStreamAggregation(tableName, client)
+	}
 	expenseCategoryStore := expensecategory.NewDDBStore(tableName, client)
+	expenseRuleStore := expenserule.NewDDBStore(tableName, client)
+	budgetStore := budget.NewDDBStore(tableName, client)
+	notificationStore := notification.NewDDBStore(tableName, client)
+	savingsGoalStore := savingsgoal.NewDDBStore(tableName, client)
+	idempotencyStore := idempotency.NewDDBStore(tableName, client)
+	undoStore := undo.NewDDBStore(tableName, client)
 	userStore := user.NewDDBStore(tableName, client)
 
-	newApp := server.NewApplication(logger, expenseStore, expenseCategoryStore, userStore)
+	newApp := server.NewApplication(logger, expenseStore, expenseCategoryStore, expenseRuleStore, budgetStore, notificationStore, savingsGoalStore, idempotencyStore, undoStore, userStore, initNotifyChannels(logger)...)
 	return newApp, nil
 }
 
@@ -86,6 +102,18 @@ func createDDBTableIfNotExists(ctx context.Context, logger *slog.Logger, client
 	return nil
 }
 
+// Returns notification channels configured with environment variables, next
+// to in-app notifications. Email is sent in background, so that it doesn't
+// delay responses.
+func initNotifyChannels(logger *slog.Logger) []notify.Channel {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	smtpChannel := notify.NewSMTPChannel(addr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	return []notify.Channel{notify.NewBackgroundChannel(smtpChannel, logger, 30*time.Second)}
+}
+
 func initLogger(w io.Writer) *slog.Logger {
 	envLevel := strings.ToLower(os.Getenv("LOG_LEVEL"))
 	var level slog.Level
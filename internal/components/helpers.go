@@ -2,6 +2,8 @@ package components
 
 import (
 	"encoding/json"
+	"slices"
+	"strings"
 
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
@@ -12,24 +14,59 @@ func toJSON(v any) string {
 	return string(b)
 }
 
+// Returns names of all categories, ordered so that subcategories directly follow
+// their parents. Names found only on expenses are appended at the end.
 func getUniqueCategoryNames(categoriesFromExpenses []string, categories []expensecategory.Category) []string {
-	m := make(map[string]bool)
+	sorted, depths := expensecategory.SortHierarchically(categories)
 
-	for _, c := range categoriesFromExpenses {
-		m[c] = true
+	result := []string{}
+	for _, c := range sorted {
+		result = append(result, c.Name)
 	}
 
-	for _, c := range categories {
-		m[c.Name] = true
+	extra := []string{}
+	for _, c := range categoriesFromExpenses {
+		if _, found := depths[c]; !found && !slices.Contains(extra, c) {
+			extra = append(extra, c)
+		}
 	}
+	slices.Sort(extra)
 
-	result := []string{}
+	return append(result, extra...)
+}
 
-	for k := range m {
-		result = append(result, k)
+// Returns depth of each category in the category tree, 0 for top level ones.
+func categoryDepths(categories []expensecategory.Category) map[string]int {
+	_, depths := expensecategory.SortHierarchically(categories)
+	return depths
+}
+
+type categoryOption struct {
+	Name  string
+	Label string
+}
+
+// Returns select options for categories in hierarchical order, with names of
+// subcategories indented under their parents. Archived categories are left out
+// unless includeArchived is set.
+func categoryOptions(categories []expensecategory.Category, includeArchived bool) []categoryOption {
+	sorted, depths := expensecategory.SortHierarchically(categories)
+	options := make([]categoryOption, 0, len(sorted))
+	for _, c := range sorted {
+		if c.Archived && !includeArchived {
+			continue
+		}
+		label := c.Name
+		if icon := expensecategory.IconEmoji(c.Icon); icon != "" {
+			label = icon + " " + label
+		}
+		options = append(options, categoryOption{Name: c.Name, Label: indentCategoryName(label, depths[c.Name])})
 	}
+	return options
+}
 
-	return result
+func indentCategoryName(name string, depth int) string {
+	return strings.Repeat("\u00a0\u00a0\u00a0", depth) + name
 }
 
 func extractCategories(expenses []expense.Expense) []string {
@@ -4,14 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
 )
 
+// TTLAttributeName is the numeric attribute holding the Unix time after which
+// DynamoDB deletes the item.
+const TTLAttributeName = "expiresAt"
+
 func CreateDynamoDBClient(ctx context.Context) (*dynamodb.Client, error) {
 	cfg, err := config.LoadDefaultConfig(ctx)
 
@@ -88,6 +94,10 @@ func CreateDDBTable(ctx context.Context, client *dynamodb.Client, tableName stri
 			ReadCapacityUnits:  aws.Int64(10),
 			WriteCapacityUnits: aws.Int64(10),
 		},
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: types.StreamViewTypeNewAndOldImages,
+		},
 	}
 
 	_, err := client.CreateTable(ctx, tableInput)
@@ -102,5 +112,47 @@ func CreateDDBTable(ctx context.Context, client *dynamodb.Client, tableName stri
 	if err != nil {
 		return fmt.Errorf("failed to wait for table %s to exist: %w", tableName, err)
 	}
+
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(TTLAttributeName),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable TTL on table %s: %w", tableName, err)
+	}
 	return nil
 }
+
+// LatestStreamARN returns ARN of the stream of item changes in given table.
+func LatestStreamARN(ctx context.Context, client *dynamodb.Client, tableName string) (string, error) {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &tableName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+	if output.Table.LatestStreamArn == nil {
+		return "", fmt.Errorf("stream is not enabled on table %s", tableName)
+	}
+	return *output.Table.LatestStreamArn, nil
+}
+
+// CreateDynamoDBStreamsClient creates a client for reading table streams. When
+// no streams endpoint is configured, it uses the DynamoDB one, as
+// dynamodb-local serves both.
+func CreateDynamoDBStreamsClient(ctx context.Context) (*dynamodbstreams.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %w", err)
+	}
+
+	return dynamodbstreams.NewFromConfig(cfg, func(o *dynamodbstreams.Options) {
+		endpoint := os.Getenv("AWS_ENDPOINT_URL_DYNAMODB")
+		if os.Getenv("AWS_ENDPOINT_URL_DYNAMODB_STREAMS") == "" && endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	}), nil
+}
@@ -23,6 +23,16 @@ func NextDay(date string) (string, error) {
 	return nextDay.Format(time.DateOnly), nil
 }
 
+// Gets previous day of YYYY-MM-DD date string
+func PreviousDay(date string) (string, error) {
+	parsedDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return "", err
+	}
+	previousDay := parsedDate.AddDate(0, 0, -1)
+	return previousDay.Format(time.DateOnly), nil
+}
+
 // Counts difference in days of two YYYY-MM-DD date strings
 func DaysBetween(from, to string) (int, error) {
 	startDate, err := time.Parse(time.DateOnly, from)
@@ -95,3 +105,30 @@ func IsValidYYYYMM(dateString string) bool {
 	_, err := time.Parse(layout, dateString)
 	return err == nil
 }
+
+// Counts difference in months of two YYYY-MM month strings
+func MonthsBetween(from, to string) (int, error) {
+	startMonth, err := time.Parse("2006-01", from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse 'from' month: %w", err)
+	}
+	endMonth, err := time.Parse("2006-01", to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse 'to' month: %w", err)
+	}
+
+	return (endMonth.Year()-startMonth.Year())*12 + int(endMonth.Month()) - int(startMonth.Month()), nil
+}
+
+// Adds given amount of months to YYYY-MM-DD date string, keeping the day of
+// month unless the resulting month is shorter, e.g. 2026-01-31 + 1 month is
+// 2026-02-28.
+func AddMonths(date string, months int) (string, error) {
+	parsedDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return "", err
+	}
+	firstDay := time.Date(parsedDate.Year(), parsedDate.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1).Day()
+	return firstDay.AddDate(0, 0, min(parsedDate.Day(), lastDay)-1).Format(time.DateOnly), nil
+}
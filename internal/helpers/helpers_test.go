@@ -49,6 +49,36 @@ func TestNextDay(t *testing.T) {
 	})
 }
 
+func TestPreviousDay(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{input: "2024-02-01", want: "2024-01-31"},
+		{input: "2024-01-01", want: "2023-12-31"},
+		{input: "2024-03-01", want: "2024-02-29"},
+	}
+
+	for _, c := range cases {
+		t.Run("returns previous day", func(t *testing.T) {
+			got, err := PreviousDay(c.input)
+			if err != nil {
+				t.Fatalf("didn't expect an error but got one: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("got '%s', want '%s'", got, c.want)
+			}
+		})
+	}
+
+	t.Run("should return error on string with invalid date layout", func(t *testing.T) {
+		_, err := PreviousDay("2024-0404")
+		if err == nil {
+			t.Error("expected error but didn't get one")
+		}
+	})
+}
+
 func TestDaysBetween(t *testing.T) {
 	cases := []struct {
 		from string
@@ -85,6 +115,33 @@ func TestDaysBetween(t *testing.T) {
 	})
 }
 
+func TestMonthsBetween(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+		want int
+	}{
+		{from: "2024-03", to: "2024-01", want: -2},
+		{from: "2023-12", to: "2024-01", want: 1},
+		{from: "2024-01", to: "2024-01", want: 0},
+		{from: "2023-01", to: "2024-06", want: 17},
+	}
+
+	for _, c := range cases {
+		got, err := MonthsBetween(c.from, c.to)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("got '%d', want '%d' for %s - %s", got, c.want, c.from, c.to)
+		}
+	}
+
+	if _, err := MonthsBetween("2024-0", "2024-01"); err == nil {
+		t.Error("expected error but didn't get one")
+	}
+}
+
 func TestGetFirstAndLastDayOfMonth(t *testing.T) {
 	gotFrom, gotTo, err := GetFirstAndLastDayOfMonth("2024-01-01")
 	if err != nil {
@@ -100,3 +157,32 @@ func TestGetFirstAndLastDayOfMonth(t *testing.T) {
 		t.Errorf("got %s want %s", gotTo, wantTo)
 	}
 }
+
+func TestAddMonths(t *testing.T) {
+	cases := []struct {
+		date   string
+		months int
+		want   string
+	}{
+		{date: "2026-10-19", months: 1, want: "2026-11-19"},
+		{date: "2026-01-31", months: 1, want: "2026-02-28"},
+		{date: "2024-01-31", months: 1, want: "2024-02-29"},
+		{date: "2026-11-30", months: 3, want: "2027-02-28"},
+		{date: "2026-03-31", months: -1, want: "2026-02-28"},
+		{date: "2026-10-19", months: -12, want: "2025-10-19"},
+	}
+
+	for _, c := range cases {
+		got, err := AddMonths(c.date, c.months)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("got %q, want %q for %s + %d months", got, c.want, c.date, c.months)
+		}
+	}
+
+	if _, err := AddMonths("2026-13-01", 1); err == nil {
+		t.Error("expected error but didn't get one")
+	}
+}
@@ -0,0 +1,34 @@
+package budget
+
+import "fmt"
+
+// AlertThresholds are percentages of a budget at which vault members are
+// alerted. Each threshold is alerted at most once per budget per month.
+var AlertThresholds = []int{80, 100}
+
+// ReachedThresholds returns alert thresholds reached by given progress, in
+// ascending order.
+func ReachedThresholds(p Progress) []int {
+	reached := []int{}
+	for _, threshold := range AlertThresholds {
+		if p.Budgeted > 0 && p.Spent*100 >= p.Budgeted*float64(threshold) {
+			reached = append(reached, threshold)
+		}
+	}
+	return reached
+}
+
+// DescribeAlert returns title and body of an alert about reaching threshold.
+func DescribeAlert(p Progress, month string, threshold int) (title, body string) {
+	if threshold >= 100 {
+		title = fmt.Sprintf("Budget of %s exceeded", p.Category)
+	} else {
+		title = fmt.Sprintf("%d%% of %s budget used", threshold, p.Category)
+	}
+	body = fmt.Sprintf("%.2f of %.2f spent on %s in %s (%.0f%%).", p.Spent, p.Budgeted, p.Category, month, p.Percent)
+	return title, body
+}
+
+func buildAlertSK(month, category string, threshold int) string {
+	return fmt.Sprintf("%s::%s::%d", month, category, threshold)
+}
@@ -0,0 +1,27 @@
+package budget_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+)
+
+func TestReachedThresholds(t *testing.T) {
+	cases := []struct {
+		spent float64
+		want  []int
+	}{
+		{spent: 79.99, want: []int{}},
+		{spent: 80, want: []int{80}},
+		{spent: 100, want: []int{80, 100}},
+		{spent: 250, want: []int{80, 100}},
+	}
+
+	for _, c := range cases {
+		got := budget.ReachedThresholds(budget.Progress{Budgeted: 100, Spent: c.spent})
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("spent %.2f: got %v, want %v", c.spent, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package budget
+
+import (
+	"strings"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+// Budget limits monthly spending in a category, including its subcategories.
+// With Rollover set, the unused part of a month's budget is added to the next
+// month's budget.
+type Budget struct {
+	PK                  string  `dynamodbav:"PK"`
+	Category            string  `dynamodbav:"SK"`
+	Amount              float64 `dynamodbav:"amount"`
+	Rollover            bool    `dynamodbav:"rollover"`
+	CreatedAt           string  `dynamodbav:"createdAt"`
+	CreatedBy           string  `dynamodbav:"createdBy"`
+	validator.Validator `dynamodbav:"-"`
+}
+
+func New(category string, amount float64, rollover bool) (budget Budget, isValid bool, errMessages validator.ErrMessages) {
+	budget = Budget{
+		Category:  strings.TrimSpace(category),
+		Amount:    amount,
+		Rollover:  rollover,
+		CreatedAt: helpers.GenerateCurrentTimestamp(),
+	}
+
+	budget.Check(validator.StringLengthBetween(
+		"category",
+		budget.Category,
+		expensecategory.CategoryNameMinLength,
+		expensecategory.CategoryNameMaxLength,
+	))
+	budget.Check(budget.Amount > 0, "amount", "must be greater than zero")
+	budget.Check(validator.IsAmountPrecision("amount", budget.Amount))
+
+	if isValid, errMessages := budget.Validate(); !isValid {
+		return Budget{}, false, errMessages
+	}
+
+	return budget, true, nil
+}
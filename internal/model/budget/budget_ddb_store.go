@@ -0,0 +1,245 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/kkstas/tener/internal/helpers"
+)
+
+const (
+	pkPrefix      = "budget"
+	alertPKPrefix = "budgetalert"
+)
+
+type DDBStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func getKey(vaultID, category string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildPK(vaultID))
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(category)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
+func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
+	return &DDBStore{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+// Put creates budget of a category or replaces its amount and rollover setting.
+// Creation time of an existing budget is kept, as rollover is counted from it.
+func (bs *DDBStore) Put(ctx context.Context, budgetFC Budget, userID, vaultID string) (Budget, error) {
+	budgetFC.PK = buildPK(vaultID)
+	budgetFC.CreatedBy = userID
+
+	expr, err := expression.NewBuilder().WithUpdate(
+		expression.Set(expression.Name("amount"), expression.Value(budgetFC.Amount)).
+			Set(expression.Name("rollover"), expression.Value(budgetFC.Rollover)).
+			Set(expression.Name("createdBy"), expression.Value(budgetFC.CreatedBy)).
+			Set(expression.Name("createdAt"), expression.IfNotExists(expression.Name("createdAt"), expression.Value(budgetFC.CreatedAt))),
+	).Build()
+	if err != nil {
+		return Budget{}, fmt.Errorf("failed to build expression for putting budget: %w", err)
+	}
+
+	response, err := bs.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &bs.tableName,
+		Key:                       getKey(vaultID, budgetFC.Category),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return Budget{}, fmt.Errorf("failed to put budget into DynamoDB: %w", err)
+	}
+
+	var budget Budget
+	err = attributevalue.UnmarshalMap(response.Attributes, &budget)
+	if err != nil {
+		return Budget{}, fmt.Errorf("failed to unmarshal budget: %w", err)
+	}
+
+	return budget, nil
+}
+
+func (bs *DDBStore) Delete(ctx context.Context, category, vaultID string) error {
+	_, err := bs.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           &bs.tableName,
+		Key:                 getKey(vaultID, category),
+		ConditionExpression: aws.String("attribute_exists(SK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &NotFoundError{Category: category}
+		}
+		return fmt.Errorf("failed to delete budget of category '%s' from table: %w", category, err)
+	}
+
+	return nil
+}
+
+// Move moves budget of category from onto category to, adding its amount to
+// the budget of category to if there is one already. It does nothing when
+// category from has no budget.
+func (bs *DDBStore) Move(ctx context.Context, from, to, vaultID string) error {
+	response, err := bs.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &bs.tableName,
+		Key:       getKey(vaultID, from),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get budget of category '%s': %w", from, err)
+	}
+	if response.Item == nil {
+		return nil
+	}
+
+	var source Budget
+	if err := attributevalue.UnmarshalMap(response.Item, &source); err != nil {
+		return fmt.Errorf("failed to unmarshal budget: %w", err)
+	}
+
+	updateExpr, err := expression.NewBuilder().WithUpdate(
+		expression.Add(expression.Name("amount"), expression.Value(source.Amount)).
+			Set(expression.Name("rollover"), expression.IfNotExists(expression.Name("rollover"), expression.Value(source.Rollover))).
+			Set(expression.Name("createdBy"), expression.IfNotExists(expression.Name("createdBy"), expression.Value(source.CreatedBy))).
+			Set(expression.Name("createdAt"), expression.IfNotExists(expression.Name("createdAt"), expression.Value(source.CreatedAt))),
+	).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for moving budget: %w", err)
+	}
+
+	deleteExpr, err := expression.NewBuilder().
+		WithCondition(expression.Name("amount").Equal(expression.Value(source.Amount))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for moving budget: %w", err)
+	}
+
+	_, err = bs.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName:                 &bs.tableName,
+					Key:                       getKey(vaultID, to),
+					ExpressionAttributeNames:  updateExpr.Names(),
+					ExpressionAttributeValues: updateExpr.Values(),
+					UpdateExpression:          updateExpr.Update(),
+				},
+			},
+			{
+				Delete: &types.Delete{
+					TableName:                 &bs.tableName,
+					Key:                       getKey(vaultID, from),
+					ExpressionAttributeNames:  deleteExpr.Names(),
+					ExpressionAttributeValues: deleteExpr.Values(),
+					ConditionExpression:       deleteExpr.Condition(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move budget of category '%s' to '%s': %w", from, to, err)
+	}
+
+	return nil
+}
+
+// Returns all budgets of given vault sorted by category.
+func (bs *DDBStore) FindAll(ctx context.Context, vaultID string) ([]Budget, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildPK(vaultID)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for budget query %w", err)
+	}
+
+	budgets := []Budget{}
+
+	queryPaginator := dynamodb.NewQueryPaginator(bs.client, &dynamodb.QueryInput{
+		TableName:                 &bs.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query for budgets: %w", err)
+		}
+
+		resBudgets := []Budget{}
+		err = attributevalue.UnmarshalListOfMaps(response.Items, &resBudgets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query response for budgets: %w", err)
+		}
+
+		budgets = append(budgets, resBudgets...)
+	}
+
+	return budgets, nil
+}
+
+// MarkAlerted records that vault members were alerted about budget of category
+// reaching threshold in given month. It returns false if that was already
+// recorded, so that every alert is sent only once.
+func (bs *DDBStore) MarkAlerted(ctx context.Context, category, month string, threshold int, vaultID string) (bool, error) {
+	item, err := attributevalue.MarshalMap(map[string]any{
+		"PK":        buildAlertPK(vaultID),
+		"SK":        buildAlertSK(month, category, threshold),
+		"createdAt": helpers.GenerateCurrentTimestamp(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal budget alert: %w", err)
+	}
+
+	_, err = bs.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &bs.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to put budget alert into DynamoDB: %w", err)
+	}
+
+	return true, nil
+}
+
+// ClearAlerted removes the record made by MarkAlerted, so that the alert is
+// sent again once the threshold is reached.
+func (bs *DDBStore) ClearAlerted(ctx context.Context, category, month string, threshold int, vaultID string) error {
+	_, err := bs.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &bs.tableName,
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: buildAlertPK(vaultID)},
+			"SK": &types.AttributeValueMemberS{Value: buildAlertSK(month, category, threshold)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete budget alert from DynamoDB: %w", err)
+	}
+	return nil
+}
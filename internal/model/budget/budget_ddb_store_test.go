@@ -0,0 +1,139 @@
+package budget_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/budget"
+)
+
+func TestDDBPut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := budget.NewDDBStore(tableName, client)
+
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	created, err := store.Put(ctx, budgetFC, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	budgetFC, _, _ = budget.New("Food", 150, true)
+	budgetFC.CreatedAt = "later"
+	updated, err := store.Put(ctx, budgetFC, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if updated.Amount != 150 || !updated.Rollover || updated.CreatedAt != created.CreatedAt {
+		t.Errorf("expected budget to be replaced keeping creation time, got %#v", updated)
+	}
+
+	budgets, err := store.FindAll(ctx, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(budgets) != 1 {
+		t.Errorf("expected one budget, got %#v", budgets)
+	}
+}
+
+func TestDDBDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := budget.NewDDBStore(tableName, client)
+
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	if _, err := store.Put(ctx, budgetFC, "userID", "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	if err := store.Delete(ctx, "Food", "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	err = store.Delete(ctx, "Food", "activeVaultID")
+	var notFoundErr *budget.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("expected %T, got %#v", notFoundErr, err)
+	}
+}
+
+func TestDDBMove(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := budget.NewDDBStore(tableName, client)
+
+	for _, b := range []struct {
+		category string
+		amount   float64
+	}{{"Fuel", 100}, {"Transport", 50.5}} {
+		budgetFC, _, _ := budget.New(b.category, b.amount, false)
+		if _, err := store.Put(ctx, budgetFC, "userID", "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	for _, move := range [][2]string{{"Fuel", "Gas"}, {"Gas", "Transport"}, {"Unknown", "Transport"}} {
+		if err := store.Move(ctx, move[0], move[1], "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	budgets, err := store.FindAll(ctx, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(budgets) != 1 || budgets[0].Category != "Transport" || budgets[0].Amount != 150.5 {
+		t.Errorf("expected budgets to be merged into Transport, got %#v", budgets)
+	}
+}
+
+func TestDDBMarkAlerted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := budget.NewDDBStore(tableName, client)
+
+	for _, want := range []bool{true, false} {
+		got, err := store.MarkAlerted(ctx, "Food", "2024-06", 80, "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+
+	if err := store.ClearAlerted(ctx, "Food", "2024-06", 80, "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if got, _ := store.MarkAlerted(ctx, "Food", "2024-06", 80, "activeVaultID"); !got {
+		t.Error("expected cleared alert to be recorded again")
+	}
+}
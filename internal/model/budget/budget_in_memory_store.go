@@ -0,0 +1,81 @@
+package budget
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+type InMemoryStore struct {
+	budgets []Budget
+	alerted map[string]bool
+}
+
+func (s *InMemoryStore) Put(ctx context.Context, budgetFC Budget, userID, vaultID string) (Budget, error) {
+	budgetFC.CreatedBy = userID
+	for i, b := range s.budgets {
+		if b.Category == budgetFC.Category {
+			budgetFC.CreatedAt = b.CreatedAt
+			s.budgets[i] = budgetFC
+			return budgetFC, nil
+		}
+	}
+	s.budgets = append(s.budgets, budgetFC)
+	return budgetFC, nil
+}
+
+func (s *InMemoryStore) Delete(ctx context.Context, category, vaultID string) error {
+	length := len(s.budgets)
+
+	s.budgets = slices.DeleteFunc(s.budgets, func(b Budget) bool {
+		return b.Category == category
+	})
+
+	if len(s.budgets) == length {
+		return &NotFoundError{Category: category}
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore) Move(ctx context.Context, from, to, vaultID string) error {
+	i := slices.IndexFunc(s.budgets, func(b Budget) bool { return b.Category == from })
+	if i == -1 {
+		return nil
+	}
+	source := s.budgets[i]
+	s.budgets = slices.Delete(s.budgets, i, i+1)
+
+	for j, b := range s.budgets {
+		if b.Category == to {
+			s.budgets[j].Amount = round(b.Amount + source.Amount)
+			return nil
+		}
+	}
+	source.Category = to
+	s.budgets = append(s.budgets, source)
+	return nil
+}
+
+func (s *InMemoryStore) FindAll(ctx context.Context, vaultID string) ([]Budget, error) {
+	budgets := slices.Clone(s.budgets)
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].Category < budgets[j].Category })
+	return budgets, nil
+}
+
+func (s *InMemoryStore) MarkAlerted(ctx context.Context, category, month string, threshold int, vaultID string) (bool, error) {
+	if s.alerted == nil {
+		s.alerted = map[string]bool{}
+	}
+	sk := buildAlertSK(month, category, threshold)
+	if s.alerted[sk] {
+		return false, nil
+	}
+	s.alerted[sk] = true
+	return true, nil
+}
+
+func (s *InMemoryStore) ClearAlerted(ctx context.Context, category, month string, threshold int, vaultID string) error {
+	delete(s.alerted, buildAlertSK(month, category, threshold))
+	return nil
+}
@@ -0,0 +1,99 @@
+package budget_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+)
+
+func TestInMemoryPut(t *testing.T) {
+	ctx := context.Background()
+	store := &budget.InMemoryStore{}
+
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	created, err := store.Put(ctx, budgetFC, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	budgetFC, _, _ = budget.New("Food", 150, true)
+	budgetFC.CreatedAt = "later"
+	if _, err := store.Put(ctx, budgetFC, "userID", "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	budgets, _ := store.FindAll(ctx, "activeVaultID")
+	if len(budgets) != 1 || budgets[0].Amount != 150 || !budgets[0].Rollover || budgets[0].CreatedAt != created.CreatedAt {
+		t.Errorf("expected budget to be replaced keeping creation time, got %#v", budgets)
+	}
+}
+
+func TestInMemoryDelete(t *testing.T) {
+	ctx := context.Background()
+	store := &budget.InMemoryStore{}
+
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	_, _ = store.Put(ctx, budgetFC, "userID", "activeVaultID")
+
+	if err := store.Delete(ctx, "Food", "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	err := store.Delete(ctx, "Food", "activeVaultID")
+	var notFoundErr *budget.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("expected %T, got %#v", notFoundErr, err)
+	}
+}
+
+func TestInMemoryMove(t *testing.T) {
+	ctx := context.Background()
+	store := &budget.InMemoryStore{}
+
+	for _, b := range []struct {
+		category string
+		amount   float64
+	}{{"Fuel", 100}, {"Transport", 50.5}} {
+		budgetFC, _, _ := budget.New(b.category, b.amount, false)
+		_, _ = store.Put(ctx, budgetFC, "userID", "activeVaultID")
+	}
+
+	for _, move := range [][2]string{{"Fuel", "Gas"}, {"Gas", "Transport"}, {"Unknown", "Transport"}} {
+		if err := store.Move(ctx, move[0], move[1], "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	budgets, _ := store.FindAll(ctx, "activeVaultID")
+	if len(budgets) != 1 || budgets[0].Category != "Transport" || budgets[0].Amount != 150.5 {
+		t.Errorf("expected budgets to be merged into Transport, got %#v", budgets)
+	}
+}
+
+func TestInMemoryMarkAlerted(t *testing.T) {
+	ctx := context.Background()
+	store := &budget.InMemoryStore{}
+
+	for _, want := range []bool{true, false} {
+		got, err := store.MarkAlerted(ctx, "Food", "2024-06", 80, "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+
+	if got, _ := store.MarkAlerted(ctx, "Food", "2024-07", 80, "activeVaultID"); !got {
+		t.Error("expected alert in another month to be recorded")
+	}
+
+	if err := store.ClearAlerted(ctx, "Food", "2024-06", 80, "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if got, _ := store.MarkAlerted(ctx, "Food", "2024-06", 80, "activeVaultID"); !got {
+		t.Error("expected cleared alert to be recorded again")
+	}
+}
@@ -0,0 +1,33 @@
+package budget_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("returns error for non positive amount", func(t *testing.T) {
+		_, isValid, errMessages := budget.New("Food", 0, false)
+		if isValid || len(errMessages["amount"]) == 0 {
+			t.Errorf("expected amount error, got %v", errMessages)
+		}
+	})
+
+	t.Run("returns error for invalid category", func(t *testing.T) {
+		_, isValid, errMessages := budget.New(" ", 100, false)
+		if isValid || len(errMessages["category"]) == 0 {
+			t.Errorf("expected category error, got %v", errMessages)
+		}
+	})
+
+	t.Run("creates budget", func(t *testing.T) {
+		b, isValid, errMessages := budget.New(" Food ", 100.5, true)
+		if !isValid {
+			t.Fatalf("didn't expect error: %v", errMessages)
+		}
+		if b.Category != "Food" || b.Amount != 100.5 || !b.Rollover {
+			t.Errorf("got %#v", b)
+		}
+	})
+}
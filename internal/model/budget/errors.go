@@ -0,0 +1,13 @@
+package budget
+
+import "fmt"
+
+type NotFoundError struct {
+	Category string
+	Err      error
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("budget for category '%s' not found", e.Category)
+}
@@ -0,0 +1,22 @@
+package budget
+
+import "time"
+
+const monthLayout = "2006-01"
+
+func buildPK(vaultID string) string {
+	return pkPrefix + "::" + vaultID
+}
+
+func buildAlertPK(vaultID string) string {
+	return alertPKPrefix + "::" + vaultID
+}
+
+// Returns YYYY-MM month shifted by given number of months.
+func addMonths(month string, months int) string {
+	t, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return month
+	}
+	return t.AddDate(0, months, 0).Format(monthLayout)
+}
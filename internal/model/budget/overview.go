@@ -0,0 +1,97 @@
+package budget
+
+import (
+	"math"
+	"sort"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+)
+
+// RolloverMaxMonths limits how many past months can add their unused amounts
+// to the budget of a given month.
+const RolloverMaxMonths = 12
+
+// Progress shows how much of a category budget was spent in a month. Budgeted
+// includes the amount rolled over from previous months.
+type Progress struct {
+	Category   string  `json:"category"`
+	Amount     float64 `json:"amount"`
+	Rollover   bool    `json:"rollover"`
+	RolledOver float64 `json:"rolledOver"`
+	Budgeted   float64 `json:"budgeted"`
+	Spent      float64 `json:"spent"`
+	Remaining  float64 `json:"remaining"`
+	Percent    float64 `json:"percent"`
+	OverBudget bool    `json:"overBudget"`
+}
+
+// Overview computes progress of every budget in given YYYY-MM month from
+// monthly sums. Spending in subcategories counts towards budget of their parent.
+// Monthly sums should cover RolloverMaxMonths months before month for rollover
+// to be computed in full.
+func Overview(budgets []Budget, categories []expensecategory.Category, sums []expense.MonthlySum, month string) []Progress {
+	spentPerMonth := map[string]map[string]float64{}
+	for _, s := range sums {
+		m := s.SK[:7]
+		if spentPerMonth[m] == nil {
+			spentPerMonth[m] = map[string]float64{}
+		}
+		spentPerMonth[m][s.Category] += s.Sum
+	}
+
+	spent := func(category, month string) float64 {
+		total := 0.0
+		for _, name := range expensecategory.Subtree(categories, category) {
+			total += spentPerMonth[month][name]
+		}
+		return total
+	}
+
+	progress := make([]Progress, 0, len(budgets))
+	for _, b := range budgets {
+		rolledOver := 0.0
+		if b.Rollover {
+			rolledOver = rollover(b, month, spent)
+		}
+
+		p := Progress{
+			Category:   b.Category,
+			Amount:     b.Amount,
+			Rollover:   b.Rollover,
+			RolledOver: round(rolledOver),
+			Budgeted:   round(b.Amount + rolledOver),
+			Spent:      round(spent(b.Category, month)),
+		}
+		p.Remaining = round(p.Budgeted - p.Spent)
+		p.OverBudget = p.Spent > p.Budgeted
+		if p.Budgeted > 0 {
+			p.Percent = round(p.Spent / p.Budgeted * 100)
+		}
+		progress = append(progress, p)
+	}
+
+	sort.Slice(progress, func(i, j int) bool { return progress[i].Category < progress[j].Category })
+
+	return progress
+}
+
+// Returns amount left unused in months before given month, starting from the
+// month the budget was created in. Overspending a month doesn't reduce budgets
+// of the following months.
+func rollover(b Budget, month string, spent func(category, month string) float64) float64 {
+	start := addMonths(month, -RolloverMaxMonths)
+	if len(b.CreatedAt) >= 7 && b.CreatedAt[:7] > start {
+		start = b.CreatedAt[:7]
+	}
+
+	carried := 0.0
+	for m := start; m < month; m = addMonths(m, 1) {
+		carried = math.Max(0, b.Amount+carried-spent(b.Category, m))
+	}
+	return carried
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,54 @@
+package budget_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+)
+
+func TestOverview(t *testing.T) {
+	categories := []expensecategory.Category{
+		{Name: "Transport"},
+		{Name: "Fuel", Parent: "Transport"},
+		{Name: "Food"},
+	}
+	sums := []expense.MonthlySum{
+		{SK: "2024-04::Food", Category: "Food", Sum: 250},
+		{SK: "2024-05::Food", Category: "Food", Sum: 100},
+		{SK: "2024-06::Food", Category: "Food", Sum: 180},
+		{SK: "2024-06::Transport", Category: "Transport", Sum: 50},
+		{SK: "2024-06::Fuel", Category: "Fuel", Sum: 200},
+	}
+
+	t.Run("counts subcategories and marks budgets exceeded", func(t *testing.T) {
+		budgets := []budget.Budget{{Category: "Transport", Amount: 200, CreatedAt: "2024-01-01T00:00:00Z"}}
+
+		got := budget.Overview(budgets, categories, sums, "2024-06")
+		want := budget.Progress{Category: "Transport", Amount: 200, Budgeted: 200, Spent: 250, Remaining: -50, Percent: 125, OverBudget: true}
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("rolls over unused amounts since budget creation", func(t *testing.T) {
+		budgets := []budget.Budget{{Category: "Food", Amount: 200, Rollover: true, CreatedAt: "2024-04-10T00:00:00Z"}}
+
+		// April overspent (nothing carried), May left 100 unused.
+		got := budget.Overview(budgets, categories, sums, "2024-06")
+		want := budget.Progress{Category: "Food", Amount: 200, Rollover: true, RolledOver: 100, Budgeted: 300, Spent: 180, Remaining: 120, Percent: 60}
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("ignores months before budget was created", func(t *testing.T) {
+		budgets := []budget.Budget{{Category: "Food", Amount: 200, Rollover: true, CreatedAt: "2024-06-01T00:00:00Z"}}
+
+		got := budget.Overview(budgets, categories, sums, "2024-06")
+		if len(got) != 1 || got[0].RolledOver != 0 || got[0].Budgeted != 200 {
+			t.Errorf("got %#v", got)
+		}
+	})
+}
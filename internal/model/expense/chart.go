@@ -2,6 +2,7 @@ package expense
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 )
@@ -9,11 +10,78 @@ import (
 type ChartData struct {
 	Labels   [][]string     `json:"labels"`
 	Datasets []CategoryData `json:"datasets"`
+	Forecast *Forecast      `json:"forecast,omitempty"`
 }
 
 type CategoryData struct {
-	Label string    `json:"label"`
-	Data  []float64 `json:"data"`
+	Label           string    `json:"label"`
+	Data            []float64 `json:"data"`
+	BackgroundColor string    `json:"backgroundColor,omitempty"`
+	BorderColor     string    `json:"borderColor,omitempty"`
+	BorderWidth     int       `json:"borderWidth,omitempty"`
+	// Planned is set for the dataset of planned spending, stacked on top of
+	// committed spending of all categories.
+	Planned bool `json:"planned,omitempty"`
+	// Forecast is set for the dataset of spending projected for the rest of
+	// the current month, drawn with a dashed border.
+	Forecast bool `json:"forecast,omitempty"`
+}
+
+const (
+	PlannedLabel  = "Planned"
+	ForecastLabel = "Forecast"
+
+	plannedBackgroundColor  = "rgba(161, 161, 170, 0.25)"
+	plannedBorderColor      = "#a1a1aa"
+	forecastBackgroundColor = "rgba(161, 161, 170, 0.1)"
+)
+
+// WithColors sets background color of every dataset to the color returned
+// for its category, so that categories look the same on every chart.
+func (c ChartData) WithColors(colorOf func(category string) string) ChartData {
+	c.Datasets = withColors(c.Datasets, func(dataset *CategoryData) {
+		if !dataset.Planned && !dataset.Forecast {
+			dataset.BackgroundColor = colorOf(dataset.Label)
+		}
+	})
+	return c
+}
+
+// withColors returns a copy of rows with colors set by paint, leaving the
+// original rows untouched.
+func withColors[T any](rows []T, paint func(row *T)) []T {
+	colored := make([]T, len(rows))
+	for i, row := range rows {
+		paint(&row)
+		colored[i] = row
+	}
+	return colored
+}
+
+// WithForecast stacks spending projected for the rest of the current month,
+// the last one of the chart, on top of it and adds the forecast to the data.
+func (c ChartData) WithForecast(f Forecast) ChartData {
+	c.Forecast = &f
+	if f.Remaining <= 0 || len(c.Labels) == 0 {
+		return c
+	}
+
+	last := len(c.Labels) - 1
+	data := make([]float64, len(c.Labels))
+	data[last] = f.Remaining
+
+	label := slices.Clone(c.Labels[last])
+	label = slices.Insert(label, len(label)-1, fmt.Sprintf("~%d forecast", int(f.Total)))
+	c.Labels = append(slices.Clone(c.Labels[:last]), label)
+
+	c.Datasets = append(slices.Clone(c.Datasets), CategoryData{
+		Label:           ForecastLabel,
+		Data:            data,
+		BackgroundColor: forecastBackgroundColor,
+		BorderColor:     plannedBorderColor,
+		Forecast:        true,
+	})
+	return c
 }
 
 func getLastSixMonths() ([]string, []string) {
@@ -37,9 +105,14 @@ func getLastSixMonths() ([]string, []string) {
 func TransformToChartData(data []MonthlySum) ChartData {
 	months, monthKeys := getLastSixMonths()
 	categoryMap := map[string]map[string]float64{}
+	plannedMap := map[string]float64{}
 
 	for _, record := range data {
 		monthYear := record.SK[:7]
+		plannedMap[monthYear] += record.Planned
+		if record.Sum == 0 {
+			continue
+		}
 
 		if categoryMap[record.Category] == nil {
 			categoryMap[record.Category] = make(map[string]float64)
@@ -69,19 +142,62 @@ func TransformToChartData(data []MonthlySum) ChartData {
 		}
 	}
 
+	plannedAmounts := make([]float64, len(monthKeys))
+	anyPlanned := false
+	for i, monthKey := range monthKeys {
+		plannedAmounts[i] = round(plannedMap[monthKey])
+		anyPlanned = anyPlanned || plannedAmounts[i] != 0
+	}
+
 	labels := [][]string{}
 
 	for i, month := range months {
-		labels = append(labels, []string{
-			fmt.Sprintf("%d PLN", int(monthAmounts[i])),
-			month,
-		})
+		label := []string{fmt.Sprintf("%d PLN", int(monthAmounts[i]))}
+		if plannedAmounts[i] != 0 {
+			label = append(label, fmt.Sprintf("+%d planned", int(plannedAmounts[i])))
+		}
+		labels = append(labels, append(label, month))
 	}
 
 	sort.Slice(datasets, func(i int, j int) bool { return datasets[i].Label < datasets[j].Label })
 
+	if anyPlanned {
+		datasets = append(datasets, CategoryData{
+			Label:           PlannedLabel,
+			Data:            plannedAmounts,
+			BackgroundColor: plannedBackgroundColor,
+			BorderColor:     plannedBorderColor,
+			BorderWidth:     1,
+			Planned:         true,
+		})
+	}
+
 	return ChartData{
 		Labels:   labels,
 		Datasets: datasets,
 	}
 }
+
+// RollUpMonthlySums merges monthly sums of categories that rollUp maps to the
+// same category, e.g. subcategories into their top level category.
+func RollUpMonthlySums(sums []MonthlySum, rollUp func(category string) string) []MonthlySum {
+	rolledUp := []MonthlySum{}
+	indexes := map[string]int{}
+
+	for _, s := range sums {
+		month := s.SK[:7]
+		category := rollUp(s.Category)
+		sk := buildMonthlySumSK(month, category)
+
+		if i, found := indexes[sk]; found {
+			rolledUp[i].Sum += s.Sum
+			rolledUp[i].Planned += s.Planned
+			continue
+		}
+
+		indexes[sk] = len(rolledUp)
+		rolledUp = append(rolledUp, MonthlySum{PK: s.PK, SK: sk, Category: category, Sum: s.Sum, Planned: s.Planned})
+	}
+
+	return rolledUp
+}
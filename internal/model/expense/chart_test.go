@@ -0,0 +1,53 @@
+package expense_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestRollUpMonthlySums(t *testing.T) {
+	sums := []expense.MonthlySum{
+		{SK: "2024-05::Fuel", Category: "Fuel", Sum: 200},
+		{SK: "2024-05::Public transport", Category: "Public transport", Sum: 50},
+		{SK: "2024-05::Food", Category: "Food", Sum: 300},
+		{SK: "2024-06::Fuel", Category: "Fuel", Sum: 100},
+	}
+	parents := map[string]string{"Fuel": "Transport", "Public transport": "Transport"}
+
+	got := expense.RollUpMonthlySums(sums, func(category string) string {
+		if parent, found := parents[category]; found {
+			return parent
+		}
+		return category
+	})
+
+	want := []expense.MonthlySum{
+		{SK: "2024-05::Transport", Category: "Transport", Sum: 250},
+		{SK: "2024-05::Food", Category: "Food", Sum: 300},
+		{SK: "2024-06::Transport", Category: "Transport", Sum: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestChartDataWithForecast(t *testing.T) {
+	chartData := expense.TransformToChartData(nil).WithForecast(expense.Forecast{SpentToDate: 100, Remaining: 50, Total: 150})
+
+	if chartData.Forecast == nil || chartData.Forecast.Total != 150 {
+		t.Fatalf("expected forecast in chart data, got %#v", chartData.Forecast)
+	}
+	if len(chartData.Datasets) != 1 || !chartData.Datasets[0].Forecast {
+		t.Fatalf("expected forecast dataset, got %#v", chartData.Datasets)
+	}
+	data := chartData.Datasets[0].Data
+	if data[len(data)-1] != 50 || data[0] != 0 {
+		t.Errorf("expected remaining spending in the current month only, got %v", data)
+	}
+	label := chartData.Labels[len(chartData.Labels)-1]
+	if len(label) != 3 || label[1] != "~150 forecast" {
+		t.Errorf("unexpected current month label: %v", label)
+	}
+}
@@ -0,0 +1,170 @@
+package expense
+
+import (
+	"math"
+	"sort"
+
+	"github.com/kkstas/tener/internal/helpers"
+)
+
+// ComparisonMoversCount is the number of categories with the largest absolute
+// changes that are marked as the biggest movers of a comparison.
+const ComparisonMoversCount = 3
+
+// Period is a range of YYYY-MM months, both inclusive.
+type Period struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Comparison compares committed spending of categories in a period with the
+// period of the same length directly before it and with the same months of
+// the previous year.
+type Comparison struct {
+	Current  Period          `json:"current"`
+	Previous Period          `json:"previous"`
+	LastYear Period          `json:"lastYear"`
+	Rows     []ComparisonRow `json:"rows"`
+	Total    ComparisonRow   `json:"total"`
+}
+
+type ComparisonRow struct {
+	Category      string  `json:"category"`
+	Color         string  `json:"color,omitempty"`
+	Current       float64 `json:"current"`
+	Previous      float64 `json:"previous"`
+	LastYear      float64 `json:"lastYear"`
+	PreviousDelta Delta   `json:"previousDelta"`
+	LastYearDelta Delta   `json:"lastYearDelta"`
+}
+
+// Delta is the change of the current sum against the one it is compared to.
+// Percent is nil when there was nothing spent to compare against.
+type Delta struct {
+	Amount       float64  `json:"amount"`
+	Percent      *float64 `json:"percent"`
+	BiggestMover bool     `json:"biggestMover"`
+}
+
+func newDelta(current, base float64) Delta {
+	delta := Delta{Amount: round(current - base)}
+	if base != 0 {
+		percent := round((current - base) / base * 100)
+		delta.Percent = &percent
+	}
+	return delta
+}
+
+// ComparisonPeriods returns the period of the same length directly before the
+// months between from and to, and the same months a year earlier.
+func ComparisonPeriods(from, to string) (previous, lastYear Period, err error) {
+	count, err := helpers.MonthsBetween(from, to)
+	if err != nil {
+		return Period{}, Period{}, err
+	}
+
+	months := func(month string, diff int) (string, error) {
+		date, err := helpers.AddMonths(month+"-01", diff)
+		if err != nil {
+			return "", err
+		}
+		return date[:7], nil
+	}
+
+	if previous.From, err = months(from, -count-1); err != nil {
+		return Period{}, Period{}, err
+	}
+	if previous.To, err = months(from, -1); err != nil {
+		return Period{}, Period{}, err
+	}
+	if lastYear.From, err = months(from, -12); err != nil {
+		return Period{}, Period{}, err
+	}
+	if lastYear.To, err = months(to, -12); err != nil {
+		return Period{}, Period{}, err
+	}
+	return previous, lastYear, nil
+}
+
+// NewComparison compares months between from and to, both YYYY-MM and
+// inclusive, out of monthly sums covering them and the periods they are
+// compared to. Rows are ordered by their current sum, descending.
+func NewComparison(from, to string, sums []PeriodSum) (Comparison, error) {
+	previous, lastYear, err := ComparisonPeriods(from, to)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	c := Comparison{Current: Period{From: from, To: to}, Previous: previous, LastYear: lastYear}
+	within := func(month string, p Period) bool { return month >= p.From && month <= p.To }
+
+	rows := map[string]*ComparisonRow{}
+	for _, s := range sums {
+		month := s.Period
+		if s.Sum == 0 || !within(month, c.Current) && !within(month, c.Previous) && !within(month, c.LastYear) {
+			continue
+		}
+		row, found := rows[s.Category]
+		if !found {
+			row = &ComparisonRow{Category: s.Category}
+			rows[s.Category] = row
+		}
+		// previous period and last year overlap when comparing over a year
+		if within(month, c.Current) {
+			row.Current = round(row.Current + s.Sum)
+		}
+		if within(month, c.Previous) {
+			row.Previous = round(row.Previous + s.Sum)
+		}
+		if within(month, c.LastYear) {
+			row.LastYear = round(row.LastYear + s.Sum)
+		}
+	}
+
+	c.Total = ComparisonRow{Category: "Total"}
+	c.Rows = []ComparisonRow{}
+	for _, row := range rows {
+		row.PreviousDelta = newDelta(row.Current, row.Previous)
+		row.LastYearDelta = newDelta(row.Current, row.LastYear)
+		c.Rows = append(c.Rows, *row)
+		c.Total.Current = round(c.Total.Current + row.Current)
+		c.Total.Previous = round(c.Total.Previous + row.Previous)
+		c.Total.LastYear = round(c.Total.LastYear + row.LastYear)
+	}
+	c.Total.PreviousDelta = newDelta(c.Total.Current, c.Total.Previous)
+	c.Total.LastYearDelta = newDelta(c.Total.Current, c.Total.LastYear)
+
+	sort.Slice(c.Rows, func(i, j int) bool {
+		if c.Rows[i].Current != c.Rows[j].Current {
+			return c.Rows[i].Current > c.Rows[j].Current
+		}
+		return c.Rows[i].Category < c.Rows[j].Category
+	})
+	markBiggestMovers(c.Rows, func(row *ComparisonRow) *Delta { return &row.PreviousDelta })
+	markBiggestMovers(c.Rows, func(row *ComparisonRow) *Delta { return &row.LastYearDelta })
+
+	return c, nil
+}
+
+// Marks deltas of up to ComparisonMoversCount rows with the largest absolute
+// change, skipping rows that didn't change.
+func markBiggestMovers(rows []ComparisonRow, deltaOf func(row *ComparisonRow) *Delta) {
+	indexes := make([]int, len(rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return math.Abs(deltaOf(&rows[indexes[i]]).Amount) > math.Abs(deltaOf(&rows[indexes[j]]).Amount)
+	})
+	for _, i := range indexes[:min(ComparisonMoversCount, len(indexes))] {
+		if delta := deltaOf(&rows[i]); delta.Amount != 0 {
+			delta.BiggestMover = true
+		}
+	}
+}
+
+// WithColors sets color of every row to the color returned for its category.
+func (c Comparison) WithColors(colorOf func(category string) string) Comparison {
+	c.Rows = withColors(c.Rows, func(row *ComparisonRow) { row.Color = colorOf(row.Category) })
+	return c
+}
@@ -0,0 +1,95 @@
+package expense_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestComparisonPeriods(t *testing.T) {
+	cases := []struct {
+		from, to         string
+		previous, lastYr expense.Period
+	}{
+		{"2026-10", "2026-10", expense.Period{From: "2026-09", To: "2026-09"}, expense.Period{From: "2025-10", To: "2025-10"}},
+		{"2026-01", "2026-03", expense.Period{From: "2025-10", To: "2025-12"}, expense.Period{From: "2025-01", To: "2025-03"}},
+	}
+
+	for _, tc := range cases {
+		previous, lastYear, err := expense.ComparisonPeriods(tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if previous != tc.previous || lastYear != tc.lastYr {
+			t.Errorf("%s - %s: got %v and %v, want %v and %v", tc.from, tc.to, previous, lastYear, tc.previous, tc.lastYr)
+		}
+	}
+}
+
+func TestNewComparison(t *testing.T) {
+	sums := []expense.PeriodSum{
+		{Period: "2025-10", Category: "Food", Sum: 100},
+		{Period: "2026-09", Category: "Food", Sum: 200},
+		{Period: "2026-10", Category: "Food", Sum: 300},
+		{Period: "2026-09", Category: "Fuel", Sum: 50},
+		{Period: "2026-10", Category: "Fuel", Sum: 40},
+		{Period: "2026-10", Category: "Gifts", Sum: 10},
+		{Period: "2026-10", Category: "Rent", Sum: 0, Planned: 900},
+		{Period: "2026-08", Category: "Rent", Sum: 900},
+	}
+
+	got, err := expense.NewComparison("2026-10", "2026-10", sums)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	if len(got.Rows) != 3 || got.Rows[0].Category != "Food" || got.Rows[2].Category != "Gifts" {
+		t.Fatalf("unexpected rows: %#v", got.Rows)
+	}
+
+	food := got.Rows[0]
+	if food.Current != 300 || food.Previous != 200 || food.LastYear != 100 {
+		t.Errorf("unexpected food sums: %#v", food)
+	}
+	if food.PreviousDelta.Amount != 100 || *food.PreviousDelta.Percent != 50 || !food.PreviousDelta.BiggestMover {
+		t.Errorf("unexpected food previous delta: %#v", food.PreviousDelta)
+	}
+	if food.LastYearDelta.Amount != 200 || *food.LastYearDelta.Percent != 200 {
+		t.Errorf("unexpected food last year delta: %#v", food.LastYearDelta)
+	}
+
+	gifts := got.Rows[2]
+	if gifts.PreviousDelta.Percent != nil || gifts.PreviousDelta.Amount != 10 {
+		t.Errorf("expected no percent for category without previous spending, got %#v", gifts.PreviousDelta)
+	}
+
+	if got.Total.Current != 350 || got.Total.Previous != 250 || got.Total.LastYear != 100 {
+		t.Errorf("unexpected total: %#v", got.Total)
+	}
+}
+
+func TestNewComparisonMarksBiggestMovers(t *testing.T) {
+	sums := []expense.PeriodSum{}
+	for i, category := range []string{"A", "B", "C", "D", "E"} {
+		sums = append(sums,
+			expense.PeriodSum{Period: "2026-09", Category: category, Sum: 100},
+			expense.PeriodSum{Period: "2026-10", Category: category, Sum: 100 + float64(i*10) - 20},
+		)
+	}
+
+	got, err := expense.NewComparison("2026-10", "2026-10", sums)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	movers := map[string]bool{}
+	for _, row := range got.Rows {
+		if row.PreviousDelta.BiggestMover {
+			movers[row.Category] = true
+		}
+	}
+	// changes are -20, -10, 0, +10 and +20
+	if len(movers) != 3 || !movers["A"] || !movers["E"] || movers["C"] {
+		t.Errorf("unexpected biggest movers: %v", movers)
+	}
+}
@@ -0,0 +1,70 @@
+package expense
+
+import (
+	"math"
+	"strings"
+)
+
+// DuplicateMaxDaysApart is how far apart, in days, two expenses may be dated
+// and still be considered the same purchase.
+const DuplicateMaxDaysApart = 1
+
+// IsPossibleDuplicate reports whether exp looks like the same purchase as
+// other: equal amount, dates at most DuplicateMaxDaysApart days apart and a
+// similar name.
+func IsPossibleDuplicate(exp, other Expense) bool {
+	if math.Round(exp.Amount*100) != math.Round(other.Amount*100) {
+		return false
+	}
+	if math.Abs(daysBetweenDates(exp.Date, other.Date)) > DuplicateMaxDaysApart {
+		return false
+	}
+	return similarNames(exp.Name, other.Name)
+}
+
+// FindPossibleDuplicates returns those candidates that look like the same
+// purchase as exp.
+func FindPossibleDuplicates(exp Expense, candidates []Expense) []Expense {
+	duplicates := []Expense{}
+	for _, candidate := range candidates {
+		if candidate.SK != exp.SK && IsPossibleDuplicate(exp, candidate) {
+			duplicates = append(duplicates, candidate)
+		}
+	}
+	return duplicates
+}
+
+// similarNames treats names as similar when one contains the other after
+// normalization, or when they differ by a few typos.
+func similarNames(a, b string) bool {
+	a, b = normalizeName(a), normalizeName(b)
+	if a == "" || b == "" {
+		return false
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+	maxDistance := max(1, min(len([]rune(a)), len([]rune(b)))/4)
+	return levenshtein(a, b) <= maxDistance
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
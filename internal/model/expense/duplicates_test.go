@@ -0,0 +1,47 @@
+package expense_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestIsPossibleDuplicate(t *testing.T) {
+	base := expense.Expense{Name: "Dinner at Pasta Bar", Date: "2024-05-10", Amount: 120.5}
+
+	cases := []struct {
+		desc  string
+		other expense.Expense
+		want  bool
+	}{
+		{"same expense", expense.Expense{Name: "Dinner at Pasta Bar", Date: "2024-05-10", Amount: 120.5}, true},
+		{"different case and whitespace", expense.Expense{Name: " dinner at  pasta bar", Date: "2024-05-10", Amount: 120.5}, true},
+		{"name contained in other", expense.Expense{Name: "Pasta Bar", Date: "2024-05-11", Amount: 120.5}, true},
+		{"typo in name", expense.Expense{Name: "Diner at Pasta Bra", Date: "2024-05-09", Amount: 120.5}, true},
+		{"dates two days apart", expense.Expense{Name: "Dinner at Pasta Bar", Date: "2024-05-12", Amount: 120.5}, false},
+		{"different amount", expense.Expense{Name: "Dinner at Pasta Bar", Date: "2024-05-10", Amount: 120.49}, false},
+		{"different name", expense.Expense{Name: "Groceries", Date: "2024-05-10", Amount: 120.5}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			if got := expense.IsPossibleDuplicate(base, c.other); got != c.want {
+				t.Errorf("got %t, want %t", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFindPossibleDuplicates(t *testing.T) {
+	exp := expense.Expense{Name: "Dinner", Date: "2024-05-10", Amount: 50}
+	candidates := []expense.Expense{
+		{SK: "1", Name: "dinner", Date: "2024-05-09", Amount: 50},
+		{SK: "2", Name: "Cinema", Date: "2024-05-10", Amount: 50},
+		{SK: "3", Name: "Dinner", Date: "2024-05-10", Amount: 25},
+	}
+
+	got := expense.FindPossibleDuplicates(exp, candidates)
+	if len(got) != 1 || got[0].SK != "1" {
+		t.Errorf("expected only expense with SK '1' to be a duplicate, got %#v", got)
+	}
+}
@@ -22,3 +22,48 @@ func (e *MaxMonthExpenseCountExceededError) Unwrap() error { return e.Err }
 func (e *MaxMonthExpenseCountExceededError) Error() string {
 	return fmt.Sprintf("maximum expense count exceeded for month %s in vault %s", e.Month, e.Vault)
 }
+
+// VersionConflictError is returned when an expense is updated from a copy
+// whose version no longer matches the stored one.
+type VersionConflictError struct {
+	SK      string
+	Current Expense
+	Err     error
+}
+
+func (e *VersionConflictError) Unwrap() error { return e.Err }
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("expense with SK='%s' was changed in the meantime and is now at version %d", e.SK, e.Current.Version)
+}
+
+// HasRefundsError is returned when deleting an expense that still has refunds
+// linked to it.
+type HasRefundsError struct {
+	SK string
+}
+
+func (e *HasRefundsError) Error() string {
+	return fmt.Sprintf("expense with SK='%s' has refunds, which have to be deleted first", e.SK)
+}
+
+// RefundExceedsOriginalError is returned when refunds of an expense would add
+// up to more than its amount.
+type RefundExceedsOriginalError struct {
+	SK       string
+	Amount   float64
+	Refunded float64
+}
+
+func (e *RefundExceedsOriginalError) Error() string {
+	return fmt.Sprintf("refunds of expense with SK='%s' would add up to %.2f, more than its amount of %.2f", e.SK, e.Refunded, e.Amount)
+}
+
+// MaxRefundsExceededError is returned when refunding an expense that already
+// has MaxRefunds refunds.
+type MaxRefundsExceededError struct {
+	SK string
+}
+
+func (e *MaxRefundsExceededError) Error() string {
+	return fmt.Sprintf("expense with SK='%s' already has %d refunds", e.SK, MaxRefunds)
+}
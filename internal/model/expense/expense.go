@@ -22,15 +22,20 @@ const (
 )
 
 type Expense struct {
-	PK                  string  `dynamodbav:"PK"`
-	SK                  string  `dynamodbav:"SK"`
-	Name                string  `dynamodbav:"name"`
-	Date                string  `dynamodbav:"date"`
-	Category            string  `dynamodbav:"category"`
-	Amount              float64 `dynamodbav:"amount"`
-	PaymentMethod       string  `dynamodbav:"paymentMethod"`
-	CreatedAt           string  `dynamodbav:"createdAt"`
-	CreatedBy           string  `dynamodbav:"createdBy"`
+	PK                  string       `dynamodbav:"PK"`
+	SK                  string       `dynamodbav:"SK"`
+	Name                string       `dynamodbav:"name"`
+	Date                string       `dynamodbav:"date"`
+	Category            string       `dynamodbav:"category"`
+	Amount              float64      `dynamodbav:"amount"`
+	PaymentMethod       string       `dynamodbav:"paymentMethod"`
+	CreatedAt           string       `dynamodbav:"createdAt"`
+	CreatedBy           string       `dynamodbav:"createdBy"`
+	Version             int          `dynamodbav:"version"`
+	Status              string       `dynamodbav:"status,omitempty"`
+	Installment         *Installment `dynamodbav:"installment,omitempty"`
+	Refund              *Refund      `dynamodbav:"refund,omitempty"`
+	Refunds             []string     `dynamodbav:"refunds,stringset,omitempty"`
 	validator.Validator `dynamodbav:"-"`
 }
 
@@ -39,11 +44,12 @@ type MonthlySum struct {
 	SK       string  `dynamodbav:"SK"`
 	Category string  `dynamodbav:"category"`
 	Sum      float64 `dynamodbav:"sum"`
+	Planned  float64 `dynamodbav:"planned"`
 }
 
 func New(name, date, category string, amount float64, paymentMethod string) (exp Expense, isValid bool, errMessages validator.ErrMessages) {
 	currentTimestamp := helpers.GenerateCurrentTimestamp()
-	return validate(Expense{
+	exp = Expense{
 		SK:            buildSK(date, currentTimestamp),
 		Name:          strings.TrimSpace(name),
 		Date:          date,
@@ -51,7 +57,12 @@ func New(name, date, category string, amount float64, paymentMethod string) (exp
 		Amount:        amount,
 		PaymentMethod: paymentMethod,
 		CreatedAt:     currentTimestamp,
-	})
+		Status:        StatusCleared,
+	}
+	// negative amounts are only stored for refunds, see NewRefund, and for
+	// expenses entered before refunds existed, which can still be edited
+	exp.Check(amount > 0, "amount", "must be positive")
+	return validate(exp)
 }
 
 func NewFU(sk, name, date, category string, amount float64, paymentMethod string) (exp Expense, isValid bool, errMessages validator.ErrMessages) {
@@ -65,6 +76,11 @@ func NewFU(sk, name, date, category string, amount float64, paymentMethod string
 	})
 }
 
+// MovedSK returns the SK the expense has once updated to given date.
+func (e Expense) MovedSK(date string) string {
+	return buildSK(date, e.CreatedAt)
+}
+
 func validate(expense Expense) (exp Expense, isValid bool, errMessages validator.ErrMessages) {
 	expense.Check(validator.StringLengthBetween("name", expense.Name, NameMinLength, NameMaxLength))
 	expense.Check(validator.StringLengthBetween(
@@ -17,12 +17,19 @@ import (
 
 const (
 	maxExpensesInMonth = 1000
+
+	// Number of attempts to write an expense changed concurrently by another
+	// request before giving up.
+	maxWriteAttempts = 3
 )
 
 type DDBStore struct {
 	client                 *dynamodb.Client
 	tableName              string
 	expenseCountMonthLimit int
+	// streamAggregation leaves monthly sums and name stats to be maintained
+	// from the table stream by ApplyStreamChange.
+	streamAggregation bool
 }
 
 func getKey(vaultID, sk string) map[string]types.AttributeValue {
@@ -37,6 +44,30 @@ func getKey(vaultID, sk string) map[string]types.AttributeValue {
 	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
 }
 
+func getNameStatsKey(vaultID, key string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildNameStatsPK(vaultID))
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(key)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
+func getItemKey(pk, sk string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(pk)
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(sk)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
 func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
 	return NewDDBStoreWithExpenseMonthLimit(tableName, client, maxExpensesInMonth)
 }
@@ -49,6 +80,20 @@ func NewDDBStoreWithExpenseMonthLimit(tableName string, client *dynamodb.Client,
 	}
 }
 
+// NewDDBStoreWithStreamAggregation creates a store writing expenses only,
+// with their derived data maintained asynchronously by the stream worker.
+func NewDDBStoreWithStreamAggregation(tableName string, client *dynamodb.Client) *DDBStore {
+	store := NewDDBStore(tableName, client)
+	store.streamAggregation = true
+	return store
+}
+
+// StreamAggregation reports whether derived data of expenses is maintained by
+// the stream worker instead of by expense writes.
+func (es *DDBStore) StreamAggregation() bool {
+	return es.streamAggregation
+}
+
 func (es *DDBStore) marshal(
 	pk string,
 	sk string,
@@ -59,6 +104,11 @@ func (es *DDBStore) marshal(
 	category string,
 	createdAt string,
 	userID string,
+	version int,
+	status string,
+	installment *Installment,
+	refund *Refund,
+	refunds []string,
 ) (Expense, map[string]types.AttributeValue, error) {
 	newExpense := Expense{
 		PK:            pk,
@@ -70,17 +120,17 @@ func (es *DDBStore) marshal(
 		Category:      category,
 		CreatedAt:     createdAt,
 		CreatedBy:     userID,
+		Version:       version,
+		Status:        status,
+		Installment:   installment,
+		Refund:        refund,
+		Refunds:       refunds,
 	}
 	item, err := attributevalue.MarshalMap(newExpense)
 	return newExpense, item, err
 }
 
 func (es *DDBStore) Create(ctx context.Context, expenseFC Expense, userID, vaultID string) (Expense, error) {
-	err := es.validateExpenseLimit(ctx, expenseFC.Date, vaultID)
-	if err != nil {
-		return Expense{}, err
-	}
-
 	newExpense, item, err := es.marshal(
 		buildPK(vaultID),
 		expenseFC.SK,
@@ -91,25 +141,63 @@ func (es *DDBStore) Create(ctx context.Context, expenseFC Expense, userID, vault
 		expenseFC.Category,
 		expenseFC.CreatedAt,
 		userID,
+		1,
+		expenseFC.Status,
+		expenseFC.Installment,
+		expenseFC.Refund,
+		nil,
 	)
 
 	if err != nil {
 		return Expense{}, fmt.Errorf("failed to marshal expense: %w", err)
 	}
 
-	_, err = es.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName:           &es.tableName,
-		Item:                item,
-		ConditionExpression: aws.String("attribute_not_exists(SK)"),
-	})
+	putItem := types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:           &es.tableName,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(SK)"),
+		},
+	}
+
+	countItems, err := es.countChanges(ctx, vaultID, nil, &newExpense)
+	if err != nil {
+		return Expense{}, err
+	}
+
+	sumItems, err := es.syncSumDeltas(vaultID, nil, []Expense{newExpense})
+	if err != nil {
+		return Expense{}, err
+	}
 
+	items := append([]types.TransactWriteItem{putItem}, countItems...)
+	refundIndex := len(items)
+	for attempt := 1; ; attempt++ {
+		if newExpense.Refund != nil {
+			linkItem, err := es.addRefund(ctx, newExpense, vaultID)
+			if err != nil {
+				return Expense{}, err
+			}
+			items = append(items[:refundIndex], linkItem)
+		}
+		_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: append(items, sumItems...),
+		})
+		// another refund of the same expense was written in the meantime
+		if newExpense.Refund == nil || !isConditionalCheckFailed(err, refundIndex) || attempt == maxWriteAttempts {
+			break
+		}
+	}
+	if isConditionalCheckFailed(err, 1) {
+		return Expense{}, &MaxMonthExpenseCountExceededError{Month: newExpense.Date[:7], Vault: vaultID, Err: err}
+	}
 	if err != nil {
 		return Expense{}, fmt.Errorf("failed to put item into DynamoDB: %w", err)
 	}
 
-	err = es.updateMonthlySum(ctx, vaultID, newExpense.Date, newExpense.Category)
+	err = es.updateNameStats(ctx, vaultID, nil, []Expense{newExpense})
 	if err != nil {
-		return Expense{}, fmt.Errorf("failed to update monthly sum: %w", err)
+		return Expense{}, fmt.Errorf("failed to update name stats: %w", err)
 	}
 
 	return newExpense, nil
@@ -138,50 +226,115 @@ func (es *DDBStore) FindOne(ctx context.Context, sk, vaultID string) (Expense, e
 	return expense, nil
 }
 
+// Update replaces the expense together with the sums it counts towards, as
+// long as expenseFU.Version matches the stored version. Otherwise, including
+// when the expense changes while being written, VersionConflictError carries
+// its current copy.
+//
+// Refunds keep their amount, date and category, the latter following the
+// expense they refund, whose amount cannot drop below what was refunded.
 func (es *DDBStore) Update(ctx context.Context, expenseFU Expense, vaultID string) error {
 	foundExpense, err := es.FindOne(ctx, expenseFU.SK, vaultID)
 	if err != nil {
 		return fmt.Errorf("failed to find expense for update: %w", err)
 	}
-
-	err = es.validateExpenseLimit(ctx, expenseFU.Date, vaultID)
-	if err != nil {
-		return err
+	if foundExpense.Version != expenseFU.Version {
+		return &VersionConflictError{SK: expenseFU.SK, Current: foundExpense}
 	}
 
 	expenseFU.CreatedAt = foundExpense.CreatedAt
 	expenseFU.CreatedBy = foundExpense.CreatedBy
+	expenseFU.Installment = foundExpense.Installment
+	expenseFU.Refund = foundExpense.Refund
+	expenseFU.Refunds = foundExpense.Refunds
+	if expenseFU.Status == "" {
+		expenseFU.Status = foundExpense.Status
+	}
+	if foundExpense.Refund != nil {
+		expenseFU.Amount, expenseFU.Date, expenseFU.Category = foundExpense.Amount, foundExpense.Date, foundExpense.Category
+	}
+
+	refunds, err := es.findRefunds(ctx, foundExpense.Refunds, vaultID)
+	if err != nil {
+		return err
+	}
+	if refunded := refundedTotal(refunds); expenseFU.Amount < refunded {
+		return &RefundExceedsOriginalError{SK: foundExpense.SK, Amount: expenseFU.Amount, Refunded: refunded}
+	}
 
+	var items []types.TransactWriteItem
+	var updatedExpense Expense
 	if expenseFU.SK == buildSK(expenseFU.Date, foundExpense.CreatedAt) {
-		err = es.updateWithoutNewSK(ctx, expenseFU, vaultID)
+		updatedExpense = expenseFU
+		updatedExpense.Version++
+		items, err = es.updateWithoutNewSK(foundExpense, expenseFU, vaultID)
 	} else {
-		err = es.updateWithNewSK(ctx, expenseFU, vaultID)
+		updatedExpense, items, err = es.updateWithNewSK(foundExpense, expenseFU, vaultID)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to update expense: %w", err)
+		return err
+	}
+
+	removed, added := []Expense{foundExpense}, []Expense{updatedExpense}
+	if len(refunds) > 0 && (updatedExpense.SK != foundExpense.SK || updatedExpense.Category != foundExpense.Category) {
+		refundItems, movedRefunds, err := es.followOriginal(refunds, updatedExpense, vaultID)
+		if err != nil {
+			return err
+		}
+		items = append(items, refundItems...)
+		removed, added = append(removed, refunds...), append(added, movedRefunds...)
 	}
 
-	err = es.updateMonthlySum(ctx, vaultID, expenseFU.Date, expenseFU.Category)
+	countItems, err := es.countChanges(ctx, vaultID, &foundExpense, &updatedExpense)
 	if err != nil {
-		return fmt.Errorf("failed to update monthly sum: %w", err)
+		return err
 	}
 
-	if expenseFU.Date[:7] != foundExpense.Date[:7] || expenseFU.Category != foundExpense.Category {
-		err = es.updateMonthlySum(ctx, vaultID, foundExpense.Date, foundExpense.Category)
-		if err != nil {
-			return fmt.Errorf("failed to update monthly sum: %w", err)
+	sumItems, err := es.syncSumDeltas(vaultID, removed, added)
+	if err != nil {
+		return err
+	}
+
+	countIndex := len(items)
+	items = append(items, countItems...)
+	_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: append(items, sumItems...),
+	})
+	if isConditionalCheckFailed(err, 0) {
+		current, findErr := es.FindOne(ctx, expenseFU.SK, vaultID)
+		if findErr != nil {
+			return fmt.Errorf("failed to find expense changed during update: %w", findErr)
 		}
+		return &VersionConflictError{SK: expenseFU.SK, Current: current, Err: err}
+	}
+	if len(countItems) > 0 && isConditionalCheckFailed(err, countIndex) {
+		return &MaxMonthExpenseCountExceededError{Month: updatedExpense.Date[:7], Vault: vaultID, Err: err}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update expense atomically: %w", err)
+	}
+
+	err = es.updateNameStats(ctx, vaultID, removed, added)
+	if err != nil {
+		return fmt.Errorf("failed to update name stats: %w", err)
 	}
 
-	return err
+	return nil
 }
 
-func (es *DDBStore) updateWithNewSK(ctx context.Context, expenseFU Expense, vaultID string) error {
+// Returns transaction items moving the expense to the SK matching its new date.
+func (es *DDBStore) updateWithNewSK(foundExpense, expenseFU Expense, vaultID string) (Expense, []types.TransactWriteItem, error) {
+	deleteExpr, err := expression.NewBuilder().WithCondition(versionCondition(foundExpense)).Build()
+	if err != nil {
+		return Expense{}, nil, fmt.Errorf("failed to build expression for delete: %w", err)
+	}
 	deleteItem := types.TransactWriteItem{
 		Delete: &types.Delete{
-			TableName:           aws.String(es.tableName),
-			Key:                 getKey(vaultID, expenseFU.SK),
-			ConditionExpression: aws.String("attribute_exists(SK)"),
+			TableName:                 &es.tableName,
+			Key:                       getKey(vaultID, foundExpense.SK),
+			ExpressionAttributeNames:  deleteExpr.Names(),
+			ExpressionAttributeValues: deleteExpr.Values(),
+			ConditionExpression:       deleteExpr.Condition(),
 		},
 	}
 
@@ -195,9 +348,14 @@ func (es *DDBStore) updateWithNewSK(ctx context.Context, expenseFU Expense, vaul
 		expenseFU.Category,
 		expenseFU.CreatedAt,
 		expenseFU.CreatedBy,
+		foundExpense.Version+1,
+		expenseFU.Status,
+		expenseFU.Installment,
+		expenseFU.Refund,
+		expenseFU.Refunds,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to marshal expense: %w", err)
+		return Expense{}, nil, fmt.Errorf("failed to marshal expense: %w", err)
 	}
 
 	putItem := types.TransactWriteItem{
@@ -207,141 +365,428 @@ func (es *DDBStore) updateWithNewSK(ctx context.Context, expenseFU Expense, vaul
 		},
 	}
 
-	_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
-		TransactItems: []types.TransactWriteItem{deleteItem, putItem},
-	})
-
-	if err != nil {
-		var transactionErr *types.TransactionCanceledException
-
-		if errors.As(err, &transactionErr) {
-			for _, reason := range transactionErr.CancellationReasons {
-				if reason.Code != nil && *reason.Code == "ConditionalCheckFailed" {
-					return &NotFoundError{SK: expense.SK}
-				}
-			}
-		}
-		return fmt.Errorf("failed to update expense atomically: %w", err)
-	}
-
-	return nil
+	return expense, []types.TransactWriteItem{deleteItem, putItem}, nil
 }
 
-func (es *DDBStore) updateWithoutNewSK(ctx context.Context, expenseFU Expense, vaultID string) error {
+func (es *DDBStore) updateWithoutNewSK(foundExpense, expenseFU Expense, vaultID string) ([]types.TransactWriteItem, error) {
 	update := expression.
 		Set(expression.Name("name"), expression.Value(expenseFU.Name)).
 		Set(expression.Name("category"), expression.Value(expenseFU.Category)).
 		Set(expression.Name("amount"), expression.Value(expenseFU.Amount)).
-		Set(expression.Name("paymentMethod"), expression.Value(expenseFU.PaymentMethod))
-
-	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+		Set(expression.Name("paymentMethod"), expression.Value(expenseFU.PaymentMethod)).
+		Set(expression.Name("version"), expression.Value(foundExpense.Version+1))
+	if expenseFU.Status != "" {
+		update = update.Set(expression.Name("status"), expression.Value(expenseFU.Status))
+	}
 
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(versionCondition(foundExpense)).Build()
 	if err != nil {
-		return fmt.Errorf("failed to build expression for update: %w", err)
+		return nil, fmt.Errorf("failed to build expression for update: %w", err)
 	}
 
-	_, err = es.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName:                 &es.tableName,
-		Key:                       getKey(vaultID, expenseFU.SK),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		UpdateExpression:          expr.Update(),
-		ReturnValues:              types.ReturnValueUpdatedNew,
-		ConditionExpression:       aws.String("attribute_exists(SK)"),
-	})
+	return []types.TransactWriteItem{{
+		Update: &types.Update{
+			TableName:                 &es.tableName,
+			Key:                       getKey(vaultID, expenseFU.SK),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+		},
+	}}, nil
+}
 
-	if err != nil {
-		var condErr *types.ConditionalCheckFailedException
-		if errors.As(err, &condErr) {
-			return &NotFoundError{SK: expenseFU.SK}
+// Returns transaction items pointing refunds at the SK of the updated expense
+// they refund and moving them to its category, together with the refunds after.
+func (es *DDBStore) followOriginal(refunds []Expense, original Expense, vaultID string) ([]types.TransactWriteItem, []Expense, error) {
+	var items []types.TransactWriteItem
+	var movedRefunds []Expense
+	for _, refund := range refunds {
+		moved := refund
+		moved.Refund = &Refund{SK: original.SK, AttributeTo: refund.Refund.AttributeTo}
+		moved.Category = original.Category
+		moved.Version++
+
+		update := expression.
+			Set(expression.Name("refund.SK"), expression.Value(original.SK)).
+			Set(expression.Name("category"), expression.Value(original.Category)).
+			Set(expression.Name("version"), expression.Value(moved.Version))
+		expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(versionCondition(refund)).Build()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build expression for refund update: %w", err)
 		}
-		return fmt.Errorf("failed to update expense: %w", err)
+
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                 &es.tableName,
+				Key:                       getKey(vaultID, refund.SK),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+				UpdateExpression:          expr.Update(),
+				ConditionExpression:       expr.Condition(),
+			},
+		})
+		movedRefunds = append(movedRefunds, moved)
 	}
+	return items, movedRefunds, nil
+}
 
-	return nil
+// Returns transaction item adding SK of the refund to Refunds of the expense it
+// refunds, which has to exist and not be a refund, as long as its refunds don't
+// change in the meantime and stay within MaxRefunds and its amount.
+func (es *DDBStore) addRefund(ctx context.Context, refund Expense, vaultID string) (types.TransactWriteItem, error) {
+	original, err := es.FindOne(ctx, refund.Refund.SK, vaultID)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	if original.Refund != nil {
+		return types.TransactWriteItem{}, &NotFoundError{SK: refund.Refund.SK}
+	}
+	if len(original.Refunds) >= MaxRefunds {
+		return types.TransactWriteItem{}, &MaxRefundsExceededError{SK: original.SK}
+	}
+
+	refunds, err := es.findRefunds(ctx, original.Refunds, vaultID)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	if refunded := round(refundedTotal(refunds) - refund.Amount); refunded > original.Amount {
+		return types.TransactWriteItem{}, &RefundExceedsOriginalError{SK: original.SK, Amount: original.Amount, Refunded: refunded}
+	}
+
+	condition := "attribute_exists(SK) AND attribute_not_exists(refund) AND attribute_not_exists(refunds)"
+	values := map[string]types.AttributeValue{":sk": &types.AttributeValueMemberSS{Value: []string{refund.SK}}}
+	if len(original.Refunds) > 0 {
+		condition = "attribute_exists(SK) AND attribute_not_exists(refund) AND refunds = :current"
+		values[":current"] = &types.AttributeValueMemberSS{Value: original.Refunds}
+	}
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 &es.tableName,
+			Key:                       getKey(vaultID, original.SK),
+			UpdateExpression:          aws.String("ADD refunds :sk"),
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeValues: values,
+		},
+	}, nil
+}
+
+// Returns transaction item removing SK of the refund from Refunds of the
+// expense it refunds.
+func (es *DDBStore) unlinkRefund(refund Expense, vaultID string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 &es.tableName,
+			Key:                       getKey(vaultID, refund.Refund.SK),
+			UpdateExpression:          aws.String("DELETE refunds :sk"),
+			ConditionExpression:       aws.String("attribute_exists(SK) AND attribute_not_exists(refund)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":sk": &types.AttributeValueMemberSS{Value: []string{refund.SK}}},
+		},
+	}
+}
+
+// Returns refunds with given SKs.
+func (es *DDBStore) findRefunds(ctx context.Context, sks []string, vaultID string) ([]Expense, error) {
+	refunds := []Expense{}
+	for _, sk := range sks {
+		refund, err := es.FindOne(ctx, sk, vaultID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find refund: %w", err)
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, nil
 }
 
+// Delete removes the expense together with its share of sums. Expenses with
+// refunds cannot be deleted before their refunds.
 func (es *DDBStore) Delete(ctx context.Context, sk, vaultID string) error {
-	exp, err := es.FindOne(ctx, sk, vaultID)
+	var exp Expense
+	for attempt := 1; ; attempt++ {
+		var err error
+		exp, err = es.FindOne(ctx, sk, vaultID)
+		if err != nil {
+			return &NotFoundError{SK: sk}
+		}
+		if len(exp.Refunds) > 0 {
+			return &HasRefundsError{SK: sk}
+		}
+
+		deleteItem, err := es.deleteUnchanged(exp, vaultID)
+		if err != nil {
+			return err
+		}
+
+		countItems, err := es.countChanges(ctx, vaultID, &exp, nil)
+		if err != nil {
+			return err
+		}
+
+		sumItems, err := es.syncSumDeltas(vaultID, []Expense{exp}, nil)
+		if err != nil {
+			return err
+		}
+
+		items := append([]types.TransactWriteItem{deleteItem}, countItems...)
+		if exp.Refund != nil {
+			// refunds of expenses deleted before refunds were linked have nothing to unlink
+			_, err := es.FindOne(ctx, exp.Refund.SK, vaultID)
+			var notFoundErr *NotFoundError
+			if err != nil && !errors.As(err, &notFoundErr) {
+				return fmt.Errorf("failed to find refunded expense: %w", err)
+			}
+			if err == nil {
+				items = append(items, es.unlinkRefund(exp, vaultID))
+			}
+		}
+		_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: append(items, sumItems...),
+		})
+		if err == nil {
+			break
+		}
+		if !isConditionalCheckFailed(err, 0) || attempt == maxWriteAttempts {
+			return fmt.Errorf("failed to delete expense with SK=%q from table: %w", sk, err)
+		}
+	}
+
+	err := es.updateNameStats(ctx, vaultID, []Expense{exp}, nil)
 	if err != nil {
-		return &NotFoundError{SK: sk}
+		return fmt.Errorf("failed to update name stats: %w", err)
 	}
 
-	_, err = es.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: &es.tableName,
-		Key:       getKey(vaultID, sk),
-	})
+	return nil
+}
 
+// Returns transaction item deleting the expense, as long as its amount and
+// category still match the ones its monthly sum deltas are computed from.
+func (es *DDBStore) deleteUnchanged(exp Expense, vaultID string) (types.TransactWriteItem, error) {
+	expr, err := expression.NewBuilder().WithCondition(unchangedCondition(exp)).Build()
 	if err != nil {
-		return fmt.Errorf("failed to delete expense with SK=%q from table: %w", sk, err)
+		return types.TransactWriteItem{}, fmt.Errorf("failed to build expression for delete: %w", err)
 	}
 
-	err = es.updateMonthlySum(ctx, vaultID, exp.Date, exp.Category)
-	if err != nil {
-		return fmt.Errorf("failed to update monthly sum: %w", err)
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName:                 &es.tableName,
+			Key:                       getKey(vaultID, exp.SK),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ConditionExpression:       expr.Condition(),
+		},
+	}, nil
+}
+
+// versionCondition requires the stored expense to still be at the version of
+// given copy. Expenses written before versioning have no version attribute.
+func versionCondition(exp Expense) expression.ConditionBuilder {
+	if exp.Version == 0 {
+		return expression.AttributeNotExists(expression.Name("version"))
 	}
+	return expression.Name("version").Equal(expression.Value(exp.Version))
+}
 
-	return err
+func unchangedCondition(exp Expense) expression.ConditionBuilder {
+	status := expression.AttributeNotExists(expression.Name("status"))
+	if exp.Status != "" {
+		status = expression.Name("status").Equal(expression.Value(exp.Status))
+	}
+	return expression.Name("amount").Equal(expression.Value(exp.Amount)).
+		And(expression.Name("category").Equal(expression.Value(exp.Category))).
+		And(expression.Name("paymentMethod").Equal(expression.Value(exp.PaymentMethod))).
+		And(status)
 }
 
-func (es *DDBStore) updateMonthlySum(ctx context.Context, vaultID, date, category string) error {
-	yearAndMonth := date[:7]
-	if !helpers.IsValidYYYYMM(yearAndMonth) {
-		return fmt.Errorf("error: expected date in format YYYY-MM, got %s", date)
+// syncSumDeltas returns sum deltas to be written together with the expense
+// change, unless sums are maintained from the table stream.
+func (es *DDBStore) syncSumDeltas(vaultID string, removed, added []Expense) ([]types.TransactWriteItem, error) {
+	if es.streamAggregation {
+		return nil, nil
 	}
+	return es.sumDeltas(vaultID, removed, added)
+}
 
-	sum, err := es.calcMonthlySum(ctx, vaultID, category, date)
-	if err != nil {
-		return fmt.Errorf("failed to calculate monthly sum: %w", err)
+type sumKey struct {
+	pk string
+	sk string
+	// category of per category sums, empty for sums grouped by other
+	// attributes
+	category string
+}
+
+// sumKeys returns keys of the sums the expense counts towards: per category
+// sums of every granularity and monthly sums by payment method and user.
+func sumKeys(vaultID string, exp Expense) ([]sumKey, error) {
+	keys := []sumKey{}
+	for _, g := range Granularities {
+		period, err := g.Period(exp.sumDate())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sumKey{buildSumPK(g, vaultID), buildSumSK(period, exp.Category), exp.Category})
 	}
+	for _, gb := range []GroupBy{GroupByPaymentMethod, GroupByUser} {
+		keys = append(keys, sumKey{pk: buildGroupSumPK(gb, vaultID), sk: buildSumSK(exp.sumDate()[:7], gb.Key(exp))})
+	}
+	return keys, nil
+}
 
-	item, err := attributevalue.MarshalMap(
-		MonthlySum{
-			PK:       buildMonthlySumPK(vaultID),
-			SK:       buildMonthlySumSK(yearAndMonth, category),
-			Sum:      sum,
-			Category: category,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to marshal monthly sum: %w", err)
+// emptiedSumKeys returns keys of the sums removed expenses counted towards
+// that none of added expenses count towards, which may be left empty.
+func emptiedSumKeys(vaultID string, removed, added []Expense) ([]sumKey, error) {
+	kept := map[sumKey]bool{}
+	for _, exp := range added {
+		keys, err := sumKeys(vaultID, exp)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			kept[key] = true
+		}
 	}
 
-	_, err = es.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &es.tableName,
-		Item:      item,
+	emptied := []sumKey{}
+	for _, exp := range removed {
+		keys, err := sumKeys(vaultID, exp)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if !kept[key] {
+				kept[key] = true
+				emptied = append(emptied, key)
+			}
+		}
+	}
+	return emptied, nil
+}
+
+// sumDeltas returns transaction items atomically adding amounts of added
+// expenses to, and subtracting amounts of removed expenses from, the per
+// category sums of every granularity and the monthly sums by payment method
+// and user they count towards. Planned expenses are added to the `planned`
+// attribute of these items instead of `sum`. Deltas cancelling each other out
+// are skipped.
+func (es *DDBStore) sumDeltas(vaultID string, removed, added []Expense) ([]types.TransactWriteItem, error) {
+	deltas := map[sumKey]map[string]float64{}
+	addDelta := func(key sumKey, exp Expense, delta float64) {
+		if deltas[key] == nil {
+			deltas[key] = map[string]float64{}
+		}
+		deltas[key][exp.sumAttribute()] += delta
+	}
+	for _, change := range []struct {
+		expenses []Expense
+		sign     float64
+	}{{removed, -1}, {added, 1}} {
+		for _, exp := range change.expenses {
+			keys, err := sumKeys(vaultID, exp)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				addDelta(key, exp, change.sign*exp.Amount)
+			}
+		}
+	}
+
+	keys := make([]sumKey, 0, len(deltas))
+	for key := range deltas {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pk != keys[j].pk {
+			return keys[i].pk < keys[j].pk
+		}
+		return keys[i].sk < keys[j].sk
 	})
 
-	if err != nil {
-		return fmt.Errorf("failed to put monthly sum into DynamoDB: %w", err)
+	items := []types.TransactWriteItem{}
+	for _, key := range keys {
+		var update expression.UpdateBuilder
+		changed := false
+		for _, attribute := range []string{"sum", "planned"} {
+			delta := math.Round(deltas[key][attribute]*100) / 100
+			if delta == 0 {
+				continue
+			}
+			update = update.Add(expression.Name(attribute), expression.Value(delta))
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		if key.category != "" {
+			update = update.Set(expression.Name("category"), expression.Value(key.category))
+		}
+		expr, err := expression.NewBuilder().WithUpdate(update).Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build expression for sum update: %w", err)
+		}
+
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                 &es.tableName,
+				Key:                       getItemKey(key.pk, key.sk),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+				UpdateExpression:          expr.Update(),
+			},
+		})
 	}
 
-	return nil
+	return items, nil
 }
 
-func (es *DDBStore) calcMonthlySum(ctx context.Context, activeVault, category, dateStr string) (float64, error) {
-	from, to, err := helpers.GetFirstAndLastDayOfMonth(dateStr)
-	if err != nil {
-		return 0, err
+// isTransactionConflict reports whether transaction was canceled because any of
+// its conditions failed or another transaction was writing the same items.
+func isTransactionConflict(err error) bool {
+	var transactionErr *types.TransactionCanceledException
+	if !errors.As(err, &transactionErr) {
+		return false
 	}
-	thisMonthCategoryExpenses, err := es.Query(ctx, from, to, []string{category}, activeVault)
-	if err != nil {
-		return 0, err
+	for _, reason := range transactionErr.CancellationReasons {
+		if code := aws.ToString(reason.Code); code == "ConditionalCheckFailed" || code == "TransactionConflict" {
+			return true
+		}
 	}
+	return false
+}
 
-	var sum float64
-	for _, val := range thisMonthCategoryExpenses {
-		sum += val.Amount
+// Reports whether the transaction was cancelled because the condition of its
+// item at given index failed.
+func isConditionalCheckFailed(err error, index int) bool {
+	var transactionErr *types.TransactionCanceledException
+	if !errors.As(err, &transactionErr) || len(transactionErr.CancellationReasons) <= index {
+		return false
 	}
-
-	return math.Floor(sum*100) / 100, nil
+	code := transactionErr.CancellationReasons[index].Code
+	return code != nil && *code == "ConditionalCheckFailed"
 }
 
 func (es *DDBStore) GetMonthlySums(ctx context.Context, monthsAgo int, vaultID string) ([]MonthlySum, error) {
+	return es.queryMonthlySums(ctx, buildMonthlySumPK(vaultID), monthsAgo)
+}
+
+// GetMonthlySumsBy retrieves monthly sums broken down by given attribute, with
+// Category set to the payment method or user ID they are grouped by.
+func (es *DDBStore) GetMonthlySumsBy(ctx context.Context, gb GroupBy, monthsAgo int, vaultID string) ([]MonthlySum, error) {
+	monthlySums, err := es.queryMonthlySums(ctx, buildGroupSumPK(gb, vaultID), monthsAgo)
+	if err != nil {
+		return nil, err
+	}
+	for i := range monthlySums {
+		monthlySums[i].Category = keyFromSK(monthlySums[i].SK)
+	}
+	return monthlySums, nil
+}
+
+func (es *DDBStore) queryMonthlySums(ctx context.Context, pk string, monthsAgo int) ([]MonthlySum, error) {
 	from := helpers.MonthsAgo(monthsAgo)[:7]
 
 	keyCond := expression.
-		Key("PK").Equal(expression.Value(buildMonthlySumPK(vaultID))).
+		Key("PK").Equal(expression.Value(pk)).
 		And(expression.Key("SK").GreaterThanEqual(expression.Value(from)))
 
 	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
@@ -381,60 +826,249 @@ func (es *DDBStore) GetMonthlySums(ctx context.Context, monthsAgo int, vaultID s
 	return monthlySums, nil
 }
 
-// Retrieves expenses between the given `from` and `to` YYYY-MM-DD dates (inclusive).
-func (es *DDBStore) Query(ctx context.Context, from, to string, categories []string, vaultID string) ([]Expense, error) {
-	daysDiff, err := helpers.DaysBetween(from, to)
+// GetSums retrieves per category sums of periods of given granularity between
+// periods of the `from` and `to` YYYY-MM-DD dates (inclusive).
+func (es *DDBStore) GetSums(ctx context.Context, g Granularity, from, to, vaultID string) ([]PeriodSum, error) {
+	fromPeriod, err := g.Period(from)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get number of days between 'from' and 'to' date: %w", err)
-	}
-	if daysDiff < minQueryRangeDaysDiff || daysDiff > maxQueryRangeDaysDiff {
-		return nil, fmt.Errorf(
-			"invalid difference between 'from' and 'to' date; got=%d, max=%d, min=%d",
-			daysDiff,
-			minQueryRangeDaysDiff,
-			maxQueryRangeDaysDiff,
-		)
+		return nil, err
 	}
-
-	dayAfterTo, err := helpers.NextDay(to)
+	// SKs of the last period are all greater than the bare period following it
+	toPeriod, err := g.periodAfter(to)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get next day for date '%s': %w", to, err)
+		return nil, err
 	}
 
 	keyCond := expression.
-		Key("PK").Equal(expression.Value(buildPK(vaultID))).
-		And(expression.Key("SK").Between(expression.Value(from), expression.Value(dayAfterTo)))
-
-	exprBuilder := expression.NewBuilder().WithKeyCondition(keyCond)
-
-	if len(categories) > 0 {
-		categoryCondition := expression.Name("category").In(expression.Value(categories[0]))
-		for _, category := range categories[1:] {
-			categoryCondition = categoryCondition.Or(expression.Name("category").In(expression.Value(category)))
-		}
-		exprBuilder = exprBuilder.WithFilter(categoryCondition)
-	}
+		Key("PK").Equal(expression.Value(buildSumPK(g, vaultID))).
+		And(expression.Key("SK").Between(expression.Value(fromPeriod), expression.Value(toPeriod)))
 
-	expr, err := exprBuilder.Build()
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build expression for query %w", err)
+		return nil, fmt.Errorf("failed to build expression for %s sums query %w", g, err)
 	}
 
-	return es.query(ctx, expr)
-}
-
-func (es *DDBStore) query(ctx context.Context, expr expression.Expression) ([]Expense, error) {
-	expenses := []Expense{}
+	sums := []PeriodSum{}
 
-	queryInput := dynamodb.QueryInput{
+	queryPaginator := dynamodb.NewQueryPaginator(es.client, &dynamodb.QueryInput{
 		TableName:                 &es.tableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		KeyConditionExpression:    expr.KeyCondition(),
-		FilterExpression:          expr.Filter(),
-	}
-
-	queryPaginator := dynamodb.NewQueryPaginator(es.client, &queryInput)
+	})
+
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query for %s sums: %w", g, err)
+		}
+
+		resSums := []PeriodSum{}
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &resSums); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query response %w", err)
+		}
+
+		for _, sum := range resSums {
+			sum.Period = periodFromSK(sum.SK)
+			sums = append(sums, sum)
+		}
+	}
+
+	return sums, nil
+}
+
+// Suggests category, payment method and amount for an expense with given name,
+// based on stats of previously entered expenses with names starting with it.
+func (es *DDBStore) Suggest(ctx context.Context, name, vaultID string) (Suggestion, error) {
+	prefix := normalizeName(name)
+	if len(prefix) < NameMinLength {
+		return Suggestion{}, nil
+	}
+
+	keyCond := expression.
+		Key("PK").Equal(expression.Value(buildNameStatsPK(vaultID))).
+		And(expression.Key("SK").BeginsWith(prefix))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return Suggestion{}, fmt.Errorf("failed to build expression for name stats query %w", err)
+	}
+
+	response, err := es.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 &es.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		Limit:                     aws.Int32(suggestionMaxNames),
+	})
+	if err != nil {
+		return Suggestion{}, fmt.Errorf("failed to query for name stats: %w", err)
+	}
+
+	stats := []NameStats{}
+	err = attributevalue.UnmarshalListOfMaps(response.Items, &stats)
+	if err != nil {
+		return Suggestion{}, fmt.Errorf("failed to unmarshal name stats: %w", err)
+	}
+
+	return Suggest(stats, helpers.DaysAgo(0)), nil
+}
+
+func (es *DDBStore) updateNameStats(ctx context.Context, vaultID string, removed, added []Expense) error {
+	if es.streamAggregation {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		items, err := es.nameStatsWrites(ctx, vaultID, removed, added)
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			return nil
+		}
+		if attempt == nameStatsMaxAttempts || !isTransactionConflict(err) {
+			return fmt.Errorf("failed to write name stats: %w", err)
+		}
+	}
+}
+
+// nameStatsWrites returns transaction items replacing name stats affected by
+// removed and added expenses, deleting the ones left empty. Each item is
+// written only if it wasn't changed since it was read.
+func (es *DDBStore) nameStatsWrites(ctx context.Context, vaultID string, removed, added []Expense) ([]types.TransactWriteItem, error) {
+	items := []types.TransactWriteItem{}
+	for key, change := range groupNameStatsChanges(removed, added) {
+		stats, err := es.findNameStats(ctx, vaultID, key)
+		if err != nil {
+			return nil, err
+		}
+
+		cond := expression.AttributeNotExists(expression.Name("version"))
+		if stats.Version != 0 {
+			cond = expression.Name("version").Equal(expression.Value(stats.Version))
+		}
+		expr, err := expression.NewBuilder().WithCondition(cond).Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build expression for name stats write: %w", err)
+		}
+
+		for _, exp := range change[0] {
+			stats.remove(exp)
+		}
+		for _, exp := range change[1] {
+			stats.add(exp)
+		}
+
+		if stats.isEmpty() {
+			items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+				TableName:                 &es.tableName,
+				Key:                       getNameStatsKey(vaultID, key),
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			}})
+			continue
+		}
+
+		stats.Version++
+		item, err := attributevalue.MarshalMap(stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal name stats: %w", err)
+		}
+
+		items = append(items, types.TransactWriteItem{Put: &types.Put{
+			TableName:                 &es.tableName,
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}})
+	}
+
+	return items, nil
+}
+
+func (es *DDBStore) findNameStats(ctx context.Context, vaultID, key string) (NameStats, error) {
+	stats := newNameStats(vaultID, key)
+
+	response, err := es.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &es.tableName,
+		Key:            getNameStatsKey(vaultID, key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return NameStats{}, fmt.Errorf("GetItem DynamoDB operation failed for name stats %q: %w", key, err)
+	}
+
+	if len(response.Item) == 0 {
+		return stats, nil
+	}
+
+	err = attributevalue.UnmarshalMap(response.Item, &stats)
+	if err != nil {
+		return NameStats{}, fmt.Errorf("failed to unmarshal name stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Retrieves expenses between the given `from` and `to` YYYY-MM-DD dates (inclusive).
+func (es *DDBStore) Query(ctx context.Context, from, to string, categories []string, vaultID string) ([]Expense, error) {
+	daysDiff, err := helpers.DaysBetween(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get number of days between 'from' and 'to' date: %w", err)
+	}
+	if daysDiff < minQueryRangeDaysDiff || daysDiff > maxQueryRangeDaysDiff {
+		return nil, fmt.Errorf(
+			"invalid difference between 'from' and 'to' date; got=%d, max=%d, min=%d",
+			daysDiff,
+			minQueryRangeDaysDiff,
+			maxQueryRangeDaysDiff,
+		)
+	}
+
+	dayAfterTo, err := helpers.NextDay(to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next day for date '%s': %w", to, err)
+	}
+
+	keyCond := expression.
+		Key("PK").Equal(expression.Value(buildPK(vaultID))).
+		And(expression.Key("SK").Between(expression.Value(from), expression.Value(dayAfterTo)))
+
+	exprBuilder := expression.NewBuilder().WithKeyCondition(keyCond)
+
+	if len(categories) > 0 {
+		categoryCondition := expression.Name("category").In(expression.Value(categories[0]))
+		for _, category := range categories[1:] {
+			categoryCondition = categoryCondition.Or(expression.Name("category").In(expression.Value(category)))
+		}
+		exprBuilder = exprBuilder.WithFilter(categoryCondition)
+	}
+
+	expr, err := exprBuilder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for query %w", err)
+	}
+
+	return es.query(ctx, expr)
+}
+
+func (es *DDBStore) query(ctx context.Context, expr expression.Expression) ([]Expense, error) {
+	expenses := []Expense{}
+
+	queryInput := dynamodb.QueryInput{
+		TableName:                 &es.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(es.client, &queryInput)
 
 	for queryPaginator.HasMorePages() {
 		response, err := queryPaginator.NextPage(ctx)
@@ -460,17 +1094,161 @@ func (es *DDBStore) query(ctx context.Context, expr expression.Expression) ([]Ex
 	return expenses, nil
 }
 
-func (es *DDBStore) validateExpenseLimit(ctx context.Context, dateStr, vaultID string) error {
-	count, err := es.countExpensesInMonth(ctx, dateStr, vaultID)
+// GetUsage returns the number of expenses dated in the month of given date,
+// against the vault's monthly limit.
+func (es *DDBStore) GetUsage(ctx context.Context, date, vaultID string) (Usage, error) {
+	limit, err := es.GetMonthLimit(ctx, vaultID)
+	if err != nil {
+		return Usage{}, err
+	}
+	count, err := es.ensureMonthCount(ctx, date, vaultID)
+	if err != nil {
+		return Usage{}, err
+	}
+	return newUsage(date[:7], count, limit), nil
+}
+
+// GetMonthLimit returns the number of expenses the vault is allowed to have in
+// a month, falling back to the store's default when not set.
+func (es *DDBStore) GetMonthLimit(ctx context.Context, vaultID string) (int, error) {
+	response, err := es.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &es.tableName,
+		Key:       getItemKey(buildExpenseCountPK(vaultID), monthLimitSK),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get monthly expense limit: %w", err)
+	}
+	if len(response.Item) == 0 {
+		return es.expenseCountMonthLimit, nil
+	}
+
+	var limit monthLimit
+	if err = attributevalue.UnmarshalMap(response.Item, &limit); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal monthly expense limit: %w", err)
+	}
+	return limit.Limit, nil
+}
+
+// SetMonthLimit changes the number of expenses the vault is allowed to have in
+// a month. Months already above the new limit keep their expenses.
+func (es *DDBStore) SetMonthLimit(ctx context.Context, limit int, vaultID string) error {
+	item, err := attributevalue.MarshalMap(monthLimit{PK: buildExpenseCountPK(vaultID), SK: monthLimitSK, Limit: limit})
 	if err != nil {
-		return fmt.Errorf("failed to fetch expense count for month in date %s in vault %s", dateStr, vaultID)
+		return fmt.Errorf("failed to marshal monthly expense limit: %w", err)
 	}
-	if count >= es.expenseCountMonthLimit {
-		return &MaxMonthExpenseCountExceededError{Month: dateStr, Vault: vaultID}
+	_, err = es.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &es.tableName,
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put monthly expense limit: %w", err)
 	}
 	return nil
 }
 
+// countChanges returns transaction items moving the expense between monthly
+// expense counters, incrementing first. The increment's condition fails once
+// the month reaches the vault's limit.
+func (es *DDBStore) countChanges(ctx context.Context, vaultID string, removed, added *Expense) ([]types.TransactWriteItem, error) {
+	if removed != nil && added != nil && removed.Date[:7] == added.Date[:7] {
+		return nil, nil
+	}
+
+	items := []types.TransactWriteItem{}
+	if added != nil {
+		limit, err := es.GetMonthLimit(ctx, vaultID)
+		if err != nil {
+			return nil, err
+		}
+		item, err := es.countDelta(ctx, vaultID, added.Date, 1, &limit)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if removed != nil {
+		item, err := es.countDelta(ctx, vaultID, removed.Date, -1, nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (es *DDBStore) countDelta(ctx context.Context, vaultID, date string, delta int, limit *int) (types.TransactWriteItem, error) {
+	if _, err := es.ensureMonthCount(ctx, date, vaultID); err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	builder := expression.NewBuilder().WithUpdate(expression.Add(expression.Name("count"), expression.Value(delta)))
+	if limit != nil {
+		builder = builder.WithCondition(expression.Name("count").LessThan(expression.Value(*limit)))
+	}
+	expr, err := builder.Build()
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("failed to build expression for expense count update: %w", err)
+	}
+
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 &es.tableName,
+			Key:                       getItemKey(buildExpenseCountPK(vaultID), date[:7]),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+		},
+	}, nil
+}
+
+// ensureMonthCount returns the counter of expenses dated in the month of given
+// date, creating it by counting the expenses for months written before
+// counters were kept.
+func (es *DDBStore) ensureMonthCount(ctx context.Context, date, vaultID string) (int, error) {
+	key := getItemKey(buildExpenseCountPK(vaultID), date[:7])
+	for {
+		response, err := es.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      &es.tableName,
+			Key:            key,
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get expense count for month %s: %w", date[:7], err)
+		}
+		if len(response.Item) > 0 {
+			var count monthCount
+			if err = attributevalue.UnmarshalMap(response.Item, &count); err != nil {
+				return 0, fmt.Errorf("failed to unmarshal expense count: %w", err)
+			}
+			return count.Count, nil
+		}
+
+		counted, err := es.countExpensesInMonth(ctx, date, vaultID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch expense count for month in date %s in vault %s: %w", date, vaultID, err)
+		}
+		item, err := attributevalue.MarshalMap(monthCount{PK: buildExpenseCountPK(vaultID), SK: date[:7], Count: counted})
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal expense count: %w", err)
+		}
+		_, err = es.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           &es.tableName,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(SK)"),
+		})
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			// created concurrently, read it again
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to put expense count: %w", err)
+		}
+		return counted, nil
+	}
+}
+
 func (es *DDBStore) countExpensesInMonth(ctx context.Context, dateStr, vaultID string) (int, error) {
 	from, to, err := helpers.GetFirstAndLastDayOfMonth(dateStr)
 	if err != nil {
@@ -506,3 +1284,434 @@ func (es *DDBStore) countExpensesInMonth(ctx context.Context, dateStr, vaultID s
 
 	return int(output.Count), nil
 }
+
+// Recategorize moves up to limit expenses from one category to another,
+// starting after the cursor SK, and refreshes monthly sums and name stats of
+// affected expenses. Expenses already moved are skipped, so a batch interrupted
+// midway can be safely repeated.
+func (es *DDBStore) Recategorize(ctx context.Context, from, to, cursor string, limit int, vaultID string) (RecategorizeResult, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildPK(vaultID)))
+	filter := expression.Name("category").Equal(expression.Value(from))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithFilter(filter).Build()
+	if err != nil {
+		return RecategorizeResult{}, fmt.Errorf("failed to build expression for recategorize query: %w", err)
+	}
+
+	queryInput := dynamodb.QueryInput{
+		TableName:                 &es.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		Limit:                     aws.Int32(int32(limit)),
+	}
+	if cursor != "" {
+		queryInput.ExclusiveStartKey = getKey(vaultID, cursor)
+	}
+
+	response, err := es.client.Query(ctx, &queryInput)
+	if err != nil {
+		return RecategorizeResult{}, fmt.Errorf("failed to query expenses for recategorize: %w", err)
+	}
+
+	expenses := []Expense{}
+	err = attributevalue.UnmarshalListOfMaps(response.Items, &expenses)
+	if err != nil {
+		return RecategorizeResult{}, fmt.Errorf("failed to unmarshal query response %w", err)
+	}
+
+	result := RecategorizeResult{Cursor: cursor, Done: len(response.LastEvaluatedKey) == 0}
+	if !result.Done {
+		var lastKey struct{ SK string }
+		if err := attributevalue.UnmarshalMap(response.LastEvaluatedKey, &lastKey); err != nil {
+			return RecategorizeResult{}, fmt.Errorf("failed to unmarshal last evaluated key: %w", err)
+		}
+		result.Cursor = lastKey.SK
+	}
+
+	moved := []Expense{}
+	for _, exp := range expenses {
+		movedExp := exp
+		movedExp.Category = to
+
+		ok, err := es.recategorizeOne(ctx, exp, movedExp, vaultID)
+		if err != nil {
+			return RecategorizeResult{}, err
+		}
+		if !ok {
+			// expense was edited or deleted in the meantime
+			continue
+		}
+
+		moved = append(moved, movedExp)
+		result.Updated++
+	}
+
+	removed := []Expense{}
+	for _, exp := range moved {
+		exp.Category = from
+		removed = append(removed, exp)
+	}
+
+	// with stream aggregation, emptied sums are deleted by the stream worker
+	if !es.streamAggregation {
+		if err := es.deleteEmptySums(ctx, vaultID, removed, moved); err != nil {
+			return RecategorizeResult{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// recategorizeOne moves a single expense to the category of moved, together
+// with its sums and name stats. It returns false if the expense was changed
+// since it was read.
+func (es *DDBStore) recategorizeOne(ctx context.Context, exp, moved Expense, vaultID string) (bool, error) {
+	update := expression.
+		Set(expression.Name("category"), expression.Value(moved.Category)).
+		Add(expression.Name("version"), expression.Value(1))
+	updateExpr, err := expression.NewBuilder().WithUpdate(update).WithCondition(unchangedCondition(exp)).Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build expression for recategorize update: %w", err)
+	}
+
+	sumItems, err := es.syncSumDeltas(vaultID, []Expense{exp}, []Expense{moved})
+	if err != nil {
+		return false, err
+	}
+
+	updateItem := types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 &es.tableName,
+			Key:                       getKey(vaultID, exp.SK),
+			ExpressionAttributeNames:  updateExpr.Names(),
+			ExpressionAttributeValues: updateExpr.Values(),
+			UpdateExpression:          updateExpr.Update(),
+			ConditionExpression:       updateExpr.Condition(),
+		},
+	}
+
+	for attempt := 1; ; attempt++ {
+		items := append([]types.TransactWriteItem{updateItem}, sumItems...)
+		if !es.streamAggregation {
+			nameStatsItems, err := es.nameStatsWrites(ctx, vaultID, []Expense{exp}, []Expense{moved})
+			if err != nil {
+				return false, err
+			}
+			items = append(items, nameStatsItems...)
+		}
+
+		_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		if err == nil {
+			return true, nil
+		}
+		if isConditionalCheckFailed(err, 0) {
+			return false, nil
+		}
+		if attempt == nameStatsMaxAttempts || !isTransactionConflict(err) {
+			return false, fmt.Errorf("failed to recategorize expense %q: %w", exp.SK, err)
+		}
+	}
+}
+
+// deleteEmptySums removes sums removed expenses counted towards, and added
+// ones don't, once no expenses are left in them, so emptied categories,
+// payment methods and users disappear from charts.
+func (es *DDBStore) deleteEmptySums(ctx context.Context, vaultID string, removed, added []Expense) error {
+	keys, err := emptiedSumKeys(vaultID, removed, added)
+	if err != nil {
+		return err
+	}
+
+	expr, err := expression.NewBuilder().
+		WithCondition(isZero("sum").And(isZero("planned"))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for sum delete: %w", err)
+	}
+
+	for _, key := range keys {
+		_, err = es.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:                 &es.tableName,
+			Key:                       getItemKey(key.pk, key.sk),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ConditionExpression:       expr.Condition(),
+		})
+		if err != nil {
+			var condErr *types.ConditionalCheckFailedException
+			if errors.As(err, &condErr) {
+				continue
+			}
+			return fmt.Errorf("failed to delete sum %q of %q: %w", key.sk, key.pk, err)
+		}
+	}
+	return nil
+}
+
+// storedAs matches sum attributes holding given value, which for zero includes
+// attributes that were never written.
+func storedAs(attribute string, value float64) expression.ConditionBuilder {
+	if value == 0 {
+		return isZero(attribute)
+	}
+	return expression.Name(attribute).Equal(expression.Value(value))
+}
+
+// isZero matches sum attributes that are zero or were never written, e.g.
+// `planned` of sums having no planned expenses.
+func isZero(attribute string) expression.ConditionBuilder {
+	return expression.AttributeNotExists(expression.Name(attribute)).
+		Or(expression.Name(attribute).Equal(expression.Value(0)))
+}
+
+// CountByCategory counts all expenses in the vault assigned to given category.
+func (es *DDBStore) CountByCategory(ctx context.Context, category, vaultID string) (int, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildPK(vaultID)))
+	filter := expression.Name("category").Equal(expression.Value(category))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithFilter(filter).Build()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build expression for count query: %w", err)
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(es.client, &dynamodb.QueryInput{
+		TableName:                 &es.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		Select:                    types.SelectCount,
+	})
+
+	count := 0
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count expenses in category %q: %w", category, err)
+		}
+		count += int(response.Count)
+	}
+
+	return count, nil
+}
+
+// CountCategories counts expenses in the vault per category name.
+func (es *DDBStore) CountCategories(ctx context.Context, vaultID string) (map[string]int, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildPK(vaultID)))
+	projection := expression.NamesList(expression.Name("category"))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithProjection(projection).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for category count query: %w", err)
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(es.client, &dynamodb.QueryInput{
+		TableName:                 &es.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ProjectionExpression:      expr.Projection(),
+	})
+
+	counts := map[string]int{}
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query expense categories: %w", err)
+		}
+
+		items := []struct {
+			Category string `dynamodbav:"category"`
+		}{}
+		err = attributevalue.UnmarshalListOfMaps(response.Items, &items)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query response %w", err)
+		}
+		for _, item := range items {
+			counts[item.Category]++
+		}
+	}
+
+	return counts, nil
+}
+
+// ReconcileSums recomputes per category sums of every granularity and monthly
+// sums by payment method and user of the vault from its expenses and, if
+// repair is set, overwrites the ones that differ, removing sums with no
+// expenses left. Missing sums are created, which also backfills sums written
+// before their granularity or grouping existed.
+func (es *DDBStore) ReconcileSums(ctx context.Context, vaultID string, repair bool) (ReconcileResult, error) {
+	// Sums are read before expenses, so that an expense written in between is
+	// reported as a discrepancy its repair then skips, as the sum it was
+	// compared against has already changed.
+	stored := map[Granularity][]PeriodSum{}
+	for _, g := range Granularities {
+		sums, err := es.queryAllSums(ctx, buildSumPK(g, vaultID))
+		if err != nil {
+			return ReconcileResult{}, err
+		}
+		stored[g] = sums
+	}
+	storedGroups := map[GroupBy][]PeriodSum{}
+	for _, gb := range []GroupBy{GroupByPaymentMethod, GroupByUser} {
+		sums, err := es.queryAllSums(ctx, buildGroupSumPK(gb, vaultID))
+		if err != nil {
+			return ReconcileResult{}, err
+		}
+		storedGroups[gb] = sums
+	}
+
+	expensesKeyCond := expression.Key("PK").Equal(expression.Value(buildPK(vaultID)))
+	expensesExpr, err := expression.NewBuilder().WithKeyCondition(expensesKeyCond).Build()
+	if err != nil {
+		return ReconcileResult{}, fmt.Errorf("failed to build expression for query %w", err)
+	}
+	expenses, err := es.query(ctx, expensesExpr)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+
+	result := ReconcileResult{VaultID: vaultID, Discrepancies: []SumDiscrepancy{}}
+	for _, g := range Granularities {
+		checked, discrepancies, err := CompareSums(g, stored[g], expenses)
+		if err != nil {
+			return ReconcileResult{}, err
+		}
+		result.Checked += checked
+		result.Discrepancies = append(result.Discrepancies, discrepancies...)
+	}
+	for _, gb := range []GroupBy{GroupByPaymentMethod, GroupByUser} {
+		checked, discrepancies, err := CompareGroupSums(gb, storedGroups[gb], expenses)
+		if err != nil {
+			return ReconcileResult{}, err
+		}
+		result.Checked += checked
+		result.Discrepancies = append(result.Discrepancies, discrepancies...)
+	}
+	if !repair {
+		return result, nil
+	}
+
+	for _, d := range result.Discrepancies {
+		repaired, err := es.repairSum(ctx, vaultID, d)
+		if err != nil {
+			return result, err
+		}
+		if repaired {
+			result.Repaired++
+		}
+	}
+
+	return result, nil
+}
+
+// Queries all sums stored under given PK.
+func (es *DDBStore) queryAllSums(ctx context.Context, pk string) ([]PeriodSum, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(pk))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for sums query %w", err)
+	}
+
+	sums := []PeriodSum{}
+	queryPaginator := dynamodb.NewQueryPaginator(es.client, &dynamodb.QueryInput{
+		TableName:                 &es.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query for sums: %w", err)
+		}
+		resSums := []PeriodSum{}
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &resSums); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query response %w", err)
+		}
+		for _, sum := range resSums {
+			sum.Period = periodFromSK(sum.SK)
+			sums = append(sums, sum)
+		}
+	}
+
+	return sums, nil
+}
+
+// Overwrites the sum and planned sum with their actual values, unless they were
+// changed since the discrepancy was found.
+func (es *DDBStore) repairSum(ctx context.Context, vaultID string, d SumDiscrepancy) (bool, error) {
+	sum := PeriodSum{SK: buildSumSK(d.Period, d.Category), Sum: d.Actual, Planned: d.ActualPlanned}
+	if g, ok := ParseGranularity(d.Sums); ok {
+		sum.PK, sum.Category = buildSumPK(g, vaultID), d.Category
+	} else if gb, ok := ParseGroupBy(d.Sums); ok {
+		sum.PK = buildGroupSumPK(gb, vaultID)
+	} else {
+		return false, fmt.Errorf("unknown sums %q", d.Sums)
+	}
+	sk := sum.SK
+	key := getItemKey(sum.PK, sk)
+	unchanged := storedAs("sum", d.Stored).And(storedAs("planned", d.Planned))
+
+	var err error
+	switch {
+	case d.Missing:
+		var item map[string]types.AttributeValue
+		item, err = attributevalue.MarshalMap(sum)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal sum: %w", err)
+		}
+		if sum.Category == "" {
+			// sums grouped by other attributes have no category
+			delete(item, "category")
+		}
+		_, err = es.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           &es.tableName,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(SK)"),
+		})
+	case d.Actual == 0 && d.ActualPlanned == 0:
+		var expr expression.Expression
+		expr, err = expression.NewBuilder().WithCondition(unchanged).Build()
+		if err != nil {
+			return false, fmt.Errorf("failed to build expression for sum delete: %w", err)
+		}
+		_, err = es.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:                 &es.tableName,
+			Key:                       key,
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ConditionExpression:       expr.Condition(),
+		})
+	default:
+		var expr expression.Expression
+		expr, err = expression.NewBuilder().
+			WithUpdate(expression.
+				Set(expression.Name("sum"), expression.Value(d.Actual)).
+				Set(expression.Name("planned"), expression.Value(d.ActualPlanned))).
+			WithCondition(unchanged).
+			Build()
+		if err != nil {
+			return false, fmt.Errorf("failed to build expression for sum update: %w", err)
+		}
+		_, err = es.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 &es.tableName,
+			Key:                       key,
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+		})
+	}
+
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to repair %s sum %q: %w", d.Sums, sk, err)
+	}
+	return true, nil
+}
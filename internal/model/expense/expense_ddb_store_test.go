@@ -3,10 +3,15 @@ package expense_test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
 	"github.com/kkstas/tener/internal/database"
 	"github.com/kkstas/tener/internal/helpers"
 	"github.com/kkstas/tener/internal/model/expense"
@@ -254,6 +259,25 @@ func TestDDBUpdate(t *testing.T) {
 			}
 		})
 
+		t.Run("rejects update of stale copy with the current one", func(t *testing.T) {
+			stale := createDefaultDDBExpenseHelper(ctx, t, store)
+
+			edited := stale
+			edited.Name = "edited name"
+			if err := store.Update(ctx, edited, ddbStoreVaultID); err != nil {
+				t.Fatalf("didn't expect an error but got one: %v", err)
+			}
+
+			stale.Date = helpers.DaysAgo(1)
+			err := store.Update(ctx, stale, ddbStoreVaultID)
+			var conflictErr *expense.VersionConflictError
+			if !errors.As(err, &conflictErr) {
+				t.Fatalf("got %#v, want %#v", err, &expense.VersionConflictError{})
+			}
+			assertEqual(t, conflictErr.Current.Name, edited.Name)
+			assertEqual(t, conflictErr.Current.Version, stale.Version+1)
+		})
+
 		t.Run("updates monthly sums for old and new month, if date month has been changed", func(t *testing.T) {
 			category := "randomcategory"
 			date1 := helpers.DaysAgo(1)
@@ -677,6 +701,115 @@ func TestDDBQuery(t *testing.T) {
 	})
 }
 
+func TestDDBSuggest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	createDDBExpenseHelper(ctx, t, store, "Biedronka", helpers.DaysAgo(0), "Groceries", 21.37, expense.PaymentMethods[2])
+	createDDBExpenseHelper(ctx, t, store, "biedronka ", helpers.DaysAgo(1), "Groceries", 21.37, expense.PaymentMethods[2])
+	other := createDDBExpenseHelper(ctx, t, store, "Biedronka", helpers.DaysAgo(2), "Household", 5, expense.PaymentMethods[0])
+
+	t.Run("suggests most likely details for name prefix", func(t *testing.T) {
+		suggestion, err := store.Suggest(ctx, "bied", ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, suggestion.Found, true)
+		assertEqual(t, suggestion.Category, "Groceries")
+		assertEqual(t, suggestion.PaymentMethod, expense.PaymentMethods[2])
+		assertEqual(t, suggestion.Amount, 21.37)
+	})
+
+	t.Run("does not suggest for unknown names", func(t *testing.T) {
+		suggestion, err := store.Suggest(ctx, "Lidl", ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, suggestion.Found, false)
+	})
+
+	t.Run("updates stats when expense changes", func(t *testing.T) {
+		for _, exp := range []string{other.SK} {
+			err := store.Delete(ctx, exp, ddbStoreVaultID)
+			if err != nil {
+				t.Fatalf("didn't expect an error but got one: %v", err)
+			}
+		}
+
+		expenses, err := store.Query(ctx, helpers.DaysAgo(1), helpers.DaysAgo(0), []string{}, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		for _, exp := range expenses {
+			exp.Category = "Food"
+			if err := store.Update(ctx, exp, ddbStoreVaultID); err != nil {
+				t.Fatalf("didn't expect an error but got one: %v", err)
+			}
+		}
+
+		suggestion, err := store.Suggest(ctx, "Biedronka", ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, suggestion.Category, "Food")
+	})
+}
+
+func TestDDBRecategorize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	for range 3 {
+		createDDBExpenseHelper(ctx, t, store, "Orlen", helpers.DaysAgo(0), "Fuel", 100, expense.PaymentMethods[0])
+	}
+	createDDBExpenseHelper(ctx, t, store, "Lidl", helpers.DaysAgo(0), "Food", 10, expense.PaymentMethods[0])
+
+	count, err := store.CountByCategory(ctx, "Fuel", ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, count, 3)
+
+	cursor, moved := "", 0
+	for {
+		result, err := store.Recategorize(ctx, "Fuel", "Gas", cursor, 2, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		moved += result.Updated
+		cursor = result.Cursor
+		if result.Done {
+			break
+		}
+	}
+	assertEqual(t, moved, 3)
+
+	sums, err := store.GetMonthlySums(ctx, 1, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	for _, sum := range sums {
+		if sum.Category == "Fuel" {
+			t.Errorf("expected monthly sum of emptied category to be removed, got %#v", sum)
+		}
+		if sum.Category == "Gas" {
+			assertEqual(t, sum.Sum, 300.0)
+		}
+	}
+}
+
 func createDefaultDDBExpenseHelper(ctx context.Context, t testing.TB, store *expense.DDBStore) expense.Expense {
 	t.Helper()
 	return createDDBExpenseHelper(ctx, t,
@@ -725,3 +858,477 @@ func assertValidTime(t testing.TB, layout, datestring string) {
 		t.Errorf("string '%s' is not valid datetime: %v", datestring, err)
 	}
 }
+
+func TestDDBMonthlySumDeltas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	monthlySum := func(t *testing.T, category string) float64 {
+		t.Helper()
+		sums, err := store.GetMonthlySums(ctx, 1, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		for _, s := range sums {
+			if s.SK == helpers.DaysAgo(0)[:7]+"::"+category {
+				return s.Sum
+			}
+		}
+		return 0
+	}
+
+	const concurrentWrites = 10
+	created := make([]expense.Expense, concurrentWrites)
+	errs := make(chan error, concurrentWrites)
+	var wg sync.WaitGroup
+	for i := range concurrentWrites {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			expenseFC, _, _ := expense.New(validDDBExpenseName, helpers.DaysAgo(0), validDDBExpenseCategory, 0.1, expense.PaymentMethods[0])
+			var err error
+			created[i], err = store.Create(ctx, expenseFC, "userID", ddbStoreVaultID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	t.Run("adds amounts of concurrently created expenses", func(t *testing.T) {
+		assertEqual(t, monthlySum(t, validDDBExpenseCategory), 1.0)
+	})
+
+	t.Run("moves amount between categories on update", func(t *testing.T) {
+		expenseFU := created[0]
+		expenseFU.Category = validDDBExpenseCategory2
+		expenseFU.Amount = 5
+		if err := store.Update(ctx, expenseFU, ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, monthlySum(t, validDDBExpenseCategory), 0.9)
+		assertEqual(t, monthlySum(t, validDDBExpenseCategory2), 5.0)
+	})
+
+	t.Run("subtracts amount on delete", func(t *testing.T) {
+		if err := store.Delete(ctx, created[1].SK, ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, monthlySum(t, validDDBExpenseCategory), 0.8)
+	})
+}
+
+func TestDDBGetSums(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, "2026-10-19", validDDBExpenseCategory, 10, expense.PaymentMethods[0])
+	createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, "2026-10-25", validDDBExpenseCategory, 20, expense.PaymentMethods[0])
+	createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, "2026-10-26", validDDBExpenseCategory2, 5, expense.PaymentMethods[0])
+	moved := createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, "2026-10-27", validDDBExpenseCategory, 7, expense.PaymentMethods[0])
+
+	moved.Date = "2025-12-31"
+	if err := store.Update(ctx, moved, ddbStoreVaultID); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	cases := []struct {
+		granularity expense.Granularity
+		from, to    string
+		want        map[string]float64
+	}{
+		{expense.GranularityDay, "2026-10-19", "2026-10-25", map[string]float64{
+			"2026-10-19::" + validDDBExpenseCategory: 10,
+			"2026-10-25::" + validDDBExpenseCategory: 20,
+		}},
+		{expense.GranularityWeek, "2026-10-19", "2026-10-26", map[string]float64{
+			"2026-W43::" + validDDBExpenseCategory:  30,
+			"2026-W44::" + validDDBExpenseCategory:  0,
+			"2026-W44::" + validDDBExpenseCategory2: 5,
+		}},
+		{expense.GranularityYear, "2025-01-01", "2026-12-31", map[string]float64{
+			"2025::" + validDDBExpenseCategory:  7,
+			"2026::" + validDDBExpenseCategory:  30,
+			"2026::" + validDDBExpenseCategory2: 5,
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.granularity), func(t *testing.T) {
+			sums, err := store.GetSums(ctx, tc.granularity, tc.from, tc.to, ddbStoreVaultID)
+			if err != nil {
+				t.Fatalf("didn't expect an error but got one: %v", err)
+			}
+			got := map[string]float64{}
+			for _, s := range sums {
+				got[s.Period+"::"+s.Category] = s.Sum
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDDBGetMonthlySumsBy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, helpers.DaysAgo(0), validDDBExpenseCategory, 10, expense.PaymentMethods[0])
+	changed := createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, helpers.DaysAgo(0), validDDBExpenseCategory2, 20, expense.PaymentMethods[0])
+
+	changed.PaymentMethod = expense.PaymentMethods[1]
+	if err := store.Update(ctx, changed, ddbStoreVaultID); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	sumsBy := func(t *testing.T, gb expense.GroupBy) map[string]float64 {
+		t.Helper()
+		sums, err := store.GetMonthlySumsBy(ctx, gb, 1, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		got := map[string]float64{}
+		for _, s := range sums {
+			got[s.Category] = s.Sum
+		}
+		return got
+	}
+
+	t.Run("sums up by payment method", func(t *testing.T) {
+		want := map[string]float64{expense.PaymentMethods[0]: 10, expense.PaymentMethods[1]: 20}
+		if got := sumsBy(t, expense.GroupByPaymentMethod); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("sums up by user", func(t *testing.T) {
+		want := map[string]float64{"userID": 30}
+		if got := sumsBy(t, expense.GroupByUser); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestDDBMonthLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	first := createDefaultDDBExpenseHelper(ctx, t, store)
+	createDefaultDDBExpenseHelper(ctx, t, store)
+
+	if err := store.SetMonthLimit(ctx, 2, ddbStoreVaultID); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	usage, err := store.GetUsage(ctx, helpers.DaysAgo(0), ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, usage, expense.Usage{Month: helpers.DaysAgo(0)[:7], Count: 2, Limit: 2, NearLimit: true})
+
+	expenseFC, _, _ := expense.New(validDDBExpenseName, helpers.DaysAgo(0), validDDBExpenseCategory, validDDBExpenseAmount, expense.PaymentMethods[0])
+	_, err = store.Create(ctx, expenseFC, "userID", ddbStoreVaultID)
+	var maxCountErr *expense.MaxMonthExpenseCountExceededError
+	if !errors.As(err, &maxCountErr) {
+		t.Fatalf("got %#v, want %#v", err, &expense.MaxMonthExpenseCountExceededError{})
+	}
+
+	if err := store.Delete(ctx, first.SK, ddbStoreVaultID); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	createDefaultDDBExpenseHelper(ctx, t, store)
+
+	usage, err = store.GetUsage(ctx, helpers.DaysAgo(0), ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, usage.Count, 2)
+}
+
+func TestDDBReconcileSums(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	created := createDefaultDDBExpenseHelper(ctx, t, store)
+
+	month := created.Date[:7]
+	for sk, sum := range map[string]string{month + "::" + validDDBExpenseCategory: "1", month + "::Deleted": "0"} {
+		_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: &tableName,
+			Item: map[string]types.AttributeValue{
+				"PK":       &types.AttributeValueMemberS{Value: "monthlysum::" + ddbStoreVaultID},
+				"SK":       &types.AttributeValueMemberS{Value: sk},
+				"category": &types.AttributeValueMemberS{Value: sk[len(month)+2:]},
+				"sum":      &types.AttributeValueMemberN{Value: sum},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to put stale monthly sum: %v", err)
+		}
+	}
+	// planned sum left behind without planned expenses
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &tableName,
+		Item: map[string]types.AttributeValue{
+			"PK":       &types.AttributeValueMemberS{Value: "monthlysum::" + ddbStoreVaultID},
+			"SK":       &types.AttributeValueMemberS{Value: month + "::Planned"},
+			"category": &types.AttributeValueMemberS{Value: "Planned"},
+			"planned":  &types.AttributeValueMemberN{Value: "40"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to put stale planned sum: %v", err)
+	}
+	// sums of an expense written before daily sums and sums by payment method
+	// existed
+	for pk, sk := range map[string]string{
+		"dailysum::" + ddbStoreVaultID:         created.Date + "::" + validDDBExpenseCategory,
+		"paymentmethodsum::" + ddbStoreVaultID: month + "::" + created.PaymentMethod,
+	} {
+		_, err = client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: &tableName,
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: pk},
+				"SK": &types.AttributeValueMemberS{Value: sk},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to delete sum: %v", err)
+		}
+	}
+
+	result, err := store.ReconcileSums(ctx, ddbStoreVaultID, false)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, len(result.Discrepancies), 5)
+	assertEqual(t, result.Repaired, 0)
+
+	result, err = store.ReconcileSums(ctx, ddbStoreVaultID, true)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, result.Repaired, 5)
+
+	result, err = store.ReconcileSums(ctx, ddbStoreVaultID, false)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, len(result.Discrepancies), 0)
+	assertEqual(t, result.Checked, len(expense.Granularities)+2)
+
+	sums, err := store.GetSums(ctx, expense.GranularityDay, created.Date, created.Date, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(sums) != 1 || sums[0].Sum != created.Amount {
+		t.Errorf("expected daily sum to be backfilled, got %#v", sums)
+	}
+
+	groupSums, err := store.GetMonthlySumsBy(ctx, expense.GroupByPaymentMethod, 1, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(groupSums) != 1 || groupSums[0].Category != created.PaymentMethod || groupSums[0].Sum != created.Amount {
+		t.Errorf("expected payment method sum to be backfilled, got %#v", groupSums)
+	}
+}
+
+func TestDDBApplyStreamChange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStoreWithStreamAggregation(tableName, client)
+
+	created := createDefaultDDBExpenseHelper(ctx, t, store)
+
+	sums, err := store.GetMonthlySums(ctx, 1, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, len(sums), 0)
+
+	change := expense.StreamChange{EventID: "event-1", New: &created}
+	for range 2 {
+		if err := store.ApplyStreamChange(ctx, change, ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	sums, err = store.GetMonthlySums(ctx, 1, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, len(sums), 1)
+	assertEqual(t, sums[0].Sum, validDDBExpenseAmount)
+
+	suggestion, err := store.Suggest(ctx, validDDBExpenseName, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, suggestion.Category, validDDBExpenseCategory)
+
+	err = store.ApplyStreamChange(ctx, expense.StreamChange{EventID: "event-2", Old: &created}, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	sums, err = store.GetMonthlySums(ctx, 1, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, len(sums), 0)
+
+	sums, err = store.GetMonthlySumsBy(ctx, expense.GroupByPaymentMethod, 1, ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	assertEqual(t, len(sums), 0)
+}
+
+func TestDDBRefunds(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table: %v", err)
+	}
+	defer removeDDB()
+	store := expense.NewDDBStore(tableName, client)
+
+	original := createDDBExpenseHelper(ctx, t, store, validDDBExpenseName, "2026-08-10", validDDBExpenseCategory, 100, expense.PaymentMethods[0])
+	refundFC, isValid, errMessages := expense.NewRefund(original, 0, "", "2026-10-05", 30, expense.RefundToOriginalMonth)
+	if !isValid {
+		t.Fatalf("invalid refund: %v", errMessages)
+	}
+	refund, err := store.Create(ctx, refundFC, "userID", ddbStoreVaultID)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	monthSums := func(t *testing.T) map[string]float64 {
+		t.Helper()
+		sums, err := store.GetSums(ctx, expense.GranularityMonth, "2026-08-01", "2026-10-31", ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		got := map[string]float64{}
+		for _, s := range sums {
+			got[s.Period] += s.Sum
+		}
+		return got
+	}
+
+	t.Run("links refund to the original", func(t *testing.T) {
+		found, err := store.FindOne(ctx, original.SK, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, reflect.DeepEqual(found.Refunds, []string{refund.SK}), true)
+	})
+
+	t.Run("refuses refunds exceeding the original", func(t *testing.T) {
+		found, err := store.FindOne(ctx, original.SK, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		// validated against a stale refunded amount, as a concurrent request would
+		refundFC, _, _ := expense.NewRefund(found, 0, "", "2026-10-06", 80, expense.RefundToRefundMonth)
+
+		var exceedsErr *expense.RefundExceedsOriginalError
+		_, err = store.Create(ctx, refundFC, "userID", ddbStoreVaultID)
+		assertEqual(t, errors.As(err, &exceedsErr), true)
+	})
+
+	t.Run("refuses to delete the original", func(t *testing.T) {
+		var hasRefundsErr *expense.HasRefundsError
+		assertEqual(t, errors.As(store.Delete(ctx, original.SK, ddbStoreVaultID), &hasRefundsErr), true)
+	})
+
+	t.Run("moves refund with the original", func(t *testing.T) {
+		moved, err := store.FindOne(ctx, original.SK, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		moved.Date = "2026-09-10"
+		if err := store.Update(ctx, moved, ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		foundRefund, err := store.FindOne(ctx, refund.SK, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, foundRefund.Refund.SK, original.MovedSK("2026-09-10"))
+		assertEqual(t, reflect.DeepEqual(monthSums(t), map[string]float64{"2026-08": 0, "2026-09": 70}), true)
+	})
+
+	t.Run("keeps original above refunded amount and refund in its category", func(t *testing.T) {
+		moved, err := store.FindOne(ctx, original.MovedSK("2026-09-10"), ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		var exceedsErr *expense.RefundExceedsOriginalError
+		lowered := moved
+		lowered.Amount = 20
+		assertEqual(t, errors.As(store.Update(ctx, lowered, ddbStoreVaultID), &exceedsErr), true)
+
+		moved.Category = validDDBExpenseCategory + " 2"
+		if err := store.Update(ctx, moved, ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		foundRefund, err := store.FindOne(ctx, refund.SK, ddbStoreVaultID)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		assertEqual(t, foundRefund.Category, moved.Category)
+	})
+
+	t.Run("unlinks deleted refund", func(t *testing.T) {
+		if err := store.Delete(ctx, refund.SK, ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if err := store.Delete(ctx, original.MovedSK("2026-09-10"), ddbStoreVaultID); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	})
+}
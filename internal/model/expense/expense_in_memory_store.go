@@ -4,21 +4,62 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/kkstas/tener/internal/helpers"
 )
 
 type InMemoryStore struct {
 	expenses []Expense
+	limits   map[string]int
 }
 
 func (e *InMemoryStore) Create(ctx context.Context, expenseFC Expense, userID, vaultID string) (Expense, error) {
+	expenseFC.PK = buildPK(vaultID)
 	expenseFC.CreatedBy = userID
+	expenseFC.Version = 1
+	expenseFC.Refunds = nil
+	if expenseFC.Refund != nil {
+		i := slices.IndexFunc(e.expenses, func(exp Expense) bool { return exp.SK == expenseFC.Refund.SK && exp.Refund == nil })
+		if i < 0 {
+			return Expense{}, &NotFoundError{SK: expenseFC.Refund.SK}
+		}
+		original := e.expenses[i]
+		if len(original.Refunds) >= MaxRefunds {
+			return Expense{}, &MaxRefundsExceededError{SK: original.SK}
+		}
+		refunds := slices.DeleteFunc(slices.Clone(e.expenses), func(exp Expense) bool { return !slices.Contains(original.Refunds, exp.SK) })
+		if refunded := round(refundedTotal(refunds) - expenseFC.Amount); refunded > original.Amount {
+			return Expense{}, &RefundExceedsOriginalError{SK: original.SK, Amount: original.Amount, Refunded: refunded}
+		}
+		e.expenses[i].Refunds = append(slices.Clone(e.expenses[i].Refunds), expenseFC.SK)
+	}
 	e.expenses = append(e.expenses, expenseFC)
 	return expenseFC, nil
 }
 
+// Removes SK of a refund from Refunds of the expense it refunds.
+func (e *InMemoryStore) unlinkRefund(refund Expense) {
+	for i, exp := range e.expenses {
+		if exp.SK == refund.Refund.SK {
+			e.expenses[i].Refunds = slices.DeleteFunc(slices.Clone(exp.Refunds), func(sk string) bool { return sk == refund.SK })
+		}
+	}
+}
+
 func (e *InMemoryStore) Delete(ctx context.Context, SK, vaultID string) error {
+	for _, exp := range e.expenses {
+		if exp.SK != SK {
+			continue
+		}
+		if len(exp.Refunds) > 0 {
+			return &HasRefundsError{SK: SK}
+		}
+		if exp.Refund != nil {
+			e.unlinkRefund(exp)
+		}
+	}
+
 	var deleted bool
 
 	e.expenses = slices.DeleteFunc(e.expenses, func(expense Expense) bool {
@@ -38,8 +79,33 @@ func (e *InMemoryStore) Update(ctx context.Context, expenseFU Expense, vaultID s
 
 	for i, el := range e.expenses {
 		if el.SK == expenseFU.SK {
+			if el.Version != expenseFU.Version {
+				return &VersionConflictError{SK: el.SK, Current: el}
+			}
+			if el.Refund != nil {
+				expenseFU.Amount, expenseFU.Date, expenseFU.Category = el.Amount, el.Date, el.Category
+			}
+			refunds := slices.DeleteFunc(slices.Clone(e.expenses), func(exp Expense) bool { return !slices.Contains(el.Refunds, exp.SK) })
+			if refunded := refundedTotal(refunds); expenseFU.Amount < refunded {
+				return &RefundExceedsOriginalError{SK: el.SK, Amount: expenseFU.Amount, Refunded: refunded}
+			}
 			found = true
+			expenseFU.PK = el.PK
+			expenseFU.CreatedAt, expenseFU.CreatedBy = el.CreatedAt, el.CreatedBy
+			expenseFU.Installment = el.Installment
+			expenseFU.Refund = el.Refund
+			expenseFU.Refunds = el.Refunds
+			expenseFU.SK = el.MovedSK(expenseFU.Date)
+			if expenseFU.Status == "" {
+				expenseFU.Status = el.Status
+			}
+			expenseFU.Version++
 			e.expenses[i] = expenseFU
+
+			if expenseFU.SK != el.SK || expenseFU.Category != el.Category {
+				e.followOriginal(el.Refunds, expenseFU)
+			}
+			break
 		}
 	}
 
@@ -49,6 +115,18 @@ func (e *InMemoryStore) Update(ctx context.Context, expenseFU Expense, vaultID s
 	return nil
 }
 
+// Points refunds with given SKs at the SK of the updated expense they refund
+// and moves them to its category.
+func (e *InMemoryStore) followOriginal(sks []string, original Expense) {
+	for i, exp := range e.expenses {
+		if slices.Contains(sks, exp.SK) {
+			e.expenses[i].Refund = &Refund{SK: original.SK, AttributeTo: exp.Refund.AttributeTo}
+			e.expenses[i].Category = original.Category
+			e.expenses[i].Version++
+		}
+	}
+}
+
 func (e *InMemoryStore) FindOne(ctx context.Context, SK, vaultID string) (Expense, error) {
 	for _, el := range e.expenses {
 		if el.SK == SK {
@@ -58,26 +136,64 @@ func (e *InMemoryStore) FindOne(ctx context.Context, SK, vaultID string) (Expens
 	return Expense{}, &NotFoundError{SK: SK}
 }
 
+func (e *InMemoryStore) GetUsage(ctx context.Context, date, vaultID string) (Usage, error) {
+	limit, err := e.GetMonthLimit(ctx, vaultID)
+	if err != nil {
+		return Usage{}, err
+	}
+	count := 0
+	for _, exp := range e.expenses {
+		if strings.HasPrefix(exp.Date, date[:7]) {
+			count++
+		}
+	}
+	return newUsage(date[:7], count, limit), nil
+}
+
+func (e *InMemoryStore) GetMonthLimit(ctx context.Context, vaultID string) (int, error) {
+	if limit, found := e.limits[vaultID]; found {
+		return limit, nil
+	}
+	return maxExpensesInMonth, nil
+}
+
+func (e *InMemoryStore) SetMonthLimit(ctx context.Context, limit int, vaultID string) error {
+	if e.limits == nil {
+		e.limits = map[string]int{}
+	}
+	e.limits[vaultID] = limit
+	return nil
+}
+
 func (es *InMemoryStore) GetMonthlySums(ctx context.Context, monthsAgo int, vaultID string) ([]MonthlySum, error) {
+	return es.GetMonthlySumsBy(ctx, GroupByCategory, monthsAgo, vaultID)
+}
+
+func (es *InMemoryStore) GetMonthlySumsBy(ctx context.Context, gb GroupBy, monthsAgo int, vaultID string) ([]MonthlySum, error) {
 	expenses, err := es.Query(ctx, helpers.MonthsAgo(monthsAgo), helpers.DaysAgo(0), []string{}, vaultID)
 	if err != nil {
 		return nil, err
 	}
 
+	return sumMonthly(gb, expenses), nil
+}
+
+func sumMonthly(gb GroupBy, expenses []Expense) []MonthlySum {
 	m := make(map[string]MonthlySum)
 
 	for _, val := range expenses {
-		sum, found := m[val.Date[:7]+val.Category]
+		key := gb.Key(val)
+		month := val.sumDate()[:7]
+		sum, found := m[month+key]
 		if !found {
-			m[val.Date[:7]+val.Category] = MonthlySum{
-				SK:       val.Date[:7] + val.Category,
-				Category: val.Category,
-				Sum:      val.Amount,
-			}
-			continue
+			sum = MonthlySum{SK: month + key, Category: key}
+		}
+		if val.IsPlanned() {
+			sum.Planned += val.Amount
+		} else {
+			sum.Sum += val.Amount
 		}
-		sum.Sum += val.Amount
-		m[val.Date[:7]+val.Category] = sum
+		m[month+key] = sum
 	}
 
 	results := []MonthlySum{}
@@ -86,7 +202,58 @@ func (es *InMemoryStore) GetMonthlySums(ctx context.Context, monthsAgo int, vaul
 		results = append(results, v)
 	}
 
-	return results, err
+	return results
+}
+
+func (e *InMemoryStore) Suggest(ctx context.Context, name, vaultID string) (Suggestion, error) {
+	prefix := normalizeName(name)
+	if len(prefix) < NameMinLength {
+		return Suggestion{}, nil
+	}
+
+	statsByName := map[string]*NameStats{}
+	for _, expense := range e.expenses {
+		key := normalizeName(expense.Name)
+		if expense.PK != buildPK(vaultID) || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if statsByName[key] == nil {
+			stats := newNameStats(vaultID, key)
+			statsByName[key] = &stats
+		}
+		statsByName[key].add(expense)
+	}
+
+	stats := []NameStats{}
+	for _, s := range statsByName {
+		stats = append(stats, *s)
+	}
+
+	return Suggest(stats, helpers.DaysAgo(0)), nil
+}
+
+func (es *InMemoryStore) GetSums(ctx context.Context, g Granularity, from, to, vaultID string) ([]PeriodSum, error) {
+	fromPeriod, err := g.Period(from)
+	if err != nil {
+		return nil, err
+	}
+	toPeriod, err := g.Period(to)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses := []Expense{}
+	for _, exp := range es.expenses {
+		period, err := g.Period(exp.sumDate())
+		if err != nil {
+			return nil, err
+		}
+		if period >= fromPeriod && period <= toPeriod {
+			expenses = append(expenses, exp)
+		}
+	}
+
+	return SumByPeriod(g, expenses)
 }
 
 // Retrieves expenses between the given `from` and `to` YYYY-MM-DD dates (inclusive).
@@ -131,3 +298,47 @@ func (e *InMemoryStore) Query(ctx context.Context, from, to string, categories [
 
 	return expenses, nil
 }
+
+func (e *InMemoryStore) Recategorize(ctx context.Context, from, to, cursor string, limit int, vaultID string) (RecategorizeResult, error) {
+	slices.SortFunc(e.expenses, func(a, b Expense) int { return strings.Compare(a.SK, b.SK) })
+
+	result := RecategorizeResult{Cursor: cursor, Done: true}
+	for i, el := range e.expenses {
+		if el.SK <= cursor || el.Category != from {
+			continue
+		}
+		if result.Updated == limit {
+			result.Done = false
+			break
+		}
+		e.expenses[i].Category = to
+		result.Updated++
+		result.Cursor = el.SK
+	}
+
+	return result, nil
+}
+
+func (e *InMemoryStore) CountByCategory(ctx context.Context, category, vaultID string) (int, error) {
+	count := 0
+	for _, el := range e.expenses {
+		if el.Category == category {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (e *InMemoryStore) CountCategories(ctx context.Context, vaultID string) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, el := range e.expenses {
+		counts[el.Category]++
+	}
+	return counts, nil
+}
+
+// ReconcileSums has nothing to repair, as sums of the in-memory store are
+// always computed from expenses on demand.
+func (e *InMemoryStore) ReconcileSums(ctx context.Context, vaultID string, repair bool) (ReconcileResult, error) {
+	return ReconcileResult{VaultID: vaultID, Discrepancies: []SumDiscrepancy{}}, nil
+}
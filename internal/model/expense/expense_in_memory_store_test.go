@@ -3,8 +3,10 @@ package expense_test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
+	"github.com/kkstas/tener/internal/helpers"
 	"github.com/kkstas/tener/internal/model/expense"
 )
 
@@ -28,6 +30,28 @@ func TestInMemoryCreate(t *testing.T) {
 	}
 }
 
+func TestInMemoryCreateRefund(t *testing.T) {
+	ctx := context.Background()
+	store := &expense.InMemoryStore{}
+
+	originalFC, _, _ := expense.New("Shoes", "2024-09-07", "Clothes", 100, expense.PaymentMethods[0])
+	original, err := store.Create(ctx, originalFC, "userID", "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	// both validated against no earlier refunds, as concurrent requests would be
+	for i, want := range []bool{true, false} {
+		refundFC, _, _ := expense.NewRefund(original, 0, "", "2024-09-08", 80, expense.RefundToRefundMonth)
+		_, err := store.Create(ctx, refundFC, "userID", "vaultID")
+
+		var exceedsErr *expense.RefundExceedsOriginalError
+		if got := err == nil; got != want || (!want && !errors.As(err, &exceedsErr)) {
+			t.Errorf("refund %d: unexpected error %v", i, err)
+		}
+	}
+}
+
 func TestInMemoryDelete(t *testing.T) {
 	t.Run("deletes existing expense", func(t *testing.T) {
 		ctx := context.Background()
@@ -239,6 +263,101 @@ func TestInMemoryQuery(t *testing.T) {
 	})
 }
 
+func TestInMemorySuggest(t *testing.T) {
+	ctx := context.Background()
+	store := &expense.InMemoryStore{}
+
+	createInMemoryExpenseHelper(t, ctx, store, "Biedronka", helpers.DaysAgo(0), "Groceries", 21.37, expense.PaymentMethods[2])
+	createInMemoryExpenseHelper(t, ctx, store, "Biedronka", helpers.DaysAgo(1), "Groceries", 21.37, expense.PaymentMethods[2])
+	createInMemoryExpenseHelper(t, ctx, store, "Biedronka", helpers.DaysAgo(2), "Household", 5, expense.PaymentMethods[2])
+
+	suggestion, err := store.Suggest(ctx, "bied", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if !suggestion.Found || suggestion.Category != "Groceries" || suggestion.Amount != 21.37 {
+		t.Errorf("got unexpected suggestion %#v", suggestion)
+	}
+
+	suggestion, err = store.Suggest(ctx, "bied", "otherVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if suggestion.Found {
+		t.Errorf("didn't expect suggestion from expenses of another vault, got %#v", suggestion)
+	}
+}
+
+func TestInMemoryRecategorize(t *testing.T) {
+	ctx := context.Background()
+	store := &expense.InMemoryStore{}
+
+	for range 3 {
+		createInMemoryExpenseHelper(t, ctx, store, "Orlen", helpers.DaysAgo(0), "Fuel", 100, expense.PaymentMethods[0])
+	}
+	createInMemoryExpenseHelper(t, ctx, store, "Lidl", helpers.DaysAgo(0), "Food", 10, expense.PaymentMethods[0])
+
+	result, err := store.Recategorize(ctx, "Fuel", "Gas", "", 2, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if result.Updated != 2 || result.Done {
+		t.Fatalf("expected first batch of 2 with more to come, got %#v", result)
+	}
+
+	result, err = store.Recategorize(ctx, "Fuel", "Gas", result.Cursor, 2, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if result.Updated != 1 || !result.Done {
+		t.Fatalf("expected last batch of 1, got %#v", result)
+	}
+
+	count, _ := store.CountByCategory(ctx, "Gas", "activeVaultID")
+	if count != 3 {
+		t.Errorf("expected 3 expenses moved, got %d", count)
+	}
+}
+
+func TestInMemoryGetSums(t *testing.T) {
+	ctx := context.Background()
+	store := &expense.InMemoryStore{}
+
+	createInMemoryExpenseHelper(t, ctx, store, "Lidl", "2025-12-31", "Food", 10, expense.PaymentMethods[0])
+	createInMemoryExpenseHelper(t, ctx, store, "Lidl", "2026-01-02", "Food", 20, expense.PaymentMethods[0])
+	createInMemoryExpenseHelper(t, ctx, store, "Lidl", "2026-01-05", "Food", 5, expense.PaymentMethods[0])
+
+	sums, err := store.GetSums(ctx, expense.GranularityWeek, "2025-12-29", "2026-01-04", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(sums) != 1 || sums[0].Period != "2026-W01" || sums[0].Sum != 30 {
+		t.Errorf("expected ISO week spanning the new year to be summed up, got %#v", sums)
+	}
+}
+
+func TestInMemoryGetMonthlySumsBy(t *testing.T) {
+	ctx := context.Background()
+	store := &expense.InMemoryStore{}
+
+	createInMemoryExpenseHelper(t, ctx, store, "Lidl", helpers.DaysAgo(0), "Food", 10, expense.PaymentMethods[0])
+	createInMemoryExpenseHelper(t, ctx, store, "Orlen", helpers.DaysAgo(0), "Fuel", 20, expense.PaymentMethods[0])
+	createInMemoryExpenseHelper(t, ctx, store, "Lidl", helpers.DaysAgo(0), "Food", 5, expense.PaymentMethods[1])
+
+	sums, err := store.GetMonthlySumsBy(ctx, expense.GroupByPaymentMethod, 1, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	got := map[string]float64{}
+	for _, s := range sums {
+		got[s.Category] = s.Sum
+	}
+	want := map[string]float64{expense.PaymentMethods[0]: 30, expense.PaymentMethods[1]: 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func createDefaultInMemoryExpenseHelper(t testing.TB, ctx context.Context, store *expense.InMemoryStore) expense.Expense {
 	t.Helper()
 	return createInMemoryExpenseHelper(
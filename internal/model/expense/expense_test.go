@@ -75,6 +75,24 @@ func TestNew(t *testing.T) {
 		}
 	})
 
+	t.Run("returns an error when amount is negative", func(t *testing.T) {
+		_, isValid, errMessages := expense.New(validName, validDate, validCategory, -24.99, validPaymentMethod)
+		if isValid || errMessages["amount"] == nil {
+			t.Errorf("expected amount error, got %v", errMessages)
+		}
+	})
+
+	t.Run("keeps accepting negative amount of existing expense", func(t *testing.T) {
+		_, isValid, errMessages := expense.NewFU(validDate+"::sk", validName, validDate, validCategory, -24.99, validPaymentMethod)
+		if !isValid {
+			t.Errorf("didn't expect an error but got one: %v", errMessages)
+		}
+		_, isValid, _ = expense.NewFU(validDate+"::sk", validName, validDate, validCategory, 0, validPaymentMethod)
+		if isValid {
+			t.Error("expected zero amount to fail validation")
+		}
+	})
+
 	t.Run("fails validation if paymentMethod is invalid", func(t *testing.T) {
 		_, isValid, _ := expense.New(validName, validDate, validCategory, validAmount, "beans")
 		if isValid {
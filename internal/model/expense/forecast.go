@@ -0,0 +1,217 @@
+package expense
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ForecastHistoryMonths is the number of full months before the current one
+// whose daily spending patterns the forecast is based on. Spending of a
+// category on the same day of every one of them is considered recurring.
+const ForecastHistoryMonths = 3
+
+// Forecast bounds how far the pace of the current month can scale historical
+// spending of the rest of the month.
+const (
+	forecastMinPace = 0.5
+	forecastMaxPace = 2
+)
+
+// Forecast projects spending of the month of Date, as a sum of what was spent
+// up to Date, what is already planned or dated later in the month and what is
+// expected to be spent on top of that.
+type Forecast struct {
+	Date        string             `json:"date"`
+	DaysInMonth int                `json:"daysInMonth"`
+	SpentToDate float64            `json:"spentToDate"`
+	Upcoming    float64            `json:"upcoming"`
+	Remaining   float64            `json:"remaining"`
+	Total       float64            `json:"total"`
+	Categories  []CategoryForecast `json:"categories"`
+}
+
+// CategoryForecast is the forecast of a single category. Remaining includes
+// Recurring, the usual amounts of recurring expenses that haven't occurred in
+// the month yet.
+type CategoryForecast struct {
+	Category    string  `json:"category"`
+	SpentToDate float64 `json:"spentToDate"`
+	Upcoming    float64 `json:"upcoming"`
+	Recurring   float64 `json:"recurring"`
+	Remaining   float64 `json:"remaining"`
+	Total       float64 `json:"total"`
+}
+
+type recurringKey struct {
+	category string
+	day      int
+}
+
+// NewForecast projects spending of the month of date out of expenses of that
+// month and daily sums of ForecastHistoryMonths months before it. Expenses
+// are counted in the month of their sums, so refunds attributed to the
+// original month are where the chart puts them.
+//
+// Spending of the rest of the month, other than recurring expenses, is the
+// average spending after the same point of previous months, scaled by how the
+// current month compares to them so far. Categories without history are
+// extrapolated linearly from their spending to date.
+func NewForecast(date string, expenses []Expense, history []PeriodSum) (Forecast, error) {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return Forecast{}, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	firstDay := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	month := firstDay.Format("2006-01")
+	daysInMonth := daysIn(firstDay)
+
+	// day of month cutoffs of previous months equivalent to the current date
+	cutoffs := map[string]int{}
+	for i := 1; i <= ForecastHistoryMonths; i++ {
+		historyMonth := firstDay.AddDate(0, -i, 0)
+		cutoffs[historyMonth.Format("2006-01")] = int(math.Round(float64(t.Day()) * float64(daysIn(historyMonth)) / float64(daysInMonth)))
+	}
+
+	recurring := recurringAmounts(history, cutoffs)
+
+	forecasts := map[string]*CategoryForecast{}
+	forecastOf := func(category string) *CategoryForecast {
+		if _, found := forecasts[category]; !found {
+			forecasts[category] = &CategoryForecast{Category: category}
+		}
+		return forecasts[category]
+	}
+
+	variableSpent := map[string]float64{}
+	spentOnDay := map[recurringKey]float64{}
+	occurred := map[recurringKey]bool{}
+
+	for _, exp := range expenses {
+		expDate := exp.sumDate()
+		if expDate[:7] != month {
+			continue
+		}
+		day, _ := time.Parse(time.DateOnly, expDate)
+		key := recurringKey{exp.Category, day.Day()}
+
+		occurred[key] = true
+		if exp.IsPlanned() || expDate > date {
+			forecastOf(exp.Category).Upcoming += exp.Amount
+			continue
+		}
+		forecastOf(exp.Category).SpentToDate += exp.Amount
+		variableSpent[exp.Category] += exp.Amount
+		spentOnDay[key] += exp.Amount
+	}
+	for key, spent := range spentOnDay {
+		variableSpent[key.category] -= min(spent, recurring[key])
+	}
+
+	before, after := map[string]float64{}, map[string]float64{}
+	for _, s := range history {
+		day, err := time.Parse(time.DateOnly, s.Period)
+		if err != nil {
+			continue
+		}
+		cutoff, found := cutoffs[s.Period[:7]]
+		if !found {
+			continue
+		}
+		variable := s.Sum - recurring[recurringKey{s.Category, day.Day()}]
+		if day.Day() <= cutoff {
+			before[s.Category] += variable
+		} else {
+			after[s.Category] += variable
+		}
+	}
+
+	for key, amount := range recurring {
+		if !occurred[key] {
+			forecastOf(key.category).Recurring += amount
+		}
+	}
+	for category := range after {
+		forecastOf(category)
+	}
+
+	f := Forecast{Date: date, DaysInMonth: daysInMonth, Categories: []CategoryForecast{}}
+	for category, cf := range forecasts {
+		variable := projectRemaining(variableSpent[category], before[category]/ForecastHistoryMonths, after[category]/ForecastHistoryMonths, t.Day(), daysInMonth)
+
+		cf.SpentToDate = round(cf.SpentToDate)
+		cf.Upcoming = round(cf.Upcoming)
+		cf.Recurring = round(cf.Recurring)
+		cf.Remaining = round(math.Max(0, cf.Recurring+variable))
+		cf.Total = round(cf.SpentToDate + cf.Upcoming + cf.Remaining)
+		if cf.Total == 0 && cf.SpentToDate == 0 {
+			continue
+		}
+
+		f.Categories = append(f.Categories, *cf)
+		f.SpentToDate = round(f.SpentToDate + cf.SpentToDate)
+		f.Upcoming = round(f.Upcoming + cf.Upcoming)
+		f.Remaining = round(f.Remaining + cf.Remaining)
+		f.Total = round(f.Total + cf.Total)
+	}
+
+	sort.Slice(f.Categories, func(i, j int) bool {
+		if f.Categories[i].Total != f.Categories[j].Total {
+			return f.Categories[i].Total > f.Categories[j].Total
+		}
+		return f.Categories[i].Category < f.Categories[j].Category
+	})
+
+	return f, nil
+}
+
+// Returns the amounts committed on the same day of each of the months of
+// history in the same category, the smallest of them being the recurring one.
+func recurringAmounts(history []PeriodSum, cutoffs map[string]int) map[recurringKey]float64 {
+	months := map[recurringKey]map[string]float64{}
+	for _, s := range history {
+		day, err := time.Parse(time.DateOnly, s.Period)
+		if err != nil || s.Sum <= 0 {
+			continue
+		}
+		if _, found := cutoffs[s.Period[:7]]; !found {
+			continue
+		}
+		key := recurringKey{s.Category, day.Day()}
+		if months[key] == nil {
+			months[key] = map[string]float64{}
+		}
+		months[key][s.Period[:7]] += s.Sum
+	}
+
+	recurring := map[recurringKey]float64{}
+	for key, sums := range months {
+		if len(sums) < len(cutoffs) {
+			continue
+		}
+		amount := math.Inf(1)
+		for _, sum := range sums {
+			amount = min(amount, sum)
+		}
+		recurring[key] = amount
+	}
+	return recurring
+}
+
+// Projects variable spending of the rest of the month out of the average
+// spending before and after the same point of previous months.
+func projectRemaining(spent, avgBefore, avgAfter float64, day, daysInMonth int) float64 {
+	if avgBefore == 0 && avgAfter == 0 {
+		return spent / float64(day) * float64(daysInMonth-day)
+	}
+	if avgBefore == 0 {
+		return avgAfter
+	}
+	pace := min(max(spent/avgBefore, forecastMinPace), forecastMaxPace)
+	return avgAfter * pace
+}
+
+func daysIn(firstDay time.Time) int {
+	return firstDay.AddDate(0, 1, -1).Day()
+}
@@ -0,0 +1,122 @@
+package expense_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestNewForecast(t *testing.T) {
+	newExpense := func(t testing.TB, name, date, category string, amount float64) expense.Expense {
+		t.Helper()
+		exp, isValid, errMessages := expense.New(name, date, category, amount, "Cash")
+		if !isValid {
+			t.Fatalf("invalid expense: %v", errMessages)
+		}
+		return exp
+	}
+	daySum := func(period, category string, sum float64) expense.PeriodSum {
+		return expense.PeriodSum{Period: period, Category: category, Sum: sum}
+	}
+
+	t.Run("extrapolates categories without history linearly", func(t *testing.T) {
+		got, err := expense.NewForecast("2026-09-10", []expense.Expense{
+			newExpense(t, "Groceries", "2026-09-02", "Food", 100),
+		}, nil)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got.DaysInMonth != 30 || got.SpentToDate != 100 || got.Remaining != 200 || got.Total != 300 {
+			t.Errorf("unexpected forecast: %#v", got)
+		}
+	})
+
+	t.Run("counts refunds in month of their sums", func(t *testing.T) {
+		original := newExpense(t, "Shoes", "2026-08-20", "Clothes", 100)
+		refund, isValid, errMessages := expense.NewRefund(original, 0, "", "2026-09-03", 40, expense.RefundToOriginalMonth)
+		if !isValid {
+			t.Fatalf("invalid refund: %v", errMessages)
+		}
+
+		got, err := expense.NewForecast("2026-09-10", []expense.Expense{
+			newExpense(t, "Groceries", "2026-09-02", "Food", 100),
+			refund,
+		}, nil)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got.SpentToDate != 100 || len(got.Categories) != 1 {
+			t.Errorf("unexpected forecast: %#v", got)
+		}
+	})
+
+	t.Run("projects rest of month from previous months scaled by current pace", func(t *testing.T) {
+		history := []expense.PeriodSum{
+			daySum("2026-06-04", "Food", 100), daySum("2026-06-24", "Food", 300),
+			daySum("2026-07-05", "Food", 100), daySum("2026-07-25", "Food", 300),
+			daySum("2026-08-06", "Food", 100), daySum("2026-08-26", "Food", 300),
+		}
+		expenses := []expense.Expense{newExpense(t, "Groceries", "2026-09-05", "Food", 200)}
+
+		got, err := expense.NewForecast("2026-09-15", expenses, history)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		// twice as much spent so far as usual, so twice the usual 300 is expected
+		if len(got.Categories) != 1 || got.Categories[0].Remaining != 600 || got.Total != 800 {
+			t.Errorf("unexpected forecast: %#v", got)
+		}
+	})
+
+	t.Run("adds recurring expenses that haven't occurred yet and upcoming ones", func(t *testing.T) {
+		history := []expense.PeriodSum{}
+		for _, month := range []string{"2026-06", "2026-07", "2026-08"} {
+			history = append(history,
+				daySum(month+"-20", "Housing", 1000),
+				daySum(month+"-05", "Food", 20),
+				daySum(month+"-25", "Food", 60),
+			)
+		}
+		planned := newExpense(t, "Vet", "2026-09-28", "Pets", 150)
+		planned.Status = expense.StatusPlanned
+		expenses := []expense.Expense{
+			newExpense(t, "Lunch", "2026-09-03", "Food", 40),
+			newExpense(t, "Cinema", "2026-09-22", "Fun", 30),
+			planned,
+		}
+
+		got, err := expense.NewForecast("2026-09-15", expenses, history)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		categories := map[string]expense.CategoryForecast{}
+		for _, c := range got.Categories {
+			categories[c.Category] = c
+		}
+
+		if housing := categories["Housing"]; housing.Recurring != 1000 || housing.Total != 1000 {
+			t.Errorf("unexpected housing forecast: %#v", housing)
+		}
+		// snacks and dinners recur too, but lunch is new: food pace of 40
+		// against no variable history is extrapolated linearly
+		if food := categories["Food"]; food.SpentToDate != 40 || food.Recurring != 80 || food.Total != 160 {
+			t.Errorf("unexpected food forecast: %#v", food)
+		}
+		if fun := categories["Fun"]; fun.Upcoming != 30 || fun.SpentToDate != 0 || fun.Total != 30 {
+			t.Errorf("unexpected fun forecast: %#v", fun)
+		}
+		if pets := categories["Pets"]; pets.Upcoming != 150 || pets.Total != 150 {
+			t.Errorf("unexpected pets forecast: %#v", pets)
+		}
+		if got.Categories[0].Category != "Housing" || got.Total != 1340 {
+			t.Errorf("unexpected forecast: %#v", got)
+		}
+	})
+
+	t.Run("returns error for invalid date", func(t *testing.T) {
+		if _, err := expense.NewForecast("2026-13-01", nil, nil); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
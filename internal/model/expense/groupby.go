@@ -0,0 +1,56 @@
+package expense
+
+import "strings"
+
+// GroupBy is the expense attribute monthly sums are broken down by.
+type GroupBy string
+
+const (
+	GroupByCategory      GroupBy = "category"
+	GroupByPaymentMethod GroupBy = "paymentMethod"
+	GroupByUser          GroupBy = "user"
+)
+
+var GroupBys = []GroupBy{GroupByCategory, GroupByPaymentMethod, GroupByUser}
+
+// PK prefixes of monthly sums by attributes other than category, which are
+// kept under monthlySumPKPrefix.
+var groupSumPKPrefixes = map[GroupBy]string{
+	GroupByPaymentMethod: "paymentmethodsum",
+	GroupByUser:          "usersum",
+}
+
+func ParseGroupBy(s string) (GroupBy, bool) {
+	for _, gb := range GroupBys {
+		if string(gb) == s {
+			return gb, true
+		}
+	}
+	return "", false
+}
+
+// Key returns the value of the attribute the expense is grouped by: its
+// category, payment method or ID of the user who created it.
+func (gb GroupBy) Key(exp Expense) string {
+	switch gb {
+	case GroupByPaymentMethod:
+		return exp.PaymentMethod
+	case GroupByUser:
+		return exp.CreatedBy
+	default:
+		return exp.Category
+	}
+}
+
+func buildGroupSumPK(gb GroupBy, vaultID string) string {
+	if gb == GroupByCategory {
+		return buildMonthlySumPK(vaultID)
+	}
+	return groupSumPKPrefixes[gb] + "::" + vaultID
+}
+
+// keyFromSK returns the grouped by value of a sum with given SK.
+func keyFromSK(sk string) string {
+	_, key, _ := strings.Cut(sk, "::")
+	return key
+}
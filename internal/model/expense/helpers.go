@@ -15,3 +15,7 @@ func buildMonthlySumPK(vaultID string) string {
 func buildMonthlySumSK(month, category string) string {
 	return month + "::" + category
 }
+
+func buildNameStatsPK(vaultID string) string {
+	return nameStatsPKPrefix + "::" + vaultID
+}
@@ -0,0 +1,130 @@
+package expense
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+const (
+	InstallmentsMinCount = 2
+	InstallmentsMaxCount = 36
+)
+
+// Installment links an expense to the other expenses of the same installment
+// purchase, each dated in its own month.
+type Installment struct {
+	Group  string `dynamodbav:"group"`
+	Number int    `dynamodbav:"number"`
+	Count  int    `dynamodbav:"count"`
+}
+
+// NewInstallments splits total amount of a purchase into count expenses, the
+// first one dated at given date and each next one a month later. Cents that
+// don't split evenly are added to the first installment.
+func NewInstallments(name, date, category string, total float64, count int, paymentMethod string) (expenses []Expense, isValid bool, errMessages validator.ErrMessages) {
+	v := validator.NewValidator()
+	v.Check(count >= InstallmentsMinCount && count <= InstallmentsMaxCount, "installments", fmt.Sprintf("must be between %d and %d", InstallmentsMinCount, InstallmentsMaxCount))
+	v.Check(validator.IsAmountPrecision("amount", total))
+	v.Check(validator.IsTime("date", time.DateOnly, date))
+	if isValid, errMessages := v.Validate(); !isValid {
+		return nil, false, errMessages
+	}
+
+	group := uuid.New().String()
+	amounts := splitAmount(total, count)
+	for i := range count {
+		installmentDate, err := helpers.AddMonths(date, i)
+		if err != nil {
+			return nil, false, validator.ErrMessages{"date": {err.Error()}}
+		}
+		exp, isValid, errMessages := New(name, installmentDate, category, amounts[i], paymentMethod)
+		if !isValid {
+			return nil, false, errMessages
+		}
+		exp.Installment = &Installment{Group: group, Number: i + 1, Count: count}
+		expenses = append(expenses, exp)
+	}
+
+	return expenses, true, nil
+}
+
+func splitAmount(total float64, count int) []float64 {
+	cents := int64(math.Round(total * 100))
+	amounts := make([]float64, count)
+	for i := range amounts {
+		amounts[i] = float64(cents/int64(count)) / 100
+	}
+	amounts[0] = round(amounts[0] + float64(cents%int64(count))/100)
+	return amounts
+}
+
+// InstallmentRange returns the date range spanning all installments of the
+// group the expense belongs to.
+func (e Expense) InstallmentRange() (from, to string, err error) {
+	if e.Installment == nil {
+		return e.Date, e.Date, nil
+	}
+	first, err := helpers.AddMonths(e.Date, 1-e.Installment.Number)
+	if err != nil {
+		return "", "", err
+	}
+	last, err := helpers.AddMonths(e.Date, e.Installment.Count-e.Installment.Number)
+	if err != nil {
+		return "", "", err
+	}
+	from, _, err = helpers.GetFirstAndLastDayOfMonth(first)
+	if err != nil {
+		return "", "", err
+	}
+	_, to, err = helpers.GetFirstAndLastDayOfMonth(last)
+	return from, to, err
+}
+
+// InstallmentsOf returns the expenses belonging to the installment group of
+// exp, ordered by their number.
+func InstallmentsOf(exp Expense, expenses []Expense) []Expense {
+	if exp.Installment == nil {
+		return []Expense{exp}
+	}
+	group := []Expense{}
+	for _, e := range expenses {
+		if e.Installment != nil && e.Installment.Group == exp.Installment.Group {
+			group = append(group, e)
+		}
+	}
+	sort.Slice(group, func(i, j int) bool { return group[i].Installment.Number < group[j].Installment.Number })
+	return group
+}
+
+// EditInstallments applies name, category, payment method and status of
+// changes to installments of a purchase. Their amounts are kept unless
+// changes.Amount differs from the amount of the edited installment; then
+// changes.Amount for each installment is split between them again, so that
+// the total stays a whole number of cents.
+func EditInstallments(installments []Expense, edited, changes Expense) []Expense {
+	var amounts []float64
+	if changes.Amount != edited.Amount {
+		amounts = splitAmount(changes.Amount*float64(len(installments)), len(installments))
+	}
+
+	result := make([]Expense, len(installments))
+	for i, installment := range installments {
+		installment.Name = changes.Name
+		installment.Category = changes.Category
+		installment.PaymentMethod = changes.PaymentMethod
+		if changes.Status != "" {
+			installment.Status = changes.Status
+		}
+		if amounts != nil {
+			installment.Amount = amounts[i]
+		}
+		result[i] = installment
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package expense_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestNewInstallments(t *testing.T) {
+	t.Run("splits amount into monthly installments", func(t *testing.T) {
+		installments, isValid, errMessages := expense.NewInstallments("Laptop", "2026-01-31", "Electronics", 100, 3, expense.PaymentMethods[0])
+		if !isValid {
+			t.Fatalf("didn't expect validation errors but got: %v", errMessages)
+		}
+		if len(installments) != 3 {
+			t.Fatalf("expected 3 installments, got %d", len(installments))
+		}
+
+		wantDates := []string{"2026-01-31", "2026-02-28", "2026-03-31"}
+		wantAmounts := []float64{33.34, 33.33, 33.33}
+		for i, installment := range installments {
+			if installment.Date != wantDates[i] || installment.Amount != wantAmounts[i] {
+				t.Errorf("installment %d: got %s %.2f, want %s %.2f", i+1, installment.Date, installment.Amount, wantDates[i], wantAmounts[i])
+			}
+			if installment.Installment == nil || installment.Installment.Number != i+1 || installment.Installment.Count != 3 {
+				t.Errorf("installment %d: got %#v", i+1, installment.Installment)
+			}
+			if installment.Installment.Group != installments[0].Installment.Group {
+				t.Errorf("expected all installments in one group")
+			}
+		}
+	})
+
+	t.Run("rejects invalid installment count", func(t *testing.T) {
+		for _, count := range []int{1, expense.InstallmentsMaxCount + 1} {
+			_, isValid, errMessages := expense.NewInstallments("Laptop", "2026-01-31", "Electronics", 100, count, expense.PaymentMethods[0])
+			if isValid || errMessages["installments"] == nil {
+				t.Errorf("expected installments error for count %d, got %v", count, errMessages)
+			}
+		}
+	})
+}
+
+func TestEditInstallments(t *testing.T) {
+	installments, _, _ := expense.NewInstallments("Laptop", "2026-01-31", "Electronics", 100, 3, expense.PaymentMethods[0])
+
+	t.Run("keeps amounts when amount didn't change", func(t *testing.T) {
+		changes := installments[1]
+		changes.Name, changes.Category = "Work laptop", "Work"
+
+		edited := expense.EditInstallments(installments, installments[1], changes)
+		wantAmounts := []float64{33.34, 33.33, 33.33}
+		for i, installment := range edited {
+			if installment.Name != "Work laptop" || installment.Category != "Work" || installment.Amount != wantAmounts[i] {
+				t.Errorf("installment %d: got %#v", i+1, installment)
+			}
+		}
+	})
+
+	t.Run("splits new total when amount changed", func(t *testing.T) {
+		changes := installments[1]
+		changes.Amount = 10.01
+
+		edited := expense.EditInstallments(installments, installments[1], changes)
+		wantAmounts := []float64{10.01, 10.01, 10.01}
+		for i, installment := range edited {
+			if installment.Amount != wantAmounts[i] {
+				t.Errorf("installment %d: got amount %.2f, want %.2f", i+1, installment.Amount, wantAmounts[i])
+			}
+		}
+	})
+}
+
+func TestInstallmentRange(t *testing.T) {
+	exp := expense.Expense{Date: "2026-02-28", Installment: &expense.Installment{Group: "g", Number: 2, Count: 14}}
+
+	from, to, err := exp.InstallmentRange()
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if from != "2026-01-01" || to != "2027-02-28" {
+		t.Errorf("got %s - %s, want 2026-01-01 - 2027-02-28", from, to)
+	}
+}
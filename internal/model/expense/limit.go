@@ -0,0 +1,60 @@
+package expense
+
+import (
+	"fmt"
+
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+const (
+	expenseCountPKPrefix = "expensecount"
+	// SK of the vault's monthly limit setting, kept next to its per month
+	// expense counters with YYYY-MM SKs.
+	monthLimitSK = "limit"
+
+	MonthLimitMin = 1
+	MonthLimitMax = 10000
+
+	// Share of the monthly limit after which the UI warns about approaching it.
+	usageWarningRatio = 0.9
+)
+
+// Usage reports how many expenses are dated in the month and how many the
+// vault is allowed to have there.
+type Usage struct {
+	Month     string `json:"month"`
+	Count     int    `json:"count"`
+	Limit     int    `json:"limit"`
+	NearLimit bool   `json:"nearLimit"`
+}
+
+func newUsage(month string, count, limit int) Usage {
+	return Usage{
+		Month:     month,
+		Count:     count,
+		Limit:     limit,
+		NearLimit: float64(count) >= float64(limit)*usageWarningRatio,
+	}
+}
+
+type monthLimit struct {
+	PK    string `dynamodbav:"PK"`
+	SK    string `dynamodbav:"SK"`
+	Limit int    `dynamodbav:"limit"`
+}
+
+type monthCount struct {
+	PK    string `dynamodbav:"PK"`
+	SK    string `dynamodbav:"SK"`
+	Count int    `dynamodbav:"count"`
+}
+
+func ValidateMonthLimit(limit int) (isValid bool, errMessages validator.ErrMessages) {
+	v := validator.NewValidator()
+	v.Check(limit >= MonthLimitMin && limit <= MonthLimitMax, "limit", fmt.Sprintf("must be between %d and %d", MonthLimitMin, MonthLimitMax))
+	return v.Validate()
+}
+
+func buildExpenseCountPK(vaultID string) string {
+	return expenseCountPKPrefix + "::" + vaultID
+}
@@ -0,0 +1,212 @@
+package expense
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nameStatsPKPrefix = "namestats"
+
+	// Occurrences lose half of their weight after this many days, so that
+	// recent habits outweigh old ones.
+	nameStatsHalfLifeDays = 90
+	nameStatsMaxAmounts   = 10
+	suggestionMaxNames    = 50
+
+	// Name stats are written with the version they were read at, and written
+	// again from scratch when another write got there first.
+	nameStatsMaxAttempts = 3
+)
+
+// WeightedCount is an exponentially decayed occurrence count. Score is the
+// weight as of LastUsed date; every occurrence adds 1 at its own date.
+type WeightedCount struct {
+	Score    float64 `dynamodbav:"score"`
+	LastUsed string  `dynamodbav:"lastUsed"`
+}
+
+// NameStats aggregates past expenses sharing the same normalized name.
+type NameStats struct {
+	PK             string                   `dynamodbav:"PK"`
+	SK             string                   `dynamodbav:"SK"`
+	Categories     map[string]WeightedCount `dynamodbav:"categories"`
+	PaymentMethods map[string]WeightedCount `dynamodbav:"paymentMethods"`
+	Amounts        map[string]WeightedCount `dynamodbav:"amounts"`
+	Version        int                      `dynamodbav:"version"`
+}
+
+type Suggestion struct {
+	Found         bool    `json:"found"`
+	Category      string  `json:"category"`
+	PaymentMethod string  `json:"paymentMethod"`
+	Amount        float64 `json:"amount"`
+}
+
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+func decay(days float64) float64 {
+	return math.Pow(0.5, days/nameStatsHalfLifeDays)
+}
+
+func daysBetweenDates(from, to string) float64 {
+	fromDate, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return 0
+	}
+	toDate, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return 0
+	}
+	return toDate.Sub(fromDate).Hours() / 24
+}
+
+// at returns the weight decayed to given YYYY-MM-DD date.
+func (wc WeightedCount) at(date string) float64 {
+	days := daysBetweenDates(wc.LastUsed, date)
+	if days < 0 {
+		days = 0
+	}
+	return wc.Score * decay(days)
+}
+
+func bump(counts map[string]WeightedCount, key, date string, delta float64) {
+	wc, found := counts[key]
+	if !found {
+		if delta > 0 {
+			counts[key] = WeightedCount{Score: delta, LastUsed: date}
+		}
+		return
+	}
+
+	if days := daysBetweenDates(wc.LastUsed, date); days > 0 {
+		wc.Score = wc.Score*decay(days) + delta
+		wc.LastUsed = date
+	} else {
+		wc.Score += delta * decay(-days)
+	}
+
+	if wc.Score <= 1e-6 {
+		delete(counts, key)
+		return
+	}
+	counts[key] = wc
+}
+
+func newNameStats(vaultID, name string) NameStats {
+	return NameStats{
+		PK:             buildNameStatsPK(vaultID),
+		SK:             normalizeName(name),
+		Categories:     map[string]WeightedCount{},
+		PaymentMethods: map[string]WeightedCount{},
+		Amounts:        map[string]WeightedCount{},
+	}
+}
+
+func (ns *NameStats) ensureMaps() {
+	if ns.Categories == nil {
+		ns.Categories = map[string]WeightedCount{}
+	}
+	if ns.PaymentMethods == nil {
+		ns.PaymentMethods = map[string]WeightedCount{}
+	}
+	if ns.Amounts == nil {
+		ns.Amounts = map[string]WeightedCount{}
+	}
+}
+
+func (ns *NameStats) record(exp Expense, delta float64) {
+	ns.ensureMaps()
+	bump(ns.Categories, exp.Category, exp.Date, delta)
+	bump(ns.PaymentMethods, exp.PaymentMethod, exp.Date, delta)
+	bump(ns.Amounts, strconv.FormatFloat(exp.Amount, 'f', 2, 64), exp.Date, delta)
+
+	for len(ns.Amounts) > nameStatsMaxAmounts {
+		delete(ns.Amounts, weakest(ns.Amounts, exp.Date))
+	}
+}
+
+func (ns *NameStats) add(exp Expense)    { ns.record(exp, 1) }
+func (ns *NameStats) remove(exp Expense) { ns.record(exp, -1) }
+
+func (ns *NameStats) isEmpty() bool {
+	return len(ns.Categories) == 0 && len(ns.PaymentMethods) == 0 && len(ns.Amounts) == 0
+}
+
+func weakest(counts map[string]WeightedCount, date string) string {
+	var weakestKey string
+	weakestScore := math.Inf(1)
+	for key, wc := range counts {
+		if score := wc.at(date); score < weakestScore || (score == weakestScore && key < weakestKey) {
+			weakestKey, weakestScore = key, score
+		}
+	}
+	return weakestKey
+}
+
+func strongest(scores map[string]float64) string {
+	var strongestKey string
+	strongestScore := math.Inf(-1)
+	for key, score := range scores {
+		if score > strongestScore || (score == strongestScore && key < strongestKey) {
+			strongestKey, strongestScore = key, score
+		}
+	}
+	return strongestKey
+}
+
+// Suggest combines stats of all similar names into the most likely category,
+// payment method and amount as of given YYYY-MM-DD date.
+func Suggest(stats []NameStats, today string) Suggestion {
+	categories := map[string]float64{}
+	paymentMethods := map[string]float64{}
+	amounts := map[string]float64{}
+
+	for _, ns := range stats {
+		for key, wc := range ns.Categories {
+			categories[key] += wc.at(today)
+		}
+		for key, wc := range ns.PaymentMethods {
+			paymentMethods[key] += wc.at(today)
+		}
+		for key, wc := range ns.Amounts {
+			amounts[key] += wc.at(today)
+		}
+	}
+
+	if len(categories) == 0 {
+		return Suggestion{}
+	}
+
+	amount, _ := strconv.ParseFloat(strongest(amounts), 64)
+
+	return Suggestion{
+		Found:         true,
+		Category:      strongest(categories),
+		PaymentMethod: strongest(paymentMethods),
+		Amount:        amount,
+	}
+}
+
+// groupNameStatsChanges groups expenses that should be removed from and added
+// to name stats by normalized name, so that each stats item is written once.
+func groupNameStatsChanges(removed, added []Expense) map[string][2][]Expense {
+	changes := map[string][2][]Expense{}
+	for _, exp := range removed {
+		key := normalizeName(exp.Name)
+		change := changes[key]
+		change[0] = append(change[0], exp)
+		changes[key] = change
+	}
+	for _, exp := range added {
+		key := normalizeName(exp.Name)
+		change := changes[key]
+		change[1] = append(change[1], exp)
+		changes[key] = change
+	}
+	return changes
+}
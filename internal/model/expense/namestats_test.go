@@ -0,0 +1,80 @@
+package expense
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNameStats(t *testing.T) {
+	t.Run("adding and removing the same expense leaves stats empty", func(t *testing.T) {
+		stats := newNameStats("vaultID", "Biedronka")
+		exp := Expense{Name: "Biedronka", Date: "2024-05-01", Category: "Groceries", PaymentMethod: "Cash", Amount: 10}
+
+		stats.add(exp)
+		stats.remove(exp)
+
+		if !stats.isEmpty() {
+			t.Errorf("expected empty stats, got %#v", stats)
+		}
+	})
+
+	t.Run("removing older expense subtracts its decayed weight", func(t *testing.T) {
+		stats := newNameStats("vaultID", "Biedronka")
+		older := Expense{Name: "Biedronka", Date: "2024-01-01", Category: "Groceries", PaymentMethod: "Cash", Amount: 10}
+		newer := Expense{Name: "Biedronka", Date: "2024-03-31", Category: "Groceries", PaymentMethod: "Cash", Amount: 10}
+
+		stats.add(older)
+		stats.add(newer)
+		stats.remove(older)
+
+		got := stats.Categories["Groceries"]
+		if math.Abs(got.Score-1) > 1e-9 || got.LastUsed != newer.Date {
+			t.Errorf("expected weight of one occurrence at %s, got %#v", newer.Date, got)
+		}
+	})
+
+	t.Run("keeps a bounded number of amounts", func(t *testing.T) {
+		stats := newNameStats("vaultID", "Biedronka")
+		for i := range nameStatsMaxAmounts + 5 {
+			stats.add(Expense{Name: "Biedronka", Date: "2024-01-01", Category: "Groceries", PaymentMethod: "Cash", Amount: float64(i + 1)})
+		}
+		if len(stats.Amounts) != nameStatsMaxAmounts {
+			t.Errorf("expected %d amounts, got %d", nameStatsMaxAmounts, len(stats.Amounts))
+		}
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	t.Run("recent usage outweighs old frequency", func(t *testing.T) {
+		stats := newNameStats("vaultID", "Orlen")
+		for range 3 {
+			stats.add(Expense{Name: "Orlen", Date: "2023-01-01", Category: "Car", PaymentMethod: "Cash", Amount: 100})
+		}
+		stats.add(Expense{Name: "Orlen", Date: "2024-06-01", Category: "Fuel", PaymentMethod: "Credit Card", Amount: 250})
+
+		got := Suggest([]NameStats{stats}, "2024-06-02")
+
+		want := Suggestion{Found: true, Category: "Fuel", PaymentMethod: "Credit Card", Amount: 250}
+		if got != want {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("frequency wins between equally recent entries", func(t *testing.T) {
+		stats := newNameStats("vaultID", "Orlen")
+		stats.add(Expense{Name: "Orlen", Date: "2024-06-01", Category: "Car", PaymentMethod: "Cash", Amount: 100})
+		stats.add(Expense{Name: "Orlen", Date: "2024-06-01", Category: "Fuel", PaymentMethod: "Cash", Amount: 100})
+		stats.add(Expense{Name: "Orlen", Date: "2024-06-01", Category: "Fuel", PaymentMethod: "Cash", Amount: 100})
+
+		got := Suggest([]NameStats{stats}, "2024-06-01")
+		if got.Category != "Fuel" {
+			t.Errorf("got category %q, want %q", got.Category, "Fuel")
+		}
+	})
+
+	t.Run("returns not found without stats", func(t *testing.T) {
+		if got := Suggest([]NameStats{}, "2024-06-01"); got.Found {
+			t.Errorf("didn't expect a suggestion, got %#v", got)
+		}
+	})
+}
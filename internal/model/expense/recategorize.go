@@ -0,0 +1,11 @@
+package expense
+
+// RecategorizeResult describes one batch of moving expenses between categories.
+type RecategorizeResult struct {
+	// Updated is the number of expenses moved in this batch.
+	Updated int
+	// Cursor is the SK to resume from with the next batch.
+	Cursor string
+	// Done is set once no expenses are left in the source category.
+	Done bool
+}
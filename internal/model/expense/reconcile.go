@@ -0,0 +1,109 @@
+package expense
+
+import (
+	"math"
+	"sort"
+)
+
+// SumDiscrepancy is a sum whose stored value doesn't match the sum of expenses
+// it covers.
+type SumDiscrepancy struct {
+	// Sums is the granularity of a per category sum, or the attribute a
+	// monthly sum is grouped by, in which case Category holds its value.
+	Sums     string  `json:"sums"`
+	Period   string  `json:"period"`
+	Category string  `json:"category"`
+	Stored   float64 `json:"stored"`
+	Actual   float64 `json:"actual"`
+	// Missing is set when no sum item is stored for expenses of given period
+	// and category.
+	Missing bool `json:"missing"`
+	// Planned and ActualPlanned are the stored and recomputed sums of planned
+	// expenses, kept in the same item.
+	Planned       float64 `json:"planned,omitempty"`
+	ActualPlanned float64 `json:"actualPlanned,omitempty"`
+}
+
+// ReconcileResult reports sums of a vault recomputed from its expenses.
+type ReconcileResult struct {
+	VaultID       string           `json:"vaultID"`
+	Checked       int              `json:"checked"`
+	Discrepancies []SumDiscrepancy `json:"discrepancies"`
+	// Repaired is the number of discrepancies fixed. Sums changed by
+	// concurrent writes while reconciling are left for the next run.
+	Repaired int `json:"repaired"`
+}
+
+// CompareSums recomputes per category sums of committed and of planned
+// expenses of given granularity and returns the stored ones that differ from
+// them. Stored sums with no expenses left, e.g. of deleted categories, are
+// reported with zero actual values, even when they are zero themselves.
+func CompareSums(g Granularity, stored []PeriodSum, expenses []Expense) (checked int, discrepancies []SumDiscrepancy, err error) {
+	return compareSums(string(g), stored, expenses, func(exp Expense) (string, string, error) {
+		period, err := g.Period(exp.sumDate())
+		return period, exp.Category, err
+	})
+}
+
+// CompareGroupSums is CompareSums for monthly sums grouped by an attribute
+// other than category.
+func CompareGroupSums(gb GroupBy, stored []PeriodSum, expenses []Expense) (checked int, discrepancies []SumDiscrepancy, err error) {
+	return compareSums(string(gb), stored, expenses, func(exp Expense) (string, string, error) {
+		return exp.sumDate()[:7], gb.Key(exp), nil
+	})
+}
+
+func compareSums(sums string, stored []PeriodSum, expenses []Expense, keyOf func(exp Expense) (period, key string, err error)) (int, []SumDiscrepancy, error) {
+	actual, actualPlanned := map[string]float64{}, map[string]float64{}
+	for _, exp := range expenses {
+		period, key, err := keyOf(exp)
+		if err != nil {
+			return 0, nil, err
+		}
+		if exp.IsPlanned() {
+			actualPlanned[buildSumSK(period, key)] += exp.Amount
+		} else {
+			actual[buildSumSK(period, key)] += exp.Amount
+		}
+	}
+
+	discrepancies := []SumDiscrepancy{}
+	seen := map[string]bool{}
+	for _, s := range stored {
+		seen[s.SK] = true
+		sum, found := actual[s.SK]
+		planned, foundPlanned := actualPlanned[s.SK]
+		sum, planned = round(sum), round(planned)
+		if sum == round(s.Sum) && planned == round(s.Planned) && (found || foundPlanned) {
+			continue
+		}
+		discrepancies = append(discrepancies, SumDiscrepancy{Sums: sums, Period: periodFromSK(s.SK), Category: keyFromSK(s.SK), Stored: s.Sum, Actual: sum, Planned: s.Planned, ActualPlanned: planned})
+	}
+	missing := map[string]bool{}
+	for sk := range actual {
+		missing[sk] = !seen[sk]
+	}
+	for sk := range actualPlanned {
+		missing[sk] = !seen[sk]
+	}
+	for sk, isMissing := range missing {
+		sum, planned := round(actual[sk]), round(actualPlanned[sk])
+		if !isMissing || (sum == 0 && planned == 0) {
+			continue
+		}
+		discrepancies = append(discrepancies, SumDiscrepancy{Sums: sums, Period: periodFromSK(sk), Category: keyFromSK(sk), Actual: sum, ActualPlanned: planned, Missing: true})
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].Period != discrepancies[j].Period {
+			return discrepancies[i].Period < discrepancies[j].Period
+		}
+		return discrepancies[i].Category < discrepancies[j].Category
+	})
+
+	return len(stored), discrepancies, nil
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,136 @@
+package expense_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestCompareSums(t *testing.T) {
+	expenses := []expense.Expense{
+		{Date: "2026-09-02", Category: "Food", Amount: 10.1},
+		{Date: "2026-09-20", Category: "Food", Amount: 20.2},
+		{Date: "2026-09-05", Category: "Fuel", Amount: 100},
+		{Date: "2026-10-01", Category: "Food", Amount: 5},
+	}
+
+	t.Run("reports mismatched, emptied and missing monthly sums", func(t *testing.T) {
+		stored := []expense.PeriodSum{
+			{SK: "2026-09::Food", Category: "Food", Sum: 30.3},
+			{SK: "2026-09::Fuel", Category: "Fuel", Sum: 80},
+			{SK: "2026-09::Deleted", Category: "Deleted", Sum: 0},
+			{SK: "2026-08::Old", Category: "Old", Sum: 15},
+		}
+
+		checked, got, err := expense.CompareSums(expense.GranularityMonth, stored, expenses)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		want := []expense.SumDiscrepancy{
+			{Sums: "month", Period: "2026-08", Category: "Old", Stored: 15, Actual: 0},
+			{Sums: "month", Period: "2026-09", Category: "Deleted", Stored: 0, Actual: 0},
+			{Sums: "month", Period: "2026-09", Category: "Fuel", Stored: 80, Actual: 100},
+			{Sums: "month", Period: "2026-10", Category: "Food", Actual: 5, Missing: true},
+		}
+		if checked != len(stored) {
+			t.Errorf("expected %d checked sums, got %d", len(stored), checked)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("reports sums missing in other granularities", func(t *testing.T) {
+		stored := []expense.PeriodSum{
+			{SK: "2026-W36::Food", Category: "Food", Sum: 10.1},
+			{SK: "2026-W36::Fuel", Category: "Fuel", Sum: 100},
+		}
+
+		_, got, err := expense.CompareSums(expense.GranularityWeek, stored, expenses)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		want := []expense.SumDiscrepancy{
+			{Sums: "week", Period: "2026-W38", Category: "Food", Actual: 20.2, Missing: true},
+			{Sums: "week", Period: "2026-W40", Category: "Food", Actual: 5, Missing: true},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+}
+
+func TestCompareGroupSums(t *testing.T) {
+	expenses := []expense.Expense{
+		{Date: "2026-09-02", Category: "Food", Amount: 10.1, PaymentMethod: "Card", CreatedBy: "userID"},
+		{Date: "2026-09-20", Category: "Fuel", Amount: 20.2, PaymentMethod: "Cash", CreatedBy: "userID"},
+	}
+	stored := []expense.PeriodSum{
+		{SK: "2026-09::Card", Sum: 10.1},
+		{SK: "2026-09::Transfer", Sum: 5},
+	}
+
+	checked, got, err := expense.CompareGroupSums(expense.GroupByPaymentMethod, stored, expenses)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	want := []expense.SumDiscrepancy{
+		{Sums: "paymentMethod", Period: "2026-09", Category: "Cash", Actual: 20.2, Missing: true},
+		{Sums: "paymentMethod", Period: "2026-09", Category: "Transfer", Stored: 5, Actual: 0},
+	}
+	if checked != len(stored) {
+		t.Errorf("expected %d checked sums, got %d", len(stored), checked)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestCompareSumsSeparatesPlanned(t *testing.T) {
+	expenses := []expense.Expense{
+		{Date: "2026-10-02", Category: "Bills", Amount: 120, Status: expense.StatusPlanned},
+		{Date: "2026-10-05", Category: "Food", Amount: 10, Status: expense.StatusPending},
+		{Date: "2026-10-06", Category: "Food", Amount: 50, Status: expense.StatusPlanned},
+	}
+	stored := []expense.PeriodSum{
+		{SK: "2026-10::Bills", Category: "Bills", Sum: 0, Planned: 120},
+		{SK: "2026-10::Food", Category: "Food", Sum: 10, Planned: 50},
+	}
+
+	_, got, err := expense.CompareSums(expense.GranularityMonth, stored, expenses)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no discrepancies, got %#v", got)
+	}
+}
+
+func TestCompareSumsReportsPlanned(t *testing.T) {
+	expenses := []expense.Expense{
+		{Date: "2026-10-02", Category: "Bills", Amount: 120, Status: expense.StatusPlanned},
+		{Date: "2026-10-06", Category: "Rent", Amount: 900, Status: expense.StatusPlanned},
+	}
+	stored := []expense.PeriodSum{
+		{SK: "2026-10::Bills", Category: "Bills", Sum: 0, Planned: 100},
+		{SK: "2026-10::Gone", Category: "Gone", Sum: 0, Planned: 50},
+	}
+
+	_, got, err := expense.CompareSums(expense.GranularityMonth, stored, expenses)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	want := []expense.SumDiscrepancy{
+		{Sums: "month", Period: "2026-10", Category: "Bills", Planned: 100, ActualPlanned: 120},
+		{Sums: "month", Period: "2026-10", Category: "Gone", Planned: 50},
+		{Sums: "month", Period: "2026-10", Category: "Rent", ActualPlanned: 900, Missing: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package expense
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+// Refunds reduce the sums of either the month of the original expense, so that
+// it looks as if less was spent on it, or the month they were received in.
+const (
+	RefundToOriginalMonth = "original"
+	RefundToRefundMonth   = "refund"
+)
+
+var RefundAttributions = []string{RefundToOriginalMonth, RefundToRefundMonth}
+
+// MaxRefunds limits refunds of an expense, so that updating it together with
+// its refunds and their sums fits in a single transaction.
+const MaxRefunds = 5
+
+// Refund links a refund, stored as an expense with negative amount, to the
+// expense it returns money for. The store keeps SKs of refunds of an expense
+// in its Refunds, so that they follow it when its date changes.
+type Refund struct {
+	SK          string `dynamodbav:"SK"`
+	AttributeTo string `dynamodbav:"attributeTo"`
+}
+
+// NewRefund returns a refund of given amount of the original expense, in its
+// category and payment method. Together with the amount already refunded, it
+// cannot exceed the amount of the original.
+func NewRefund(original Expense, refunded float64, name, date string, amount float64, attributeTo string) (exp Expense, isValid bool, errMessages validator.ErrMessages) {
+	v := validator.NewValidator()
+	v.Check(original.Refund == nil, "refund", "cannot refund a refund")
+	v.Check(len(original.Refunds) < MaxRefunds, "refund", fmt.Sprintf("expense can have at most %d refunds", MaxRefunds))
+	v.Check(validator.OneOf("attributeTo", attributeTo, RefundAttributions))
+	v.Check(amount > 0, "amount", "must be positive")
+	v.Check(round(refunded+amount) <= original.Amount, "amount", fmt.Sprintf("cannot exceed %.2f left to refund of the original expense", round(original.Amount-refunded)))
+	v.Check(validator.IsTime("date", time.DateOnly, date))
+	v.Check(date >= original.Date, "date", "cannot be before date of the original expense")
+	if isValid, errMessages := v.Validate(); !isValid {
+		return Expense{}, false, errMessages
+	}
+
+	if name == "" {
+		name = original.Name
+	}
+	exp, isValid, errMessages = New(name, date, original.Category, amount, original.PaymentMethod)
+	if !isValid {
+		return Expense{}, false, errMessages
+	}
+	exp.Amount = -amount
+	exp.Refund = &Refund{SK: original.SK, AttributeTo: attributeTo}
+	return exp, true, nil
+}
+
+// sumDate returns the date the expense is summed up at, which for refunds
+// attributed to the original month is the date of the original expense.
+func (e Expense) sumDate() string {
+	if e.Refund != nil && e.Refund.AttributeTo == RefundToOriginalMonth {
+		return e.Refund.SK[:len(time.DateOnly)]
+	}
+	return e.Date
+}
+
+// Returns the amount returned by given refunds.
+func refundedTotal(refunds []Expense) float64 {
+	var refunded float64
+	for _, refund := range refunds {
+		refunded -= refund.Amount
+	}
+	return round(refunded)
+}
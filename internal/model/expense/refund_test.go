@@ -0,0 +1,75 @@
+package expense_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestNewRefund(t *testing.T) {
+	original, _, _ := expense.New("Shoes", "2026-09-10", "Clothes", 200, expense.PaymentMethods[0])
+
+	t.Run("returns negative expense linked to the original", func(t *testing.T) {
+		refund, isValid, errMessages := expense.NewRefund(original, 0, "", "2026-10-02", 50, expense.RefundToOriginalMonth)
+		if !isValid {
+			t.Fatalf("didn't expect validation errors but got: %v", errMessages)
+		}
+		if refund.Amount != -50 || refund.Name != "Shoes" || refund.Category != "Clothes" || refund.PaymentMethod != original.PaymentMethod {
+			t.Errorf("unexpected refund: %#v", refund)
+		}
+		if refund.Refund == nil || refund.Refund.SK != original.SK {
+			t.Errorf("expected refund to reference %q, got %#v", original.SK, refund.Refund)
+		}
+	})
+
+	t.Run("rejects invalid refunds", func(t *testing.T) {
+		refund, _, _ := expense.NewRefund(original, 0, "", "2026-10-02", 50, expense.RefundToRefundMonth)
+		refunded := original
+		refunded.Refunds = []string{"1", "2", "3", "4", "5"}
+
+		cases := []struct {
+			name        string
+			original    expense.Expense
+			refunded    float64
+			date        string
+			amount      float64
+			attributeTo string
+			field       string
+		}{
+			{"exceeding amount", original, 0, "2026-10-02", 200.01, expense.RefundToRefundMonth, "amount"},
+			{"exceeding amount left to refund", original, 150, "2026-10-02", 50.01, expense.RefundToRefundMonth, "amount"},
+			{"non-positive amount", original, 0, "2026-10-02", 0, expense.RefundToRefundMonth, "amount"},
+			{"dated before original", original, 0, "2026-09-09", 50, expense.RefundToRefundMonth, "date"},
+			{"unknown attribution", original, 0, "2026-10-02", 50, "next", "attributeTo"},
+			{"refund of refund", refund, 0, "2026-10-02", 50, expense.RefundToRefundMonth, "refund"},
+			{"too many refunds", refunded, 50, "2026-10-02", 10, expense.RefundToRefundMonth, "refund"},
+		}
+
+		for _, tc := range cases {
+			_, isValid, errMessages := expense.NewRefund(tc.original, tc.refunded, "", tc.date, tc.amount, tc.attributeTo)
+			if isValid || errMessages[tc.field] == nil {
+				t.Errorf("%s: expected %s error, got %v", tc.name, tc.field, errMessages)
+			}
+		}
+	})
+}
+
+func TestSumByPeriodAttributesRefunds(t *testing.T) {
+	original, _, _ := expense.New("Shoes", "2026-09-10", "Clothes", 200, expense.PaymentMethods[0])
+	toOriginal, _, _ := expense.NewRefund(original, 0, "", "2026-10-02", 50, expense.RefundToOriginalMonth)
+	toRefund, _, _ := expense.NewRefund(original, 0, "", "2026-10-03", 30, expense.RefundToRefundMonth)
+
+	got, err := expense.SumByPeriod(expense.GranularityMonth, []expense.Expense{original, toOriginal, toRefund})
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	want := []expense.PeriodSum{
+		{SK: "2026-09::Clothes", Period: "2026-09", Category: "Clothes", Sum: 150},
+		{SK: "2026-10::Clothes", Period: "2026-10", Category: "Clothes", Sum: -30},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package expense
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+// ReportMaxMonths is the longest period a report can cover.
+const ReportMaxMonths = 36
+
+// Report is a pivot table of committed spending of categories by month.
+type Report struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	Months      []string    `json:"months"`
+	Rows        []ReportRow `json:"rows"`
+	MonthTotals []float64   `json:"monthTotals"`
+	Total       float64     `json:"total"`
+	// Average is the average total of a month.
+	Average float64 `json:"average"`
+}
+
+// ReportRow holds sums of a category in every month of the report, followed by
+// their total, monthly average and percent of the report total.
+type ReportRow struct {
+	Category string    `json:"category"`
+	Color    string    `json:"color,omitempty"`
+	Sums     []float64 `json:"sums"`
+	Total    float64   `json:"total"`
+	Average  float64   `json:"average"`
+	Percent  float64   `json:"percent"`
+}
+
+func ValidateReportPeriod(from, to string) (isValid bool, errMessages validator.ErrMessages) {
+	v := validator.NewValidator()
+	v.Check(helpers.IsValidYYYYMM(from), "from", "must be a month in YYYY-MM format")
+	v.Check(helpers.IsValidYYYYMM(to), "to", "must be a month in YYYY-MM format")
+	if isValid, errMessages := v.Validate(); !isValid {
+		return false, errMessages
+	}
+
+	months, _ := helpers.MonthsBetween(from, to)
+	v.Check(months >= 0, "to", "must not be before from")
+	v.Check(months < ReportMaxMonths, "to", fmt.Sprintf("report can cover at most %d months", ReportMaxMonths))
+	return v.Validate()
+}
+
+// NewReport builds the report of months between from and to, both YYYY-MM and
+// inclusive, out of monthly sums. Rows are ordered by their total, descending.
+func NewReport(from, to string, sums []PeriodSum) (Report, error) {
+	count, err := helpers.MonthsBetween(from, to)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{From: from, To: to, Months: make([]string, count+1), MonthTotals: make([]float64, count+1)}
+	index := map[string]int{}
+	for i := range report.Months {
+		month, err := helpers.AddMonths(from+"-01", i)
+		if err != nil {
+			return Report{}, err
+		}
+		report.Months[i] = month[:7]
+		index[month[:7]] = i
+	}
+
+	rows := map[string]*ReportRow{}
+	for _, s := range sums {
+		i, found := index[s.Period]
+		if !found || s.Sum == 0 {
+			continue
+		}
+		row, found := rows[s.Category]
+		if !found {
+			row = &ReportRow{Category: s.Category, Sums: make([]float64, len(report.Months))}
+			rows[s.Category] = row
+		}
+		row.Sums[i] = round(row.Sums[i] + s.Sum)
+		row.Total = round(row.Total + s.Sum)
+		report.MonthTotals[i] = round(report.MonthTotals[i] + s.Sum)
+		report.Total = round(report.Total + s.Sum)
+	}
+
+	report.Rows = []ReportRow{}
+	for _, row := range rows {
+		row.Average = round(row.Total / float64(len(report.Months)))
+		if report.Total != 0 {
+			row.Percent = round(row.Total / report.Total * 100)
+		}
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Total != report.Rows[j].Total {
+			return report.Rows[i].Total > report.Rows[j].Total
+		}
+		return report.Rows[i].Category < report.Rows[j].Category
+	})
+	report.Average = round(report.Total / float64(len(report.Months)))
+
+	return report, nil
+}
+
+// WithColors sets color of every row to the color returned for its category.
+func (r Report) WithColors(colorOf func(category string) string) Report {
+	r.Rows = withColors(r.Rows, func(row *ReportRow) { row.Color = colorOf(row.Category) })
+	return r
+}
@@ -0,0 +1,59 @@
+package expense_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestNewReport(t *testing.T) {
+	sums := []expense.PeriodSum{
+		{Period: "2026-08", Category: "Food", Sum: 100},
+		{Period: "2026-09", Category: "Food", Sum: 200},
+		{Period: "2026-09", Category: "Fuel", Sum: 100},
+		{Period: "2026-10", Category: "Fuel", Sum: 0, Planned: 50},
+		{Period: "2026-11", Category: "Food", Sum: 999},
+	}
+
+	got, err := expense.NewReport("2026-08", "2026-10", sums)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	want := expense.Report{
+		From:        "2026-08",
+		To:          "2026-10",
+		Months:      []string{"2026-08", "2026-09", "2026-10"},
+		MonthTotals: []float64{100, 300, 0},
+		Total:       400,
+		Average:     133.33,
+		Rows: []expense.ReportRow{
+			{Category: "Food", Sums: []float64{100, 200, 0}, Total: 300, Average: 100, Percent: 75},
+			{Category: "Fuel", Sums: []float64{0, 100, 0}, Total: 100, Average: 33.33, Percent: 25},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestValidateReportPeriod(t *testing.T) {
+	cases := []struct {
+		from, to string
+		valid    bool
+	}{
+		{"2026-01", "2026-10", true},
+		{"2026-10", "2026-10", true},
+		{"2026-10", "2026-09", false},
+		{"2024-01", "2026-12", true},
+		{"2024-01", "2027-01", false},
+		{"2026-1", "2026-10", false},
+	}
+
+	for _, tc := range cases {
+		if isValid, _ := expense.ValidateReportPeriod(tc.from, tc.to); isValid != tc.valid {
+			t.Errorf("%s - %s: got valid %t, want %t", tc.from, tc.to, isValid, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package expense
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Granularity is the length of periods expenses are summed up over.
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+	GranularityYear  Granularity = "year"
+)
+
+var Granularities = []Granularity{GranularityDay, GranularityWeek, GranularityMonth, GranularityYear}
+
+// PK prefixes of sums per granularity. Monthly sums keep the prefix they had
+// before other granularities were added.
+var sumPKPrefixes = map[Granularity]string{
+	GranularityDay:   "dailysum",
+	GranularityWeek:  "weeklysum",
+	GranularityMonth: monthlySumPKPrefix,
+	GranularityYear:  "yearlysum",
+}
+
+// PeriodSum is the sum of expenses of a category over a single period.
+type PeriodSum struct {
+	PK       string  `dynamodbav:"PK"       json:"-"`
+	SK       string  `dynamodbav:"SK"       json:"-"`
+	Period   string  `dynamodbav:"-"        json:"period"`
+	Category string  `dynamodbav:"category" json:"category"`
+	Sum      float64 `dynamodbav:"sum"      json:"sum"`
+	Planned  float64 `dynamodbav:"planned"  json:"planned"`
+}
+
+func ParseGranularity(s string) (Granularity, bool) {
+	for _, g := range Granularities {
+		if string(g) == s {
+			return g, true
+		}
+	}
+	return "", false
+}
+
+// Period returns the period of given YYYY-MM-DD date: the date itself, its
+// ISO week (YYYY-Www), month (YYYY-MM) or year (YYYY).
+func (g Granularity) Period(date string) (string, error) {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", date, err)
+	}
+
+	switch g {
+	case GranularityDay:
+		return date, nil
+	case GranularityWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week), nil
+	case GranularityMonth:
+		return date[:7], nil
+	case GranularityYear:
+		return date[:4], nil
+	default:
+		return "", fmt.Errorf("unknown granularity %q", g)
+	}
+}
+
+// periodAfter returns the period following the one of given date.
+func (g Granularity) periodAfter(date string) (string, error) {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", date, err)
+	}
+
+	switch g {
+	case GranularityDay:
+		t = t.AddDate(0, 0, 1)
+	case GranularityWeek:
+		t = t.AddDate(0, 0, 7)
+	case GranularityMonth:
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	case GranularityYear:
+		t = time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return g.Period(t.Format(time.DateOnly))
+}
+
+func buildSumPK(g Granularity, vaultID string) string {
+	return sumPKPrefixes[g] + "::" + vaultID
+}
+
+func buildSumSK(period, category string) string {
+	return period + "::" + category
+}
+
+func periodFromSK(sk string) string {
+	period, _, _ := strings.Cut(sk, "::")
+	return period
+}
+
+// SumByPeriod sums up expenses per period of given granularity and category.
+func SumByPeriod(g Granularity, expenses []Expense) ([]PeriodSum, error) {
+	sums := map[string]PeriodSum{}
+	for _, exp := range expenses {
+		period, err := g.Period(exp.sumDate())
+		if err != nil {
+			return nil, err
+		}
+		sk := buildSumSK(period, exp.Category)
+		sum := sums[sk]
+		sum.SK, sum.Period, sum.Category = sk, period, exp.Category
+		if exp.IsPlanned() {
+			sum.Planned = round(sum.Planned + exp.Amount)
+		} else {
+			sum.Sum = round(sum.Sum + exp.Amount)
+		}
+		sums[sk] = sum
+	}
+
+	results := make([]PeriodSum, 0, len(sums))
+	for _, sum := range sums {
+		results = append(results, sum)
+	}
+	sortPeriodSums(results)
+	return results, nil
+}
+
+func sortPeriodSums(sums []PeriodSum) {
+	sort.Slice(sums, func(i, j int) bool { return sums[i].SK < sums[j].SK })
+}
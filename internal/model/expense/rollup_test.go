@@ -0,0 +1,91 @@
+package expense_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestGranularityPeriod(t *testing.T) {
+	cases := []struct {
+		granularity expense.Granularity
+		date        string
+		want        string
+	}{
+		{expense.GranularityDay, "2026-10-19", "2026-10-19"},
+		{expense.GranularityWeek, "2026-10-19", "2026-W43"},
+		{expense.GranularityWeek, "2026-01-01", "2026-W01"},
+		{expense.GranularityWeek, "2027-01-01", "2026-W53"},
+		{expense.GranularityWeek, "2024-12-30", "2025-W01"},
+		{expense.GranularityMonth, "2026-10-19", "2026-10"},
+		{expense.GranularityYear, "2026-10-19", "2026"},
+	}
+
+	for _, tc := range cases {
+		got, err := tc.granularity.Period(tc.date)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("%s period of %s: got %q, want %q", tc.granularity, tc.date, got, tc.want)
+		}
+	}
+
+	if _, err := expense.GranularityDay.Period("2026-13-01"); err == nil {
+		t.Error("expected an error for invalid date but didn't get one")
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	if g, ok := expense.ParseGranularity("week"); !ok || g != expense.GranularityWeek {
+		t.Errorf("expected week granularity, got %q", g)
+	}
+	if _, ok := expense.ParseGranularity("quarter"); ok {
+		t.Error("expected unknown granularity not to parse")
+	}
+}
+
+func TestSumByPeriod(t *testing.T) {
+	expenses := []expense.Expense{
+		{Date: "2026-10-19", Category: "Food", Amount: 10.1},
+		{Date: "2026-10-25", Category: "Food", Amount: 20.2},
+		{Date: "2026-10-26", Category: "Food", Amount: 5},
+		{Date: "2026-10-20", Category: "Fuel", Amount: 100},
+	}
+
+	got, err := expense.SumByPeriod(expense.GranularityWeek, expenses)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	want := []expense.PeriodSum{
+		{SK: "2026-W43::Food", Period: "2026-W43", Category: "Food", Sum: 30.3},
+		{SK: "2026-W43::Fuel", Period: "2026-W43", Category: "Fuel", Sum: 100},
+		{SK: "2026-W44::Food", Period: "2026-W44", Category: "Food", Sum: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestSumByPeriodSplitsPlanned(t *testing.T) {
+	expenses := []expense.Expense{
+		{Date: "2026-10-19", Category: "Bills", Amount: 10, Status: expense.StatusCleared},
+		{Date: "2026-10-20", Category: "Bills", Amount: 5, Status: expense.StatusPending},
+		{Date: "2026-10-21", Category: "Bills", Amount: 120, Status: expense.StatusPlanned},
+		{Date: "2026-10-22", Category: "Bills", Amount: 1},
+	}
+
+	got, err := expense.SumByPeriod(expense.GranularityMonth, expenses)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	want := []expense.PeriodSum{
+		{SK: "2026-10::Bills", Period: "2026-10", Category: "Bills", Sum: 16, Planned: 120},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package expense
+
+import (
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+// Expenses are cleared once paid, pending while e.g. a card pre-authorization
+// waits to be settled, and planned when entered ahead of time, e.g. upcoming
+// bills. Cleared and pending expenses are committed spending, while planned
+// ones are summed up apart from them. Expenses written before statuses were
+// introduced have none and count as cleared.
+const (
+	StatusCleared = "cleared"
+	StatusPending = "pending"
+	StatusPlanned = "planned"
+
+	// UpcomingDays is how far ahead planned expenses are listed as upcoming.
+	UpcomingDays = 30
+)
+
+var Statuses = []string{StatusCleared, StatusPending, StatusPlanned}
+
+func ValidateStatus(status string) (isValid bool, errMessages validator.ErrMessages) {
+	v := validator.NewValidator()
+	v.Check(validator.OneOf("status", status, Statuses))
+	return v.Validate()
+}
+
+// IsPlanned reports whether the expense is not committed spending yet.
+func (e Expense) IsPlanned() bool {
+	return e.Status == StatusPlanned
+}
+
+// sumAttribute returns the attribute of sum items the expense counts towards.
+func (e Expense) sumAttribute() string {
+	if e.IsPlanned() {
+		return "planned"
+	}
+	return "sum"
+}
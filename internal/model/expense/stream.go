@@ -0,0 +1,93 @@
+package expense
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	streamEventPKPrefix = "streamevent"
+
+	// Applied stream events are remembered for as long as the stream retains
+	// them, so that redelivered ones are skipped.
+	streamEventTTL = 24 * time.Hour
+)
+
+// StreamChange is a change of a single expense item read from the table
+// stream. Old is nil for created expenses and New is nil for deleted ones.
+type StreamChange struct {
+	EventID string
+	Old     *Expense
+	New     *Expense
+}
+
+// streamEvent marks a stream event as applied.
+type streamEvent struct {
+	PK        string `dynamodbav:"PK"`
+	SK        string `dynamodbav:"SK"`
+	ExpiresAt int64  `dynamodbav:"expiresAt"`
+}
+
+// VaultIDFromPK returns the vault of an expense item with given PK.
+func VaultIDFromPK(pk string) (string, bool) {
+	return strings.CutPrefix(pk, pkPrefix+"::")
+}
+
+// ApplyStreamChange updates sums and name stats of the vault with the
+// expense change. Each event is applied once, even if delivered again.
+func (es *DDBStore) ApplyStreamChange(ctx context.Context, change StreamChange, vaultID string) error {
+	removed, added := []Expense{}, []Expense{}
+	if change.Old != nil {
+		removed = append(removed, *change.Old)
+	}
+	if change.New != nil {
+		added = append(added, *change.New)
+	}
+
+	marker, err := attributevalue.MarshalMap(streamEvent{
+		PK:        streamEventPKPrefix + "::" + vaultID,
+		SK:        change.EventID,
+		ExpiresAt: time.Now().Add(streamEventTTL).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream event marker: %w", err)
+	}
+
+	sumItems, err := es.sumDeltas(vaultID, removed, added)
+	if err != nil {
+		return err
+	}
+	nameStatsItems, err := es.nameStatsWrites(ctx, vaultID, removed, added)
+	if err != nil {
+		return err
+	}
+
+	items := []types.TransactWriteItem{{Put: &types.Put{
+		TableName:           &es.tableName,
+		Item:                marker,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	}}}
+	items = append(items, sumItems...)
+	items = append(items, nameStatsItems...)
+
+	_, err = es.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		if isConditionalCheckFailed(err, 0) {
+			return nil
+		}
+		return fmt.Errorf("failed to apply stream event %s: %w", change.EventID, err)
+	}
+
+	if err := es.deleteEmptySums(ctx, vaultID, removed, added); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -22,3 +22,21 @@ func (e *NotFoundError) Unwrap() error { return e.Err }
 func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("expense category with SK='%s' not found", e.SK)
 }
+
+type ParentNotFoundError struct {
+	Name   string
+	Parent string
+}
+
+func (e *ParentNotFoundError) Error() string {
+	return fmt.Sprintf("parent category '%s' of expense category '%s' not found", e.Parent, e.Name)
+}
+
+type MigrationInProgressError struct {
+	Name   string
+	Target string
+}
+
+func (e *MigrationInProgressError) Error() string {
+	return fmt.Sprintf("expense category '%s' is already being moved into '%s'", e.Name, e.Target)
+}
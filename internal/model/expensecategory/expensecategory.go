@@ -10,20 +10,58 @@ const (
 )
 
 type Category struct {
-	PK                  string `dynamodbav:"PK"`
-	Name                string `dynamodbav:"SK"`
-	CreatedBy           string `dynamodbav:"createdBy"`
+	PK                  string     `dynamodbav:"PK"`
+	Name                string     `dynamodbav:"SK"`
+	Parent              string     `dynamodbav:"parent,omitempty"`
+	Color               string     `dynamodbav:"color,omitempty"`
+	Icon                string     `dynamodbav:"icon,omitempty"`
+	Order               int        `dynamodbav:"order"`
+	Archived            bool       `dynamodbav:"archived"`
+	CreatedBy           string     `dynamodbav:"createdBy"`
+	Migration           *Migration `dynamodbav:"migration,omitempty"`
 	validator.Validator `dynamodbav:"-"`
 }
 
-func New(name string) (category Category, isValid bool, errMessages validator.ErrMessages) {
+// Migration tracks progress of moving all expenses of a category into Target
+// category. Once done, the source category is removed. When Merge is false,
+// Target is a new name for the category and is created when migration starts.
+type Migration struct {
+	Target    string `dynamodbav:"target"`
+	Merge     bool   `dynamodbav:"merge"`
+	Cursor    string `dynamodbav:"cursor"`
+	Processed int    `dynamodbav:"processed"`
+	Total     int    `dynamodbav:"total"`
+}
+
+// New creates a category. Parent is the name of an existing category, or an
+// empty string for a top level category.
+func New(name, parent string) (category Category, isValid bool, errMessages validator.ErrMessages) {
 	category = Category{
-		Name: name,
+		Name:   name,
+		Parent: parent,
 	}
 	category.Check(validator.StringLengthBetween("name", name, CategoryNameMinLength, CategoryNameMaxLength))
+	if parent != "" {
+		category.Check(validator.StringLengthBetween("parent", parent, CategoryNameMinLength, CategoryNameMaxLength))
+		category.Check(parent != name, "parent", "category cannot be its own parent")
+	}
 	if isValid, errMessages = category.Validate(); !isValid {
 		return Category{}, false, errMessages
 	}
 
 	return category, true, nil
 }
+
+// Returns a copy of the category under a new name, keeping its parent and
+// metadata, to be created as the target of a rename.
+func (c Category) renamedTo(name string) Category {
+	return Category{
+		Name:      name,
+		Parent:    c.Parent,
+		Color:     c.Color,
+		Icon:      c.Icon,
+		Order:     c.Order,
+		Archived:  c.Archived,
+		CreatedBy: c.CreatedBy,
+	}
+}
@@ -44,6 +44,7 @@ func (cs *DDBStore) Create(ctx context.Context, categoryFC Category, userID, vau
 		Category{
 			PK:        pk,
 			Name:      categoryFC.Name,
+			Parent:    categoryFC.Parent,
 			CreatedBy: userID,
 		},
 	)
@@ -51,6 +52,10 @@ func (cs *DDBStore) Create(ctx context.Context, categoryFC Category, userID, vau
 		return fmt.Errorf("failed to marshal expense category: %w", err)
 	}
 
+	if categoryFC.Parent != "" {
+		return cs.createWithParent(ctx, categoryFC, item, vaultID)
+	}
+
 	_, err = cs.client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName:           &cs.tableName,
 		Item:                item,
@@ -68,9 +73,61 @@ func (cs *DDBStore) Create(ctx context.Context, categoryFC Category, userID, vau
 	return nil
 }
 
+// createWithParent puts the category only if its parent category still exists.
+func (cs *DDBStore) createWithParent(ctx context.Context, categoryFC Category, item map[string]types.AttributeValue, vaultID string) error {
+	parent := Category{Name: categoryFC.Parent}
+
+	_, err := cs.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				ConditionCheck: &types.ConditionCheck{
+					TableName:           &cs.tableName,
+					Key:                 parent.getKey(vaultID),
+					ConditionExpression: aws.String("attribute_exists(SK)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           &cs.tableName,
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(SK)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		var transactionErr *types.TransactionCanceledException
+		if errors.As(err, &transactionErr) && len(transactionErr.CancellationReasons) == 2 {
+			reasons := transactionErr.CancellationReasons
+			if reasons[0].Code != nil && *reasons[0].Code == "ConditionalCheckFailed" {
+				return &ParentNotFoundError{Name: categoryFC.Name, Parent: categoryFC.Parent}
+			}
+			if reasons[1].Code != nil && *reasons[1].Code == "ConditionalCheckFailed" {
+				return &AlreadyExistsError{PK: buildPK(vaultID), Name: categoryFC.Name}
+			}
+		}
+		return fmt.Errorf("failed to put expense category with parent into DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the category. Its subcategories are moved under its parent.
 func (cs *DDBStore) Delete(ctx context.Context, name, vaultID string) error {
 	categoryFD := Category{Name: name}
-	_, err := cs.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+
+	found, err := cs.FindOne(ctx, name, vaultID)
+	var notFoundErr *NotFoundError
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return err
+	}
+	if err == nil {
+		if err := cs.reparentChildren(ctx, name, found.Parent, vaultID); err != nil {
+			return err
+		}
+	}
+
+	_, err = cs.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &cs.tableName,
 		Key:       categoryFD.getKey(vaultID),
 	})
@@ -81,6 +138,43 @@ func (cs *DDBStore) Delete(ctx context.Context, name, vaultID string) error {
 	return nil
 }
 
+// reparentChildren moves all direct subcategories of category `name` under
+// `newParent`, or to the top level when newParent is empty.
+func (cs *DDBStore) reparentChildren(ctx context.Context, name, newParent, vaultID string) error {
+	categories, err := cs.FindAll(ctx, vaultID)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range categories {
+		if child.Parent != name {
+			continue
+		}
+
+		update := expression.Set(expression.Name("parent"), expression.Value(newParent))
+		if newParent == "" {
+			update = expression.Remove(expression.Name("parent"))
+		}
+		expr, err := expression.NewBuilder().WithUpdate(update).Build()
+		if err != nil {
+			return fmt.Errorf("failed to build expression for reparenting: %w", err)
+		}
+
+		_, err = cs.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 &cs.tableName,
+			Key:                       child.getKey(vaultID),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move expense category '%s' under '%s': %w", child.Name, newParent, err)
+		}
+	}
+
+	return nil
+}
+
 func (cs *DDBStore) FindAll(ctx context.Context, vaultID string) ([]Category, error) {
 	pk := buildPK(vaultID)
 	keyCond := expression.Key("PK").Equal(expression.Value(pk))
@@ -130,3 +224,201 @@ func (cs *DDBStore) query(ctx context.Context, expr expression.Expression) ([]Ca
 
 	return categories, nil
 }
+
+func (cs *DDBStore) FindOne(ctx context.Context, name, vaultID string) (Category, error) {
+	categoryFD := Category{Name: name}
+	response, err := cs.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &cs.tableName,
+		Key:       categoryFD.getKey(vaultID),
+	})
+	if err != nil {
+		return Category{}, fmt.Errorf("GetItem DynamoDB operation failed for expense category name='%s': %w", name, err)
+	}
+	if len(response.Item) == 0 {
+		return Category{}, &NotFoundError{SK: name}
+	}
+
+	var category Category
+	err = attributevalue.UnmarshalMap(response.Item, &category)
+	if err != nil {
+		return Category{}, fmt.Errorf("failed to unmarshal expense category: %w", err)
+	}
+
+	return category, nil
+}
+
+// StartMigration marks category as being moved into migration.Target. For
+// a rename, the target category is created with the same parent and metadata
+// in the same transaction; for a merge, the target category has to exist
+// already.
+func (cs *DDBStore) StartMigration(ctx context.Context, name string, migration Migration, vaultID string) error {
+	source, err := cs.FindOne(ctx, name, vaultID)
+	if err != nil {
+		return err
+	}
+	if source.Migration != nil {
+		return &MigrationInProgressError{Name: name, Target: source.Migration.Target}
+	}
+
+	target := Category{Name: migration.Target}
+	var targetItem types.TransactWriteItem
+
+	if migration.Merge {
+		targetItem = types.TransactWriteItem{
+			ConditionCheck: &types.ConditionCheck{
+				TableName:           &cs.tableName,
+				Key:                 target.getKey(vaultID),
+				ConditionExpression: aws.String("attribute_exists(SK)"),
+			},
+		}
+	} else {
+		renamed := source.renamedTo(migration.Target)
+		renamed.PK = buildPK(vaultID)
+		item, err := attributevalue.MarshalMap(renamed)
+		if err != nil {
+			return fmt.Errorf("failed to marshal expense category: %w", err)
+		}
+		targetItem = types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:           &cs.tableName,
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(SK)"),
+			},
+		}
+	}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("migration"), expression.Value(migration))).
+		WithCondition(expression.AttributeExists(expression.Name("SK")).And(expression.AttributeNotExists(expression.Name("migration")))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for starting migration: %w", err)
+	}
+
+	_, err = cs.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			targetItem,
+			{
+				Update: &types.Update{
+					TableName:                 &cs.tableName,
+					Key:                       source.getKey(vaultID),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
+					UpdateExpression:          expr.Update(),
+					ConditionExpression:       expr.Condition(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		var transactionErr *types.TransactionCanceledException
+		if errors.As(err, &transactionErr) && len(transactionErr.CancellationReasons) == 2 {
+			reasons := transactionErr.CancellationReasons
+			if reasons[0].Code != nil && *reasons[0].Code == "ConditionalCheckFailed" {
+				if migration.Merge {
+					return &NotFoundError{SK: migration.Target}
+				}
+				return &AlreadyExistsError{PK: buildPK(vaultID), Name: migration.Target}
+			}
+			if reasons[1].Code != nil && *reasons[1].Code == "ConditionalCheckFailed" {
+				return &MigrationInProgressError{Name: name}
+			}
+		}
+		return fmt.Errorf("failed to start expense category migration: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMigration saves progress of a migration started with StartMigration.
+func (cs *DDBStore) UpdateMigration(ctx context.Context, name string, migration Migration, vaultID string) error {
+	categoryFU := Category{Name: name}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("migration"), expression.Value(migration))).
+		WithCondition(expression.AttributeExists(expression.Name("migration"))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for updating migration: %w", err)
+	}
+
+	_, err = cs.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &cs.tableName,
+		Key:                       categoryFU.getKey(vaultID),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &NotFoundError{SK: name}
+		}
+		return fmt.Errorf("failed to update expense category migration: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateMetadata saves color, icon, display order and archived flag of an
+// existing category.
+func (cs *DDBStore) UpdateMetadata(ctx context.Context, categoryFU Category, vaultID string) error {
+	update := expression.Set(expression.Name("order"), expression.Value(categoryFU.Order)).
+		Set(expression.Name("archived"), expression.Value(categoryFU.Archived))
+	if categoryFU.Color != "" {
+		update = update.Set(expression.Name("color"), expression.Value(categoryFU.Color))
+	} else {
+		update = update.Remove(expression.Name("color"))
+	}
+	if categoryFU.Icon != "" {
+		update = update.Set(expression.Name("icon"), expression.Value(categoryFU.Icon))
+	} else {
+		update = update.Remove(expression.Name("icon"))
+	}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(expression.AttributeExists(expression.Name("PK"))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for updating expense category: %w", err)
+	}
+
+	_, err = cs.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &cs.tableName,
+		Key:                       categoryFU.getKey(vaultID),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &NotFoundError{SK: categoryFU.Name}
+		}
+		return fmt.Errorf("failed to update expense category: %w", err)
+	}
+
+	return nil
+}
+
+// FinishMigration moves subcategories of migrated category under the target
+// category and deletes the migrated category.
+func (cs *DDBStore) FinishMigration(ctx context.Context, name, vaultID string) error {
+	source, err := cs.FindOne(ctx, name, vaultID)
+	if err != nil {
+		return err
+	}
+	if source.Migration == nil {
+		return &NotFoundError{SK: name}
+	}
+
+	err = cs.reparentChildren(ctx, name, source.Migration.Target, vaultID)
+	if err != nil {
+		return err
+	}
+
+	return cs.Delete(ctx, name, vaultID)
+}
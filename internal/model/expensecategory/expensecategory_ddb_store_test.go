@@ -2,6 +2,7 @@ package expensecategory_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -26,7 +27,7 @@ func TestDDBCreate(t *testing.T) {
 			t.Fatalf("failed querying ddb table for expense categories before putting expense category, %v", err)
 		}
 
-		categoryFC, isValid, errMessages := expensecategory.New("some-name")
+		categoryFC, isValid, errMessages := expensecategory.New("some-name", "")
 		if !isValid {
 			t.Fatalf("didn't expect error but got one: %v", errMessages)
 		}
@@ -44,6 +45,54 @@ func TestDDBCreate(t *testing.T) {
 	})
 }
 
+func TestDDBCreateWithParent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := expensecategory.NewDDBStore(tableName, client)
+
+	child, _, _ := expensecategory.New("Fuel", "Transport")
+
+	t.Run("returns error when parent does not exist", func(t *testing.T) {
+		err := store.Create(ctx, child, "userID", "activeVaultID")
+		var parentNotFoundErr *expensecategory.ParentNotFoundError
+		if !errors.As(err, &parentNotFoundErr) {
+			t.Errorf("expected %T, got %#v", parentNotFoundErr, err)
+		}
+	})
+
+	t.Run("creates child of existing category", func(t *testing.T) {
+		parent, _, _ := expensecategory.New("Transport", "")
+		if err := store.Create(ctx, parent, "userID", "activeVaultID"); err != nil {
+			t.Fatalf("failed putting item into ddb, %v", err)
+		}
+		if err := store.Create(ctx, child, "userID", "activeVaultID"); err != nil {
+			t.Fatalf("failed putting item into ddb, %v", err)
+		}
+
+		categories, err := store.FindAll(ctx, "activeVaultID")
+		if err != nil {
+			t.Fatalf("failed querying ddb table for expense categories, %v", err)
+		}
+		if expensecategory.Parents(categories)["Fuel"] != "Transport" {
+			t.Errorf("expected Fuel to be stored as child of Transport, got %#v", categories)
+		}
+	})
+
+	t.Run("returns error when child already exists", func(t *testing.T) {
+		err := store.Create(ctx, child, "userID", "activeVaultID")
+		var alreadyExistsErr *expensecategory.AlreadyExistsError
+		if !errors.As(err, &alreadyExistsErr) {
+			t.Errorf("expected %T, got %#v", alreadyExistsErr, err)
+		}
+	})
+}
+
 func TestDDBDelete(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -55,7 +104,7 @@ func TestDDBDelete(t *testing.T) {
 
 	store := expensecategory.NewDDBStore(tableName, client)
 
-	categoryFC, isValid, errMessages := expensecategory.New("some-name")
+	categoryFC, isValid, errMessages := expensecategory.New("some-name", "")
 	if !isValid {
 		t.Fatalf("didn't expect error but got one: %v", errMessages)
 	}
@@ -83,3 +132,112 @@ func TestDDBDelete(t *testing.T) {
 		t.Errorf("expected one expense category deleted. got %d", len(newCategories)-len(categories))
 	}
 }
+
+func TestDDBUpdateMetadata(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := expensecategory.NewDDBStore(tableName, client)
+	if err := store.Create(ctx, expensecategory.Category{Name: "Food"}, "userID", "activeVaultID"); err != nil {
+		t.Fatalf("failed putting item into ddb, %v", err)
+	}
+
+	categoryFU := expensecategory.Category{Name: "Food", Color: "#ff6384", Icon: "food", Order: 3, Archived: true}
+	if err := store.UpdateMetadata(ctx, categoryFU, "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	found, err := store.FindOne(ctx, "Food", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if found.Color != categoryFU.Color || found.Icon != categoryFU.Icon || found.Order != categoryFU.Order || !found.Archived {
+		t.Errorf("got %#v, want metadata of %#v", found, categoryFU)
+	}
+
+	err = store.UpdateMetadata(ctx, expensecategory.Category{Name: "Unknown"}, "activeVaultID")
+	var notFoundErr *expensecategory.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("expected %T, got %#v", notFoundErr, err)
+	}
+}
+
+func TestDDBMigration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := expensecategory.NewDDBStore(tableName, client)
+	for _, c := range []expensecategory.Category{{Name: "Transport"}, {Name: "Fuel", Parent: "Transport"}, {Name: "Travel"}} {
+		if err := store.Create(ctx, c, "userID", "activeVaultID"); err != nil {
+			t.Fatalf("failed putting item into ddb, %v", err)
+		}
+	}
+
+	t.Run("rename fails when target already exists", func(t *testing.T) {
+		err := store.StartMigration(ctx, "Transport", expensecategory.Migration{Target: "Travel"}, "activeVaultID")
+		var alreadyExistsErr *expensecategory.AlreadyExistsError
+		if !errors.As(err, &alreadyExistsErr) {
+			t.Errorf("expected %T, got %#v", alreadyExistsErr, err)
+		}
+	})
+
+	t.Run("merge saves progress and finishes", func(t *testing.T) {
+		migration := expensecategory.Migration{Target: "Travel", Merge: true, Total: 10}
+		if err := store.StartMigration(ctx, "Transport", migration, "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		migration.Processed, migration.Cursor = 5, "2024-01-01::cursor"
+		if err := store.UpdateMigration(ctx, "Transport", migration, "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		found, err := store.FindOne(ctx, "Transport", "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if found.Migration == nil || *found.Migration != migration {
+			t.Errorf("got migration %#v, want %#v", found.Migration, migration)
+		}
+
+		if err := store.FinishMigration(ctx, "Transport", "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		categories, err := store.FindAll(ctx, "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if len(categories) != 2 || expensecategory.Parents(categories)["Fuel"] != "Travel" {
+			t.Errorf("expected Fuel to be moved under Travel, got %#v", categories)
+		}
+	})
+
+	t.Run("rename keeps metadata", func(t *testing.T) {
+		categoryFU := expensecategory.Category{Name: "Fuel", Color: "#ff6384", Icon: "fuel", Order: 2, Archived: true}
+		if err := store.UpdateMetadata(ctx, categoryFU, "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if err := store.StartMigration(ctx, "Fuel", expensecategory.Migration{Target: "Petrol"}, "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		found, err := store.FindOne(ctx, "Petrol", "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if found.Parent != "Travel" || found.Color != categoryFU.Color || found.Icon != categoryFU.Icon || found.Order != categoryFU.Order || !found.Archived {
+			t.Errorf("got %#v, want parent and metadata of %#v", found, categoryFU)
+		}
+	})
+}
@@ -10,6 +10,12 @@ type InMemoryStore struct {
 }
 
 func (e *InMemoryStore) Create(ctx context.Context, categoryFC Category, userID, vaultID string) error {
+	if categoryFC.Parent != "" && !slices.ContainsFunc(e.categories, func(c Category) bool { return c.Name == categoryFC.Parent }) {
+		return &ParentNotFoundError{Name: categoryFC.Name, Parent: categoryFC.Parent}
+	}
+	if slices.ContainsFunc(e.categories, func(c Category) bool { return c.Name == categoryFC.Name }) {
+		return &AlreadyExistsError{Name: categoryFC.Name}
+	}
 	categoryFC.CreatedBy = userID
 	e.categories = append(e.categories, categoryFC)
 	return nil
@@ -18,6 +24,14 @@ func (e *InMemoryStore) Create(ctx context.Context, categoryFC Category, userID,
 func (e *InMemoryStore) Delete(ctx context.Context, name, vaultID string) error {
 	var deleted bool
 
+	if found, err := e.FindOne(ctx, name, vaultID); err == nil {
+		for i, category := range e.categories {
+			if category.Parent == name {
+				e.categories[i].Parent = found.Parent
+			}
+		}
+	}
+
 	e.categories = slices.DeleteFunc(e.categories, func(category Category) bool {
 		deleted = true
 		return category.Name == name
@@ -33,3 +47,76 @@ func (e *InMemoryStore) Delete(ctx context.Context, name, vaultID string) error
 func (e *InMemoryStore) FindAll(ctx context.Context, vaultID string) ([]Category, error) {
 	return e.categories, nil
 }
+
+func (e *InMemoryStore) FindOne(ctx context.Context, name, vaultID string) (Category, error) {
+	for _, category := range e.categories {
+		if category.Name == name {
+			return category, nil
+		}
+	}
+	return Category{}, &NotFoundError{SK: name}
+}
+
+func (e *InMemoryStore) StartMigration(ctx context.Context, name string, migration Migration, vaultID string) error {
+	source, err := e.FindOne(ctx, name, vaultID)
+	if err != nil {
+		return err
+	}
+	if source.Migration != nil {
+		return &MigrationInProgressError{Name: name, Target: source.Migration.Target}
+	}
+
+	_, err = e.FindOne(ctx, migration.Target, vaultID)
+	if migration.Merge && err != nil {
+		return err
+	}
+	if !migration.Merge {
+		if err == nil {
+			return &AlreadyExistsError{Name: migration.Target}
+		}
+		e.categories = append(e.categories, source.renamedTo(migration.Target))
+	}
+
+	return e.UpdateMigration(ctx, name, migration, vaultID)
+}
+
+func (e *InMemoryStore) UpdateMigration(ctx context.Context, name string, migration Migration, vaultID string) error {
+	for i, category := range e.categories {
+		if category.Name == name {
+			e.categories[i].Migration = &migration
+			return nil
+		}
+	}
+	return &NotFoundError{SK: name}
+}
+
+func (e *InMemoryStore) UpdateMetadata(ctx context.Context, categoryFU Category, vaultID string) error {
+	for i, category := range e.categories {
+		if category.Name == categoryFU.Name {
+			e.categories[i].Color = categoryFU.Color
+			e.categories[i].Icon = categoryFU.Icon
+			e.categories[i].Order = categoryFU.Order
+			e.categories[i].Archived = categoryFU.Archived
+			return nil
+		}
+	}
+	return &NotFoundError{SK: categoryFU.Name}
+}
+
+func (e *InMemoryStore) FinishMigration(ctx context.Context, name, vaultID string) error {
+	source, err := e.FindOne(ctx, name, vaultID)
+	if err != nil {
+		return err
+	}
+	if source.Migration == nil {
+		return &NotFoundError{SK: name}
+	}
+
+	for i, category := range e.categories {
+		if category.Parent == name {
+			e.categories[i].Parent = source.Migration.Target
+		}
+	}
+
+	return e.Delete(ctx, name, vaultID)
+}
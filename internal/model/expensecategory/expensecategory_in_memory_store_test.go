@@ -29,6 +29,23 @@ func TestInMemoryCreate(t *testing.T) {
 	}
 }
 
+func TestInMemoryCreateWithParent(t *testing.T) {
+	ctx := context.Background()
+	store := expensecategory.InMemoryStore{}
+
+	err := store.Create(ctx, expensecategory.Category{Name: "Fuel", Parent: "Transport"}, "userID", "activeVaultID")
+	var parentNotFoundErr *expensecategory.ParentNotFoundError
+	if !errors.As(err, &parentNotFoundErr) {
+		t.Fatalf("expected %T, got %#v", parentNotFoundErr, err)
+	}
+
+	_ = store.Create(ctx, expensecategory.Category{Name: "Transport"}, "userID", "activeVaultID")
+	err = store.Create(ctx, expensecategory.Category{Name: "Fuel", Parent: "Transport"}, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+}
+
 func TestInMemoryDelete(t *testing.T) {
 	t.Run("deletes existing categories", func(t *testing.T) {
 		ctx := context.Background()
@@ -53,6 +70,23 @@ func TestInMemoryDelete(t *testing.T) {
 		}
 	})
 
+	t.Run("moves subcategories under parent of deleted category", func(t *testing.T) {
+		ctx := context.Background()
+		store := expensecategory.InMemoryStore{}
+		_ = store.Create(ctx, expensecategory.Category{Name: "Transport"}, "userID", "activeVaultID")
+		_ = store.Create(ctx, expensecategory.Category{Name: "Car", Parent: "Transport"}, "userID", "activeVaultID")
+		_ = store.Create(ctx, expensecategory.Category{Name: "Fuel", Parent: "Car"}, "userID", "activeVaultID")
+
+		if err := store.Delete(ctx, "Car", "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error while deleting category but got one: %v", err)
+		}
+
+		categories, _ := store.FindAll(ctx, "activeVaultID")
+		if got := expensecategory.Parents(categories)["Fuel"]; got != "Transport" {
+			t.Errorf("got parent %q, want %q", got, "Transport")
+		}
+	})
+
 	t.Run("returns proper error when category for deletion does not exist", func(t *testing.T) {
 		ctx := context.Background()
 		store := expensecategory.InMemoryStore{}
@@ -69,3 +103,84 @@ func TestInMemoryDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestInMemoryUpdateMetadata(t *testing.T) {
+	ctx := context.Background()
+	store := expensecategory.InMemoryStore{}
+	_ = store.Create(ctx, expensecategory.Category{Name: "Food"}, "userID", "activeVaultID")
+
+	categoryFU := expensecategory.Category{Name: "Food", Color: "#ff6384", Icon: "food", Order: 3, Archived: true}
+	if err := store.UpdateMetadata(ctx, categoryFU, "activeVaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	found, _ := store.FindOne(ctx, "Food", "activeVaultID")
+	if found.Color != categoryFU.Color || found.Icon != categoryFU.Icon || found.Order != categoryFU.Order || !found.Archived {
+		t.Errorf("got %#v, want metadata of %#v", found, categoryFU)
+	}
+
+	err := store.UpdateMetadata(ctx, expensecategory.Category{Name: "Unknown"}, "activeVaultID")
+	var notFoundErr *expensecategory.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("expected %T, got %#v", notFoundErr, err)
+	}
+}
+
+func TestInMemoryMigration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rename creates target and removes source after finishing", func(t *testing.T) {
+		store := expensecategory.InMemoryStore{}
+		_ = store.Create(ctx, expensecategory.Category{Name: "Transport"}, "userID", "activeVaultID")
+		_ = store.Create(ctx, expensecategory.Category{Name: "Fuel", Parent: "Transport"}, "userID", "activeVaultID")
+
+		err := store.StartMigration(ctx, "Transport", expensecategory.Migration{Target: "Travel"}, "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		err = store.StartMigration(ctx, "Transport", expensecategory.Migration{Target: "Other"}, "activeVaultID")
+		var inProgressErr *expensecategory.MigrationInProgressError
+		if !errors.As(err, &inProgressErr) {
+			t.Errorf("expected %T, got %#v", inProgressErr, err)
+		}
+
+		if err := store.FinishMigration(ctx, "Transport", "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		categories, _ := store.FindAll(ctx, "activeVaultID")
+		parents := expensecategory.Parents(categories)
+		if len(categories) != 2 || parents["Fuel"] != "Travel" {
+			t.Errorf("expected Fuel to be moved under Travel, got %#v", categories)
+		}
+	})
+
+	t.Run("rename keeps metadata", func(t *testing.T) {
+		store := expensecategory.InMemoryStore{}
+		_ = store.Create(ctx, expensecategory.Category{Name: "Transport"}, "userID", "activeVaultID")
+		_ = store.Create(ctx, expensecategory.Category{Name: "Fuel", Parent: "Transport"}, "userID", "activeVaultID")
+		categoryFU := expensecategory.Category{Name: "Fuel", Color: "#ff6384", Icon: "fuel", Order: 2, Archived: true}
+		_ = store.UpdateMetadata(ctx, categoryFU, "activeVaultID")
+
+		if err := store.StartMigration(ctx, "Fuel", expensecategory.Migration{Target: "Petrol"}, "activeVaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+
+		found, _ := store.FindOne(ctx, "Petrol", "activeVaultID")
+		if found.Parent != "Transport" || found.Color != categoryFU.Color || found.Icon != categoryFU.Icon || found.Order != categoryFU.Order || !found.Archived {
+			t.Errorf("got %#v, want parent and metadata of %#v", found, categoryFU)
+		}
+	})
+
+	t.Run("merge requires existing target", func(t *testing.T) {
+		store := expensecategory.InMemoryStore{}
+		_ = store.Create(ctx, expensecategory.Category{Name: "Fuel"}, "userID", "activeVaultID")
+
+		err := store.StartMigration(ctx, "Fuel", expensecategory.Migration{Target: "Transport", Merge: true}, "activeVaultID")
+		var notFoundErr *expensecategory.NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Errorf("expected %T, got %#v", notFoundErr, err)
+		}
+	})
+}
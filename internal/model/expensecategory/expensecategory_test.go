@@ -8,21 +8,37 @@ import (
 
 func TestNew(t *testing.T) {
 	t.Run("returns error when expense category has invalid length", func(t *testing.T) {
-		_, isValid, _ := expensecategory.New("a")
+		_, isValid, _ := expensecategory.New("a", "")
 		if isValid {
 			t.Error("expected error when category name is too short")
 		}
 
-		_, isValid, _ = expensecategory.New(string(make([]byte, 100)))
+		_, isValid, _ = expensecategory.New(string(make([]byte, 100)), "")
 		if isValid {
 			t.Error("expected error when category name is too long")
 		}
 	})
 
 	t.Run("creates expense category when category name is valid", func(t *testing.T) {
-		_, isValid, errMessages := expensecategory.New("food")
+		_, isValid, errMessages := expensecategory.New("food", "")
 		if !isValid {
 			t.Errorf("didn't expect error: %v", errMessages)
 		}
 	})
+	t.Run("returns error when category is its own parent", func(t *testing.T) {
+		_, isValid, errMessages := expensecategory.New("Fuel", "Fuel")
+		if isValid || len(errMessages["parent"]) == 0 {
+			t.Errorf("expected parent error, got %v", errMessages)
+		}
+	})
+
+	t.Run("creates child expense category", func(t *testing.T) {
+		category, isValid, errMessages := expensecategory.New("Fuel", "Transport")
+		if !isValid {
+			t.Errorf("didn't expect error: %v", errMessages)
+		}
+		if category.Parent != "Transport" {
+			t.Errorf("got parent %q, want %q", category.Parent, "Transport")
+		}
+	})
 }
@@ -0,0 +1,111 @@
+package expensecategory
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+// Icon can be assigned to a category to be displayed next to its name.
+type Icon struct {
+	Key   string
+	Emoji string
+}
+
+// Icons lists icons that can be assigned to a category.
+var Icons = []Icon{
+	{"cart", "🛒"},
+	{"food", "🍽️"},
+	{"car", "🚗"},
+	{"fuel", "⛽"},
+	{"home", "🏠"},
+	{"bills", "🧾"},
+	{"health", "💊"},
+	{"fun", "🎉"},
+	{"travel", "✈️"},
+	{"gift", "🎁"},
+	{"clothes", "👕"},
+	{"education", "📚"},
+	{"pets", "🐾"},
+	{"kids", "🧸"},
+	{"other", "📦"},
+}
+
+// IconEmoji returns emoji of the icon with given key, or empty string if
+// there's none.
+func IconEmoji(key string) string {
+	for _, icon := range Icons {
+		if icon.Key == key {
+			return icon.Emoji
+		}
+	}
+	return ""
+}
+
+func iconKeys() []string {
+	keys := make([]string, len(Icons))
+	for i, icon := range Icons {
+		keys[i] = icon.Key
+	}
+	return keys
+}
+
+// DefaultColors is the palette used for categories without a color of their
+// own, so that every category keeps the same color on every chart.
+var DefaultColors = []string{
+	"#36a2eb", "#ff6384", "#4bc0c0", "#ff9f40", "#9966ff", "#ffcd56",
+	"#c45850", "#3cba9f", "#e8c3b9", "#8e5ea2", "#3e95cd", "#c9cbcf",
+}
+
+// NewMetadataFU validates display settings of the category with given name.
+func NewMetadataFU(name, color, icon string, order int, archived bool) (category Category, isValid bool, errMessages validator.ErrMessages) {
+	category = Category{
+		Name:     name,
+		Color:    strings.ToLower(strings.TrimSpace(color)),
+		Icon:     icon,
+		Order:    order,
+		Archived: archived,
+	}
+	if category.Color != "" {
+		category.Check(validator.IsHexColor("color", category.Color))
+	}
+	if category.Icon != "" {
+		category.Check(validator.OneOf("icon", category.Icon, iconKeys()))
+	}
+	if isValid, errMessages = category.Validate(); !isValid {
+		return Category{}, false, errMessages
+	}
+
+	return category, true, nil
+}
+
+// DefaultColor picks a color from DefaultColors based on the category name.
+func DefaultColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return DefaultColors[h.Sum32()%uint32(len(DefaultColors))]
+}
+
+// DisplayColor returns the category color, or its default color if not set.
+func (c Category) DisplayColor() string {
+	if c.Color != "" {
+		return c.Color
+	}
+	return DefaultColor(c.Name)
+}
+
+// ColorPicker returns a function resolving colors of given categories by name.
+// Names without a category, e.g. of orphaned expenses, get their default color.
+func ColorPicker(categories []Category) func(name string) string {
+	colors := make(map[string]string, len(categories))
+	for _, c := range categories {
+		colors[c.Name] = c.DisplayColor()
+	}
+	return func(name string) string {
+		if color, found := colors[name]; found {
+			return color
+		}
+		return DefaultColor(name)
+	}
+}
@@ -0,0 +1,47 @@
+package expensecategory_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expensecategory"
+)
+
+func TestNewMetadataFU(t *testing.T) {
+	t.Run("returns error for invalid color and icon", func(t *testing.T) {
+		_, isValid, errMessages := expensecategory.NewMetadataFU("Food", "red", "unknown", 0, false)
+		if isValid || len(errMessages["color"]) == 0 || len(errMessages["icon"]) == 0 {
+			t.Errorf("expected color and icon errors, got %v", errMessages)
+		}
+	})
+
+	t.Run("normalizes color", func(t *testing.T) {
+		category, isValid, errMessages := expensecategory.NewMetadataFU("Food", " #FF6384 ", "food", 2, true)
+		if !isValid {
+			t.Fatalf("didn't expect error: %v", errMessages)
+		}
+		if category.Color != "#ff6384" || category.Icon != "food" || category.Order != 2 || !category.Archived {
+			t.Errorf("got %#v", category)
+		}
+	})
+
+	t.Run("allows empty color and icon", func(t *testing.T) {
+		_, isValid, errMessages := expensecategory.NewMetadataFU("Food", "", "", 0, false)
+		if !isValid {
+			t.Errorf("didn't expect error: %v", errMessages)
+		}
+	})
+}
+
+func TestColorPicker(t *testing.T) {
+	colorOf := expensecategory.ColorPicker([]expensecategory.Category{{Name: "Food", Color: "#123456"}, {Name: "Fuel"}})
+
+	if got := colorOf("Food"); got != "#123456" {
+		t.Errorf("got %q, want category color", got)
+	}
+	if got, want := colorOf("Fuel"), expensecategory.DefaultColor("Fuel"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := colorOf("Deleted"), expensecategory.DefaultColor("Deleted"); got != want {
+		t.Errorf("got %q, want %q for unknown category", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package expensecategory
+
+import (
+	"sort"
+)
+
+// Parents maps category names to names of their parent categories. Top level
+// categories are not present in the map.
+func Parents(categories []Category) map[string]string {
+	parents := make(map[string]string, len(categories))
+	for _, c := range categories {
+		if c.Parent != "" {
+			parents[c.Name] = c.Parent
+		}
+	}
+	return parents
+}
+
+// Ancestors returns parent, grandparent etc. of given category, nearest first.
+// Broken or cyclic links end the chain instead of looping forever.
+func Ancestors(parents map[string]string, name string) []string {
+	ancestors := []string{}
+	seen := map[string]bool{name: true}
+	for parent, found := parents[name]; found && !seen[parent]; parent, found = parents[parent] {
+		ancestors = append(ancestors, parent)
+		seen[parent] = true
+	}
+	return ancestors
+}
+
+// Root returns the top level category that given category belongs to.
+func Root(parents map[string]string, name string) string {
+	if ancestors := Ancestors(parents, name); len(ancestors) > 0 {
+		return ancestors[len(ancestors)-1]
+	}
+	return name
+}
+
+// Subtree returns given category name followed by names of all its descendants.
+func Subtree(categories []Category, name string) []string {
+	children := map[string][]string{}
+	for _, c := range categories {
+		if c.Parent != "" {
+			children[c.Parent] = append(children[c.Parent], c.Name)
+		}
+	}
+
+	subtree := []string{}
+	seen := map[string]bool{}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		subtree = append(subtree, current)
+		queue = append(queue, children[current]...)
+	}
+	return subtree
+}
+
+// ExpandSelection replaces every selected category with its whole subtree, so
+// that selecting a parent category also selects all of its children.
+func ExpandSelection(categories []Category, selected []string) []string {
+	if len(selected) == 0 {
+		return selected
+	}
+
+	expanded := []string{}
+	seen := map[string]bool{}
+	for _, name := range selected {
+		for _, n := range Subtree(categories, name) {
+			if !seen[n] {
+				seen[n] = true
+				expanded = append(expanded, n)
+			}
+		}
+	}
+	return expanded
+}
+
+// SortHierarchically orders categories by their display order and name, with
+// every category directly followed by its children, and returns depth of each
+// category in the tree (0 for top level categories).
+func SortHierarchically(categories []Category) ([]Category, map[string]int) {
+	byName := make(map[string]Category, len(categories))
+	children := map[string][]Category{}
+	for _, c := range categories {
+		byName[c.Name] = c
+	}
+	roots := []Category{}
+	for _, c := range categories {
+		if _, parentExists := byName[c.Parent]; c.Parent == "" || !parentExists {
+			roots = append(roots, c)
+			continue
+		}
+		children[c.Parent] = append(children[c.Parent], c)
+	}
+
+	sorted := make([]Category, 0, len(categories))
+	depths := make(map[string]int, len(categories))
+
+	var visit func(level []Category, depth int)
+	visit = func(level []Category, depth int) {
+		sort.Slice(level, func(i, j int) bool {
+			if level[i].Order != level[j].Order {
+				return level[i].Order < level[j].Order
+			}
+			return level[i].Name < level[j].Name
+		})
+		for _, c := range level {
+			if _, visited := depths[c.Name]; visited {
+				continue
+			}
+			depths[c.Name] = depth
+			sorted = append(sorted, c)
+			visit(children[c.Name], depth+1)
+		}
+	}
+	visit(roots, 0)
+
+	return sorted, depths
+}
+
+// CanMigrate reports whether all expenses of category from can be moved into
+// category to. Moving a category into its own subtree would turn its
+// subcategories into a cycle once they are attached to the target.
+func CanMigrate(categories []Category, from, to string) bool {
+	if from == to {
+		return false
+	}
+	for _, name := range Subtree(categories, from) {
+		if name == to {
+			return false
+		}
+	}
+	return true
+}
+
+// Orphan is a category name used by expenses that has no category item.
+type Orphan struct {
+	Name         string `json:"name"`
+	ExpenseCount int    `json:"expenseCount"`
+}
+
+// FindOrphans returns category names from expenseCounts that don't belong to
+// any of the categories, sorted by name.
+func FindOrphans(categories []Category, expenseCounts map[string]int) []Orphan {
+	existing := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		existing[c.Name] = true
+	}
+
+	orphans := []Orphan{}
+	for name, count := range expenseCounts {
+		if !existing[name] && count > 0 {
+			orphans = append(orphans, Orphan{Name: name, ExpenseCount: count})
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Name < orphans[j].Name })
+
+	return orphans
+}
@@ -0,0 +1,97 @@
+package expensecategory_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expensecategory"
+)
+
+var testTree = []expensecategory.Category{
+	{Name: "Public transport", Parent: "Transport"},
+	{Name: "Food"},
+	{Name: "Transport"},
+	{Name: "Fuel", Parent: "Transport"},
+	{Name: "Diesel", Parent: "Fuel"},
+}
+
+func TestRoot(t *testing.T) {
+	parents := expensecategory.Parents(testTree)
+
+	cases := map[string]string{
+		"Diesel":    "Transport",
+		"Fuel":      "Transport",
+		"Transport": "Transport",
+		"Food":      "Food",
+		"Unknown":   "Unknown",
+	}
+	for name, want := range cases {
+		if got := expensecategory.Root(parents, name); got != want {
+			t.Errorf("root of %q: got %q, want %q", name, got, want)
+		}
+	}
+
+	t.Run("does not loop on cyclic parents", func(t *testing.T) {
+		cyclic := map[string]string{"a": "b", "b": "a"}
+		if got := expensecategory.Ancestors(cyclic, "a"); !reflect.DeepEqual(got, []string{"b"}) {
+			t.Errorf("got %v", got)
+		}
+	})
+}
+
+func TestExpandSelection(t *testing.T) {
+	got := expensecategory.ExpandSelection(testTree, []string{"Transport", "Diesel", "Food"})
+	want := []string{"Transport", "Public transport", "Fuel", "Diesel", "Food"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := expensecategory.ExpandSelection(testTree, nil); len(got) != 0 {
+		t.Errorf("expected empty selection to stay empty, got %v", got)
+	}
+}
+
+func TestSortHierarchically(t *testing.T) {
+	sorted, depths := expensecategory.SortHierarchically(testTree)
+
+	names := []string{}
+	for _, c := range sorted {
+		names = append(names, c.Name)
+	}
+	wantNames := []string{"Food", "Transport", "Fuel", "Diesel", "Public transport"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("got %v, want %v", names, wantNames)
+	}
+
+	wantDepths := map[string]int{"Food": 0, "Transport": 0, "Fuel": 1, "Diesel": 2, "Public transport": 1}
+	if !reflect.DeepEqual(depths, wantDepths) {
+		t.Errorf("got %v, want %v", depths, wantDepths)
+	}
+}
+
+func TestSortHierarchicallyByOrder(t *testing.T) {
+	sorted, _ := expensecategory.SortHierarchically([]expensecategory.Category{
+		{Name: "Food", Order: 2},
+		{Name: "Transport", Order: 1},
+		{Name: "Public transport", Parent: "Transport"},
+		{Name: "Fuel", Parent: "Transport", Order: 1},
+		{Name: "Bills", Order: 2},
+	})
+
+	names := []string{}
+	for _, c := range sorted {
+		names = append(names, c.Name)
+	}
+	want := []string{"Transport", "Public transport", "Fuel", "Bills", "Food"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	got := expensecategory.FindOrphans(testTree, map[string]int{"Fuel": 3, "Groceries": 2, "Bills": 1, "Food": 0, "Old": 0})
+	want := []expensecategory.Orphan{{Name: "Bills", ExpenseCount: 1}, {Name: "Groceries", ExpenseCount: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package expenserule
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+const (
+	MatchContains = "contains"
+	MatchRegexp   = "regexp"
+
+	PatternMinLength = 1
+	PatternMaxLength = 100
+)
+
+var MatchTypes = []string{MatchContains, MatchRegexp}
+
+// Rule sets category and/or payment method of expenses whose name matches
+// Pattern and whose amount falls into the optional [MinAmount, MaxAmount] range.
+// Rules are evaluated in ascending Priority order and the first match wins.
+type Rule struct {
+	PK                  string   `dynamodbav:"PK"`
+	ID                  string   `dynamodbav:"SK"`
+	MatchType           string   `dynamodbav:"matchType"`
+	Pattern             string   `dynamodbav:"pattern"`
+	MinAmount           *float64 `dynamodbav:"minAmount,omitempty"`
+	MaxAmount           *float64 `dynamodbav:"maxAmount,omitempty"`
+	Category            string   `dynamodbav:"category"`
+	PaymentMethod       string   `dynamodbav:"paymentMethod"`
+	Priority            int      `dynamodbav:"priority"`
+	CreatedAt           string   `dynamodbav:"createdAt"`
+	CreatedBy           string   `dynamodbav:"createdBy"`
+	validator.Validator `dynamodbav:"-"`
+
+	re *regexp.Regexp
+}
+
+// Change describes how applying a rule would modify an existing expense.
+type Change struct {
+	Expense          expense.Expense
+	NewCategory      string
+	NewPaymentMethod string
+}
+
+func New(matchType, pattern string, minAmount, maxAmount *float64, category, paymentMethod string, priority int) (rule Rule, isValid bool, errMessages validator.ErrMessages) {
+	rule = Rule{
+		ID:            uuid.New().String(),
+		MatchType:     matchType,
+		Pattern:       strings.TrimSpace(pattern),
+		MinAmount:     minAmount,
+		MaxAmount:     maxAmount,
+		Category:      strings.TrimSpace(category),
+		PaymentMethod: paymentMethod,
+		Priority:      priority,
+		CreatedAt:     helpers.GenerateCurrentTimestamp(),
+	}
+
+	rule.Check(validator.OneOf("matchType", rule.MatchType, MatchTypes))
+	rule.Check(validator.StringLengthBetween("pattern", rule.Pattern, PatternMinLength, PatternMaxLength))
+	if rule.MatchType == MatchRegexp {
+		rule.Check(validator.IsRegexp("pattern", rule.Pattern))
+	}
+	rule.Check(rule.Category != "" || rule.PaymentMethod != "", "category", "rule must set category or payment method")
+	if rule.Category != "" {
+		rule.Check(validator.StringLengthBetween(
+			"category",
+			rule.Category,
+			expensecategory.CategoryNameMinLength,
+			expensecategory.CategoryNameMaxLength,
+		))
+	}
+	if rule.PaymentMethod != "" {
+		rule.Check(validator.OneOf("paymentMethod", rule.PaymentMethod, expense.PaymentMethods))
+	}
+	if minAmount != nil && maxAmount != nil {
+		rule.Check(*minAmount <= *maxAmount, "maxAmount", "must be greater than or equal to minimum amount")
+	}
+
+	if isValid, errMessages := rule.Validate(); !isValid {
+		return Rule{}, false, errMessages
+	}
+
+	return rule, true, nil
+}
+
+func (r *Rule) Matches(name string, amount float64) bool {
+	if r.MinAmount != nil && amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && amount > *r.MaxAmount {
+		return false
+	}
+
+	switch r.MatchType {
+	case MatchContains:
+		return strings.Contains(strings.ToLower(name), strings.ToLower(r.Pattern))
+	case MatchRegexp:
+		if r.re == nil {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return false
+			}
+			r.re = re
+		}
+		return r.re.MatchString(name)
+	}
+
+	return false
+}
+
+// Sort orders rules the way they are evaluated - by priority, then by creation time.
+func Sort(rules []Rule) {
+	slices.SortStableFunc(rules, func(a, b Rule) int {
+		if a.Priority != b.Priority {
+			return a.Priority - b.Priority
+		}
+		return strings.Compare(a.CreatedAt, b.CreatedAt)
+	})
+}
+
+// Match returns the first rule (in priority order) matching given name and amount.
+func Match(rules []Rule, name string, amount float64) (Rule, bool) {
+	for i := range rules {
+		if rules[i].Matches(name, amount) {
+			return rules[i], true
+		}
+	}
+	return Rule{}, false
+}
+
+// Apply fills category and payment method of given expense using the first
+// matching rule, but only for fields that were left empty.
+func Apply(rules []Rule, exp expense.Expense) expense.Expense {
+	rule, found := Match(rules, exp.Name, exp.Amount)
+	if !found {
+		return exp
+	}
+	if exp.Category == "" {
+		exp.Category = rule.Category
+	}
+	if exp.PaymentMethod == "" {
+		exp.PaymentMethod = rule.PaymentMethod
+	}
+	return exp
+}
+
+// Preview lists expenses that would be changed if given rule was applied to
+// them. Refunds are left out, as they follow the expense they refund.
+func Preview(rule Rule, expenses []expense.Expense) []Change {
+	changes := []Change{}
+
+	for _, exp := range expenses {
+		if exp.Refund != nil || !rule.Matches(exp.Name, exp.Amount) {
+			continue
+		}
+
+		change := Change{
+			Expense:          exp,
+			NewCategory:      exp.Category,
+			NewPaymentMethod: exp.PaymentMethod,
+		}
+		if rule.Category != "" {
+			change.NewCategory = rule.Category
+		}
+		if rule.PaymentMethod != "" {
+			change.NewPaymentMethod = rule.PaymentMethod
+		}
+
+		if change.NewCategory != exp.Category || change.NewPaymentMethod != exp.PaymentMethod {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
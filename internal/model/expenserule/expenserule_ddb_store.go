@@ -0,0 +1,137 @@
+package expenserule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const pkPrefix = "expenserule"
+
+type DDBStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func getKey(vaultID, id string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildPK(vaultID))
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(id)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
+func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
+	return &DDBStore{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+func (rs *DDBStore) Create(ctx context.Context, ruleFC Rule, userID, vaultID string) (Rule, error) {
+	ruleFC.PK = buildPK(vaultID)
+	ruleFC.CreatedBy = userID
+
+	item, err := attributevalue.MarshalMap(ruleFC)
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to marshal expense rule: %w", err)
+	}
+
+	_, err = rs.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &rs.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to put expense rule into DynamoDB: %w", err)
+	}
+
+	return ruleFC, nil
+}
+
+func (rs *DDBStore) FindOne(ctx context.Context, id, vaultID string) (Rule, error) {
+	response, err := rs.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &rs.tableName,
+		Key:       getKey(vaultID, id),
+	})
+	if err != nil {
+		return Rule{}, fmt.Errorf("GetItem DynamoDB operation failed for expense rule ID='%s': %w", id, err)
+	}
+
+	if len(response.Item) == 0 {
+		return Rule{}, &NotFoundError{ID: id}
+	}
+
+	var rule Rule
+	err = attributevalue.UnmarshalMap(response.Item, &rule)
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to unmarshal expense rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+func (rs *DDBStore) Delete(ctx context.Context, id, vaultID string) error {
+	_, err := rs.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           &rs.tableName,
+		Key:                 getKey(vaultID, id),
+		ConditionExpression: aws.String("attribute_exists(SK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &NotFoundError{ID: id}
+		}
+		return fmt.Errorf("failed to delete expense rule with ID='%s' from table: %w", id, err)
+	}
+
+	return nil
+}
+
+// Returns all rules of given vault in evaluation order.
+func (rs *DDBStore) FindAll(ctx context.Context, vaultID string) ([]Rule, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildPK(vaultID)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for expense rule query %w", err)
+	}
+
+	rules := []Rule{}
+
+	queryPaginator := dynamodb.NewQueryPaginator(rs.client, &dynamodb.QueryInput{
+		TableName:                 &rs.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query for expense rules: %w", err)
+		}
+
+		resRules := []Rule{}
+		err = attributevalue.UnmarshalListOfMaps(response.Items, &resRules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query response for expense rules: %w", err)
+		}
+
+		rules = append(rules, resRules...)
+	}
+
+	Sort(rules)
+
+	return rules, nil
+}
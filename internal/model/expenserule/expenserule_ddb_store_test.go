@@ -0,0 +1,105 @@
+package expenserule_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/expenserule"
+)
+
+func TestDDBCreate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := expenserule.NewDDBStore(tableName, client)
+
+	ruleFC, isValid, errMessages := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", 0)
+	if !isValid {
+		t.Fatalf("didn't expect an error but got one: %v", errMessages)
+	}
+
+	rule, err := store.Create(ctx, ruleFC, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("failed putting rule into ddb, %v", err)
+	}
+
+	found, err := store.FindOne(ctx, rule.ID, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if found.Pattern != ruleFC.Pattern || found.Category != ruleFC.Category || found.CreatedBy != "userID" {
+		t.Errorf("got %#v, want %#v", found, rule)
+	}
+}
+
+func TestDDBFindAll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := expenserule.NewDDBStore(tableName, client)
+
+	for _, priority := range []int{3, 1, 2} {
+		ruleFC, isValid, errMessages := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", priority)
+		if !isValid {
+			t.Fatalf("didn't expect an error but got one: %v", errMessages)
+		}
+		if _, err := store.Create(ctx, ruleFC, "userID", "activeVaultID"); err != nil {
+			t.Fatalf("failed putting rule into ddb, %v", err)
+		}
+	}
+
+	rules, err := store.FindAll(ctx, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+	for i, rule := range rules {
+		if rule.Priority != i+1 {
+			t.Errorf("expected rules sorted by priority, got %d at index %d", rule.Priority, i)
+		}
+	}
+}
+
+func TestDDBDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := expenserule.NewDDBStore(tableName, client)
+
+	ruleFC, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", 0)
+	rule, err := store.Create(ctx, ruleFC, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("failed putting rule into ddb, %v", err)
+	}
+
+	err = store.Delete(ctx, rule.ID, "activeVaultID")
+	if err != nil {
+		t.Fatalf("failed deleting rule: %v", err)
+	}
+
+	err = store.Delete(ctx, rule.ID, "activeVaultID")
+	var notFoundErr *expenserule.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("got %#v, want %#v", err, &expenserule.NotFoundError{ID: rule.ID})
+	}
+}
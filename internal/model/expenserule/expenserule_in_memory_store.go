@@ -0,0 +1,45 @@
+package expenserule
+
+import (
+	"context"
+	"slices"
+)
+
+type InMemoryStore struct {
+	rules []Rule
+}
+
+func (s *InMemoryStore) Create(ctx context.Context, ruleFC Rule, userID, vaultID string) (Rule, error) {
+	ruleFC.CreatedBy = userID
+	s.rules = append(s.rules, ruleFC)
+	return ruleFC, nil
+}
+
+func (s *InMemoryStore) FindOne(ctx context.Context, id, vaultID string) (Rule, error) {
+	for _, rule := range s.rules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return Rule{}, &NotFoundError{ID: id}
+}
+
+func (s *InMemoryStore) Delete(ctx context.Context, id, vaultID string) error {
+	length := len(s.rules)
+
+	s.rules = slices.DeleteFunc(s.rules, func(rule Rule) bool {
+		return rule.ID == id
+	})
+
+	if len(s.rules) == length {
+		return &NotFoundError{ID: id}
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore) FindAll(ctx context.Context, vaultID string) ([]Rule, error) {
+	rules := slices.Clone(s.rules)
+	Sort(rules)
+	return rules, nil
+}
@@ -0,0 +1,84 @@
+package expenserule_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expenserule"
+)
+
+func TestInMemoryCreate(t *testing.T) {
+	ctx := context.Background()
+	store := &expenserule.InMemoryStore{}
+
+	rule := createDefaultInMemoryRuleHelper(t, ctx, store, 0)
+
+	_, err := store.FindOne(ctx, rule.ID, "activeVaultID")
+	if err != nil {
+		t.Errorf("didn't expect an error but got one: %v", err)
+	}
+}
+
+func TestInMemoryDelete(t *testing.T) {
+	t.Run("deletes existing rule", func(t *testing.T) {
+		ctx := context.Background()
+		store := &expenserule.InMemoryStore{}
+
+		rule := createDefaultInMemoryRuleHelper(t, ctx, store, 0)
+
+		err := store.Delete(ctx, rule.ID, "activeVaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error while deleting rule but got one: %v", err)
+		}
+
+		rules, _ := store.FindAll(ctx, "activeVaultID")
+		if len(rules) != 0 {
+			t.Errorf("expected 0 rules after deleting, got %d", len(rules))
+		}
+	})
+
+	t.Run("returns proper error when rule for deletion does not exist", func(t *testing.T) {
+		ctx := context.Background()
+		store := &expenserule.InMemoryStore{}
+
+		err := store.Delete(ctx, "invalidID", "activeVaultID")
+
+		var notFoundErr *expenserule.NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Errorf("got %#v, want %#v", err, &expenserule.NotFoundError{ID: "invalidID"})
+		}
+	})
+}
+
+func TestInMemoryFindAll(t *testing.T) {
+	ctx := context.Background()
+	store := &expenserule.InMemoryStore{}
+
+	createDefaultInMemoryRuleHelper(t, ctx, store, 5)
+	createDefaultInMemoryRuleHelper(t, ctx, store, 1)
+
+	rules, err := store.FindAll(ctx, "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if rules[0].Priority != 1 {
+		t.Errorf("expected rules sorted by priority, got %#v", rules)
+	}
+}
+
+func createDefaultInMemoryRuleHelper(t testing.TB, ctx context.Context, store *expenserule.InMemoryStore, priority int) expenserule.Rule {
+	t.Helper()
+	ruleFC, isValid, errMessages := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", priority)
+	if !isValid {
+		t.Fatalf("didn't expect an error while validating rule but got one: %v", errMessages)
+	}
+	rule, err := store.Create(ctx, ruleFC, "userID", "activeVaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error while creating rule but got one: %v", err)
+	}
+	return rule
+}
@@ -0,0 +1,142 @@
+package expenserule_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expenserule"
+)
+
+func amountPtr(v float64) *float64 { return &v }
+
+func TestNew(t *testing.T) {
+	t.Run("creates valid rule", func(t *testing.T) {
+		_, isValid, errMessages := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", 0)
+		if !isValid {
+			t.Errorf("didn't expect an error but got one: %v", errMessages)
+		}
+	})
+
+	t.Run("returns an error when regular expression is invalid", func(t *testing.T) {
+		_, isValid, _ := expenserule.New(expenserule.MatchRegexp, "(biedronka", nil, nil, "Groceries", "", 0)
+		if isValid {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("returns an error when rule neither sets category nor payment method", func(t *testing.T) {
+		_, isValid, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "", "", 0)
+		if isValid {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("returns an error when payment method is invalid", func(t *testing.T) {
+		_, isValid, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "", "beans", 0)
+		if isValid {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("returns an error when amount range is inverted", func(t *testing.T) {
+		_, isValid, _ := expenserule.New(expenserule.MatchContains, "Biedronka", amountPtr(50), amountPtr(10), "Groceries", "", 0)
+		if isValid {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func TestMatches(t *testing.T) {
+	t.Run("contains match is case insensitive", func(t *testing.T) {
+		rule, _, _ := expenserule.New(expenserule.MatchContains, "biedronka", nil, nil, "Groceries", "", 0)
+		if !rule.Matches("Zakupy BIEDRONKA", 10) {
+			t.Error("expected rule to match")
+		}
+	})
+
+	t.Run("regexp match", func(t *testing.T) {
+		rule, _, _ := expenserule.New(expenserule.MatchRegexp, "^(Orlen|BP) ", nil, nil, "Fuel", "", 0)
+		if !rule.Matches("Orlen Warszawa", 200) {
+			t.Error("expected rule to match")
+		}
+		if rule.Matches("Shell Orlen", 200) {
+			t.Error("didn't expect rule to match")
+		}
+	})
+
+	t.Run("respects amount range", func(t *testing.T) {
+		rule, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", amountPtr(10), amountPtr(100), "Groceries", "", 0)
+		if rule.Matches("Biedronka", 9.99) {
+			t.Error("didn't expect rule to match amount below range")
+		}
+		if !rule.Matches("Biedronka", 100) {
+			t.Error("expected rule to match amount within range")
+		}
+		if rule.Matches("Biedronka", 100.01) {
+			t.Error("didn't expect rule to match amount above range")
+		}
+	})
+}
+
+func TestMatch(t *testing.T) {
+	t.Run("returns first matching rule in priority order", func(t *testing.T) {
+		low, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Household", "", 10)
+		high, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", 1)
+		rules := []expenserule.Rule{low, high}
+		expenserule.Sort(rules)
+
+		rule, found := expenserule.Match(rules, "Biedronka", 20)
+		if !found {
+			t.Fatal("expected a rule to match")
+		}
+		if rule.Category != "Groceries" {
+			t.Errorf("got category %q, want %q", rule.Category, "Groceries")
+		}
+	})
+
+	t.Run("returns false if no rule matches", func(t *testing.T) {
+		rule, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", 0)
+		_, found := expenserule.Match([]expenserule.Rule{rule}, "Lidl", 20)
+		if found {
+			t.Error("didn't expect a rule to match")
+		}
+	})
+}
+
+func TestApply(t *testing.T) {
+	rule, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", expense.PaymentMethods[2], 0)
+	rules := []expenserule.Rule{rule}
+
+	t.Run("fills empty fields", func(t *testing.T) {
+		got := expenserule.Apply(rules, expense.Expense{Name: "Biedronka", Amount: 20})
+		if got.Category != "Groceries" || got.PaymentMethod != expense.PaymentMethods[2] {
+			t.Errorf("expected category and payment method to be set, got %#v", got)
+		}
+	})
+
+	t.Run("does not override fields set by user", func(t *testing.T) {
+		got := expenserule.Apply(rules, expense.Expense{Name: "Biedronka", Amount: 20, Category: "Party", PaymentMethod: expense.PaymentMethods[0]})
+		if got.Category != "Party" || got.PaymentMethod != expense.PaymentMethods[0] {
+			t.Errorf("expected category and payment method to be kept, got %#v", got)
+		}
+	})
+}
+
+func TestPreview(t *testing.T) {
+	rule, _, _ := expenserule.New(expenserule.MatchContains, "Biedronka", nil, nil, "Groceries", "", 0)
+	expenses := []expense.Expense{
+		{SK: "1", Name: "Biedronka", Category: "Other", Amount: 10},
+		{SK: "2", Name: "Biedronka", Category: "Groceries", Amount: 10},
+		{SK: "3", Name: "Lidl", Category: "Other", Amount: 10},
+		{SK: "4", Name: "Biedronka", Category: "Other", Amount: -5, Refund: &expense.Refund{SK: "1"}},
+	}
+
+	changes := expenserule.Preview(rule, expenses)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %d", len(changes))
+	}
+	if changes[0].Expense.SK != "1" || changes[0].NewCategory != "Groceries" {
+		t.Errorf("got unexpected change %#v", changes[0])
+	}
+}
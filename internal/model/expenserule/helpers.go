@@ -0,0 +1,5 @@
+package expenserule
+
+func buildPK(vaultID string) string {
+	return pkPrefix + "::" + vaultID
+}
@@ -0,0 +1,14 @@
+package idempotency
+
+import "fmt"
+
+type AlreadyClaimedError struct {
+	Key      string
+	Existing Record
+	Err      error
+}
+
+func (e *AlreadyClaimedError) Unwrap() error { return e.Err }
+func (e *AlreadyClaimedError) Error() string {
+	return fmt.Sprintf("idempotency key '%s' was already used", e.Key)
+}
@@ -0,0 +1,5 @@
+package idempotency
+
+func buildPK(userID string) string {
+	return pkPrefix + "::" + userID
+}
@@ -0,0 +1,82 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// HeaderName is the request header carrying a client generated key.
+	HeaderName = "Idempotency-Key"
+
+	KeyMaxLength = 255
+
+	// Larger responses are not stored, as they wouldn't fit in a single item.
+	BodyMaxSize = 256 << 10
+
+	// Responses are kept long enough to cover client and Lambda retries.
+	TTL = 24 * time.Hour
+
+	// In progress records expire early, so that a request that crashed
+	// before completing does not lock its key for the whole TTL.
+	claimTTL = 5 * time.Minute
+)
+
+// ReplayedHeaders are response headers stored along with the response body.
+var ReplayedHeaders = []string{"Content-Type", "ETag", "HX-Refresh", "HX-Redirect", "HX-Trigger"}
+
+// Record is a response to a mutating request made with given key. Records
+// with zero StatusCode belong to requests that are still being processed.
+type Record struct {
+	PK         string            `dynamodbav:"PK"`
+	Key        string            `dynamodbav:"SK"`
+	Method     string            `dynamodbav:"method"`
+	Path       string            `dynamodbav:"path"`
+	BodyHash   string            `dynamodbav:"bodyHash"`
+	StatusCode int               `dynamodbav:"statusCode"`
+	Header     map[string]string `dynamodbav:"header"`
+	Body       []byte            `dynamodbav:"body"`
+	ExpiresAt  int64             `dynamodbav:"expiresAt"`
+}
+
+// New creates an in progress record of a request with given body made with
+// given key.
+func New(key, method, path string, body []byte) Record {
+	hash := sha256.Sum256(body)
+	return Record{
+		Key:       key,
+		Method:    method,
+		Path:      path,
+		BodyHash:  hex.EncodeToString(hash[:]),
+		ExpiresAt: time.Now().Add(claimTTL).Unix(),
+	}
+}
+
+func (r Record) InProgress() bool {
+	return r.StatusCode == 0
+}
+
+// Matches reports whether the record was made for the same endpoint.
+func (r Record) Matches(method, path string) bool {
+	return r.Method == method && r.Path == path
+}
+
+// SameBody reports whether the record was made for a request with the same
+// body as other.
+func (r Record) SameBody(other Record) bool {
+	return r.BodyHash == other.BodyHash
+}
+
+// Complete fills the record with a response that will be replayed.
+func (r Record) Complete(statusCode int, header map[string]string, body []byte) Record {
+	r.StatusCode = statusCode
+	r.Header = header
+	r.Body = body
+	r.ExpiresAt = time.Now().Add(TTL).Unix()
+	return r
+}
+
+func (r Record) expired(now time.Time) bool {
+	return r.ExpiresAt <= now.Unix()
+}
@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const pkPrefix = "idempotency"
+
+type DDBStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func getKey(userID, key string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildPK(userID))
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(key)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
+func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
+	return &DDBStore{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+// Claim puts an in progress record, unless a record with the same key exists.
+// Expired records that were not removed by TTL yet are overwritten.
+func (s *DDBStore) Claim(ctx context.Context, r Record, userID string) error {
+	r.PK = buildPK(userID)
+
+	item, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &s.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SK) OR expiresAt <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			existing := Record{}
+			if err := attributevalue.UnmarshalMap(condErr.Item, &existing); err != nil {
+				return fmt.Errorf("failed to unmarshal existing idempotency record: %w", err)
+			}
+			return &AlreadyClaimedError{Key: r.Key, Existing: existing}
+		}
+		return fmt.Errorf("failed to put idempotency record into DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// Complete overwrites a claimed record with the response to replay.
+func (s *DDBStore) Complete(ctx context.Context, r Record, userID string) error {
+	r.PK = buildPK(userID)
+
+	item, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &s.tableName,
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put idempotency record into DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes a claimed record, so that the request can be retried.
+func (s *DDBStore) Release(ctx context.Context, key, userID string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &s.tableName,
+		Key:       getKey(userID, key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record with key='%s': %w", key, err)
+	}
+
+	return nil
+}
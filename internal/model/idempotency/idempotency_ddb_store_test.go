@@ -0,0 +1,53 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/idempotency"
+)
+
+func TestDDBStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := idempotency.NewDDBStore(tableName, client)
+
+	record := idempotency.New("key", http.MethodPost, "/expense/create", []byte("name=Lidl"))
+	if err := store.Claim(ctx, record, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	var claimedErr *idempotency.AlreadyClaimedError
+	if err := store.Claim(ctx, record, "userID"); !errors.As(err, &claimedErr) || !claimedErr.Existing.InProgress() {
+		t.Fatalf("expected in progress %T, got %#v", claimedErr, err)
+	}
+
+	completed := record.Complete(http.StatusOK, map[string]string{"HX-Refresh": "true"}, []byte("body"))
+	if err := store.Complete(ctx, completed, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	err = store.Claim(ctx, record, "userID")
+	if !errors.As(err, &claimedErr) {
+		t.Fatalf("expected %T, got %#v", claimedErr, err)
+	}
+	if got := claimedErr.Existing; got.StatusCode != http.StatusOK || string(got.Body) != "body" || got.Header["HX-Refresh"] != "true" || !got.Matches(http.MethodPost, "/expense/create") {
+		t.Errorf("got %#v", got)
+	}
+
+	if err := store.Release(ctx, "key", "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if err := store.Claim(ctx, record, "userID"); err != nil {
+		t.Errorf("expected released key to be claimable again, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+type InMemoryStore struct {
+	records map[string]Record
+}
+
+func (s *InMemoryStore) Claim(ctx context.Context, r Record, userID string) error {
+	if s.records == nil {
+		s.records = map[string]Record{}
+	}
+	r.PK = buildPK(userID)
+
+	if existing, ok := s.records[r.PK+r.Key]; ok && !existing.expired(time.Now()) {
+		return &AlreadyClaimedError{Key: r.Key, Existing: existing}
+	}
+	s.records[r.PK+r.Key] = r
+	return nil
+}
+
+func (s *InMemoryStore) Complete(ctx context.Context, r Record, userID string) error {
+	r.PK = buildPK(userID)
+	s.records[r.PK+r.Key] = r
+	return nil
+}
+
+func (s *InMemoryStore) Release(ctx context.Context, key, userID string) error {
+	delete(s.records, buildPK(userID)+key)
+	return nil
+}
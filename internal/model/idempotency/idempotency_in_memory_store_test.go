@@ -0,0 +1,47 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/idempotency"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := &idempotency.InMemoryStore{}
+
+	record := idempotency.New("key", http.MethodPost, "/expense/create", []byte("name=Lidl"))
+	if err := store.Claim(ctx, record, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	var claimedErr *idempotency.AlreadyClaimedError
+	if err := store.Claim(ctx, record, "userID"); !errors.As(err, &claimedErr) || !claimedErr.Existing.InProgress() {
+		t.Fatalf("expected in progress %T, got %#v", claimedErr, err)
+	}
+
+	if err := store.Claim(ctx, record, "otherUserID"); err != nil {
+		t.Errorf("expected keys to be scoped to a user, got %v", err)
+	}
+
+	completed := record.Complete(http.StatusOK, map[string]string{"HX-Refresh": "true"}, []byte("body"))
+	if err := store.Complete(ctx, completed, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if err := store.Claim(ctx, record, "userID"); !errors.As(err, &claimedErr) || string(claimedErr.Existing.Body) != "body" {
+		t.Fatalf("expected completed record to be returned, got %#v", err)
+	}
+
+	if err := store.Release(ctx, "key", "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if err := store.Claim(ctx, record, "userID"); err != nil {
+		t.Errorf("expected released key to be claimable again, got %v", err)
+	}
+	if record.SameBody(idempotency.New("key", http.MethodPost, "/expense/create", []byte("name=Orlen"))) {
+		t.Error("expected records of requests with different bodies to differ")
+	}
+}
@@ -0,0 +1,13 @@
+package notification
+
+import "fmt"
+
+type NotFoundError struct {
+	ID  string
+	Err error
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("notification with ID='%s' not found", e.ID)
+}
@@ -0,0 +1,5 @@
+package notification
+
+func buildPK(userID string) string {
+	return pkPrefix + "::" + userID
+}
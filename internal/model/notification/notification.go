@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/kkstas/tener/internal/helpers"
+)
+
+// Notification is a message shown to a user in the app.
+type Notification struct {
+	PK        string `dynamodbav:"PK"        json:"-"`
+	ID        string `dynamodbav:"SK"        json:"id"`
+	VaultID   string `dynamodbav:"vaultID"   json:"vaultID"`
+	Title     string `dynamodbav:"title"     json:"title"`
+	Body      string `dynamodbav:"body"      json:"body"`
+	Read      bool   `dynamodbav:"read"      json:"read"`
+	CreatedAt string `dynamodbav:"createdAt" json:"createdAt"`
+}
+
+// New creates a notification with an ID that sorts by creation time.
+func New(vaultID, title, body string) Notification {
+	createdAt := helpers.GenerateCurrentTimestamp()
+	return Notification{
+		ID:        createdAt + "::" + uuid.New().String(),
+		VaultID:   vaultID,
+		Title:     title,
+		Body:      body,
+		CreatedAt: createdAt,
+	}
+}
+
+// CountUnread returns number of notifications that were not read yet.
+func CountUnread(notifications []Notification) int {
+	count := 0
+	for _, n := range notifications {
+		if !n.Read {
+			count++
+		}
+	}
+	return count
+}
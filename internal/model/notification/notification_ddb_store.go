@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const pkPrefix = "notification"
+
+type DDBStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func getKey(userID, id string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildPK(userID))
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(id)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
+func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
+	return &DDBStore{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+func (s *DDBStore) Create(ctx context.Context, n Notification, userID string) error {
+	n.PK = buildPK(userID)
+
+	item, err := attributevalue.MarshalMap(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &s.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put notification into DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// FindLatest returns up to limit most recent notifications of given user.
+func (s *DDBStore) FindLatest(ctx context.Context, userID string, limit int) ([]Notification, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildPK(userID)))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for notification query %w", err)
+	}
+
+	response, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 &s.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for notifications: %w", err)
+	}
+
+	notifications := []Notification{}
+	err = attributevalue.UnmarshalListOfMaps(response.Items, &notifications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query response for notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (s *DDBStore) MarkRead(ctx context.Context, id, userID string) error {
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("read"), expression.Value(true))).
+		WithCondition(expression.AttributeExists(expression.Name("SK"))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for marking notification as read: %w", err)
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &s.tableName,
+		Key:                       getKey(userID, id),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &NotFoundError{ID: id}
+		}
+		return fmt.Errorf("failed to mark notification with ID='%s' as read: %w", id, err)
+	}
+
+	return nil
+}
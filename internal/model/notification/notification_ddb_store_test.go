@@ -0,0 +1,49 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/notification"
+)
+
+func TestDDBNotificationStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := notification.NewDDBStore(tableName, client)
+
+	first := notification.New("vaultID", "first", "body")
+	second := notification.New("vaultID", "second", "body")
+	for _, n := range []notification.Notification{first, second} {
+		if err := store.Create(ctx, n, "userID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	if err := store.MarkRead(ctx, first.ID, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	notifications, err := store.FindLatest(ctx, "userID", 10)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if len(notifications) != 2 || notifications[0].ID != second.ID || !notifications[1].Read {
+		t.Errorf("got %#v", notifications)
+	}
+
+	err = store.MarkRead(ctx, "nonexistent", "userID")
+	var notFoundErr *notification.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("expected %T, got %#v", notFoundErr, err)
+	}
+}
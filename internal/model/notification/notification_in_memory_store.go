@@ -0,0 +1,38 @@
+package notification
+
+import (
+	"context"
+	"sort"
+)
+
+type InMemoryStore struct {
+	notifications map[string][]Notification
+}
+
+func (s *InMemoryStore) Create(ctx context.Context, n Notification, userID string) error {
+	if s.notifications == nil {
+		s.notifications = map[string][]Notification{}
+	}
+	n.PK = buildPK(userID)
+	s.notifications[userID] = append(s.notifications[userID], n)
+	return nil
+}
+
+func (s *InMemoryStore) FindLatest(ctx context.Context, userID string, limit int) ([]Notification, error) {
+	notifications := append([]Notification{}, s.notifications[userID]...)
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID > notifications[j].ID })
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+func (s *InMemoryStore) MarkRead(ctx context.Context, id, userID string) error {
+	for i, n := range s.notifications[userID] {
+		if n.ID == id {
+			s.notifications[userID][i].Read = true
+			return nil
+		}
+	}
+	return &NotFoundError{ID: id}
+}
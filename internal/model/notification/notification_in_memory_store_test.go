@@ -0,0 +1,41 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/notification"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := &notification.InMemoryStore{}
+
+	first := notification.New("vaultID", "first", "body")
+	second := notification.New("vaultID", "second", "body")
+	_ = store.Create(ctx, first, "userID")
+	_ = store.Create(ctx, second, "userID")
+
+	if err := store.MarkRead(ctx, first.ID, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	notifications, _ := store.FindLatest(ctx, "userID", 10)
+	if len(notifications) != 2 || notifications[0].ID != second.ID {
+		t.Fatalf("expected newest notification first, got %#v", notifications)
+	}
+	if got := notification.CountUnread(notifications); got != 1 {
+		t.Errorf("got %d unread, want 1", got)
+	}
+
+	if latest, _ := store.FindLatest(ctx, "userID", 1); len(latest) != 1 {
+		t.Errorf("expected limit to be applied, got %#v", latest)
+	}
+
+	err := store.MarkRead(ctx, first.ID, "otherUserID")
+	var notFoundErr *notification.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Errorf("expected %T, got %#v", notFoundErr, err)
+	}
+}
@@ -0,0 +1,30 @@
+package savingsgoal
+
+import "time"
+
+const monthLayout = "2006-01"
+
+func buildPK(vaultID string) string {
+	return pkPrefix + "::" + vaultID
+}
+
+func buildGoalSK(id string) string {
+	return goalSKPrefix + "::" + id
+}
+
+func buildContributionSK(goalID, date, createdAt string) string {
+	return buildContributionsSKPrefix(goalID) + date + "::" + createdAt
+}
+
+func buildContributionsSKPrefix(goalID string) string {
+	return contributionSKPrefix + "::" + goalID + "::"
+}
+
+// Returns YYYY-MM month shifted by given number of months.
+func addMonths(month string, months int) string {
+	t, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return month
+	}
+	return t.AddDate(0, months, 0).Format(monthLayout)
+}
@@ -0,0 +1,87 @@
+package savingsgoal
+
+import (
+	"math"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+// Progress summarizes how much was saved for a goal and when it is projected
+// to be reached at the average monthly contribution so far.
+type Progress struct {
+	GoalID         string  `json:"goalID"`
+	Name           string  `json:"name"`
+	TargetAmount   float64 `json:"targetAmount"`
+	TargetDate     string  `json:"targetDate"`
+	Category       string  `json:"category,omitempty"`
+	Saved          float64 `json:"saved"`
+	Remaining      float64 `json:"remaining"`
+	Percent        float64 `json:"percent"`
+	MonthlyAverage float64 `json:"monthlyAverage"`
+	// RequiredMonthly is the monthly contribution needed to reach the target
+	// amount by the target date, starting from the current month.
+	RequiredMonthly float64 `json:"requiredMonthly"`
+	// ProjectedMonth is the YYYY-MM month the goal is expected to be reached
+	// in, empty if nothing was saved yet.
+	ProjectedMonth string `json:"projectedMonth"`
+	OnTrack        bool   `json:"onTrack"`
+	Reached        bool   `json:"reached"`
+}
+
+// Summarize computes progress of a goal as of given YYYY-MM-DD date from its
+// contributions and monthly sums of its linked category. Only sums since the
+// month the goal was created in count towards it.
+func Summarize(goal Goal, contributions []Contribution, categorySums []expense.MonthlySum, today string) Progress {
+	startMonth := goal.CreatedAt[:7]
+	currentMonth := today[:7]
+
+	saved := 0.0
+	for _, c := range contributions {
+		if c.GoalID == goal.ID {
+			saved += c.Amount
+			if c.Date[:7] < startMonth {
+				startMonth = c.Date[:7]
+			}
+		}
+	}
+	if goal.Category != "" {
+		for _, s := range categorySums {
+			if s.Category == goal.Category && s.SK[:7] >= goal.CreatedAt[:7] && s.SK[:7] <= currentMonth {
+				saved += s.Sum
+			}
+		}
+	}
+
+	p := Progress{
+		GoalID:       goal.ID,
+		Name:         goal.Name,
+		TargetAmount: goal.TargetAmount,
+		TargetDate:   goal.TargetDate,
+		Category:     goal.Category,
+		Saved:        round(saved),
+		Remaining:    round(math.Max(0, goal.TargetAmount-saved)),
+		Percent:      round(math.Min(100, saved/goal.TargetAmount*100)),
+		Reached:      saved >= goal.TargetAmount,
+	}
+
+	elapsed, _ := helpers.MonthsBetween(startMonth, currentMonth)
+	p.MonthlyAverage = round(saved / float64(max(elapsed+1, 1)))
+
+	monthsLeft, _ := helpers.MonthsBetween(currentMonth, goal.TargetDate[:7])
+	p.RequiredMonthly = round(p.Remaining / float64(max(monthsLeft+1, 1)))
+
+	switch {
+	case p.Reached:
+		p.ProjectedMonth = currentMonth
+	case p.MonthlyAverage > 0:
+		p.ProjectedMonth = addMonths(currentMonth, int(math.Ceil(p.Remaining/p.MonthlyAverage)))
+	}
+	p.OnTrack = p.ProjectedMonth != "" && p.ProjectedMonth <= goal.TargetDate[:7]
+
+	return p
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
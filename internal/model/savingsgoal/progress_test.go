@@ -0,0 +1,88 @@
+package savingsgoal_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+)
+
+func TestSummarize(t *testing.T) {
+	newGoal := func(t *testing.T, target float64, targetDate, category string) savingsgoal.Goal {
+		t.Helper()
+		goal, isValid, errMessages := savingsgoal.New("Vacation", target, targetDate, category)
+		if !isValid {
+			t.Fatalf("didn't expect error: %v", errMessages)
+		}
+		goal.CreatedAt = "2026-08-10T10:00:00Z"
+		return goal
+	}
+
+	newContribution := func(t *testing.T, goalID string, amount float64, date string) savingsgoal.Contribution {
+		t.Helper()
+		c, isValid, errMessages := savingsgoal.NewContribution(goalID, amount, date, "")
+		if !isValid {
+			t.Fatalf("didn't expect error: %v", errMessages)
+		}
+		return c
+	}
+
+	t.Run("projects completion from average monthly contribution", func(t *testing.T) {
+		goal := newGoal(t, 1000, "2027-01-31", "")
+		contributions := []savingsgoal.Contribution{
+			newContribution(t, goal.ID, 100, "2026-08-15"),
+			newContribution(t, goal.ID, 200, "2026-09-15"),
+			newContribution(t, goal.ID, 150, "2026-10-01"),
+		}
+
+		got := savingsgoal.Summarize(goal, contributions, nil, "2026-10-19")
+
+		if got.Saved != 450 || got.Remaining != 550 || got.Percent != 45 {
+			t.Errorf("unexpected saved amounts: %#v", got)
+		}
+		if got.MonthlyAverage != 150 {
+			t.Errorf("expected monthly average 150, got %v", got.MonthlyAverage)
+		}
+		if got.ProjectedMonth != "2027-02" || got.OnTrack {
+			t.Errorf("expected goal to be reached after target date in 2027-02, got %#v", got)
+		}
+		if got.RequiredMonthly != 137.5 {
+			t.Errorf("expected required monthly 137.5, got %v", got.RequiredMonthly)
+		}
+	})
+
+	t.Run("counts linked category sums since goal creation", func(t *testing.T) {
+		goal := newGoal(t, 600, "2026-12-31", "Savings")
+		sums := []expense.MonthlySum{
+			{SK: "2026-07::Savings", Category: "Savings", Sum: 1000},
+			{SK: "2026-08::Savings", Category: "Savings", Sum: 200},
+			{SK: "2026-09::Savings", Category: "Savings", Sum: 200},
+			{SK: "2026-09::Food", Category: "Food", Sum: 500},
+		}
+
+		got := savingsgoal.Summarize(goal, nil, sums, "2026-10-19")
+
+		if got.Saved != 400 {
+			t.Errorf("expected 400 saved, got %v", got.Saved)
+		}
+		if got.ProjectedMonth != "2026-12" || !got.OnTrack {
+			t.Errorf("expected goal to be on track for 2026-12, got %#v", got)
+		}
+	})
+
+	t.Run("marks goal reached", func(t *testing.T) {
+		goal := newGoal(t, 100, "2026-12-31", "")
+		got := savingsgoal.Summarize(goal, []savingsgoal.Contribution{newContribution(t, goal.ID, 120, "2026-09-01")}, nil, "2026-10-19")
+		if !got.Reached || !got.OnTrack || got.Percent != 100 || got.Remaining != 0 {
+			t.Errorf("expected reached goal, got %#v", got)
+		}
+	})
+
+	t.Run("leaves projection empty when nothing was saved", func(t *testing.T) {
+		goal := newGoal(t, 300, "2026-12-31", "")
+		got := savingsgoal.Summarize(goal, nil, nil, "2026-10-19")
+		if got.ProjectedMonth != "" || got.OnTrack || got.RequiredMonthly != 100 {
+			t.Errorf("got %#v", got)
+		}
+	})
+}
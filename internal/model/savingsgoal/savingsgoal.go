@@ -0,0 +1,103 @@
+package savingsgoal
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/pkg/validator"
+)
+
+const (
+	NameMinLength = 2
+	NameMaxLength = 50
+	NoteMaxLength = 100
+)
+
+// Goal is an amount the vault saves up for until TargetDate. Money is put
+// aside either by recording contributions, or by adding expenses to the
+// optional linked Category (e.g. transfers to a savings account).
+type Goal struct {
+	PK                  string  `dynamodbav:"PK"`
+	SK                  string  `dynamodbav:"SK"`
+	ID                  string  `dynamodbav:"id"`
+	Name                string  `dynamodbav:"name"`
+	TargetAmount        float64 `dynamodbav:"targetAmount"`
+	TargetDate          string  `dynamodbav:"targetDate"`
+	Category            string  `dynamodbav:"category,omitempty"`
+	CreatedAt           string  `dynamodbav:"createdAt"`
+	CreatedBy           string  `dynamodbav:"createdBy"`
+	validator.Validator `dynamodbav:"-"`
+}
+
+// Contribution is an amount put aside for a goal on given date.
+type Contribution struct {
+	PK                  string  `dynamodbav:"PK"`
+	SK                  string  `dynamodbav:"SK"`
+	GoalID              string  `dynamodbav:"goalID"`
+	Amount              float64 `dynamodbav:"amount"`
+	Date                string  `dynamodbav:"date"`
+	Note                string  `dynamodbav:"note"`
+	CreatedAt           string  `dynamodbav:"createdAt"`
+	CreatedBy           string  `dynamodbav:"createdBy"`
+	validator.Validator `dynamodbav:"-"`
+}
+
+func New(name string, targetAmount float64, targetDate, category string) (goal Goal, isValid bool, errMessages validator.ErrMessages) {
+	id := uuid.New().String()
+	goal = Goal{
+		SK:           buildGoalSK(id),
+		ID:           id,
+		Name:         strings.TrimSpace(name),
+		TargetAmount: targetAmount,
+		TargetDate:   targetDate,
+		Category:     strings.TrimSpace(category),
+		CreatedAt:    helpers.GenerateCurrentTimestamp(),
+	}
+
+	goal.Check(validator.StringLengthBetween("name", goal.Name, NameMinLength, NameMaxLength))
+	goal.Check(goal.TargetAmount > 0, "targetAmount", "must be greater than zero")
+	goal.Check(validator.IsAmountPrecision("targetAmount", goal.TargetAmount))
+	goal.Check(validator.IsTime("targetDate", "2006-01-02", goal.TargetDate))
+	if goal.Category != "" {
+		goal.Check(validator.StringLengthBetween(
+			"category",
+			goal.Category,
+			expensecategory.CategoryNameMinLength,
+			expensecategory.CategoryNameMaxLength,
+		))
+	}
+
+	if isValid, errMessages := goal.Validate(); !isValid {
+		return Goal{}, false, errMessages
+	}
+
+	return goal, true, nil
+}
+
+// NewContribution creates a contribution to goal with given ID. Withdrawals
+// are recorded as negative amounts.
+func NewContribution(goalID string, amount float64, date, note string) (contribution Contribution, isValid bool, errMessages validator.ErrMessages) {
+	createdAt := helpers.GenerateCurrentTimestamp()
+	contribution = Contribution{
+		SK:        buildContributionSK(goalID, date, createdAt),
+		GoalID:    goalID,
+		Amount:    amount,
+		Date:      date,
+		Note:      strings.TrimSpace(note),
+		CreatedAt: createdAt,
+	}
+
+	contribution.Check(validator.IsNonZero("amount", contribution.Amount))
+	contribution.Check(validator.IsAmountPrecision("amount", contribution.Amount))
+	contribution.Check(validator.IsTime("date", "2006-01-02", contribution.Date))
+	contribution.Check(validator.StringLengthBetween("note", contribution.Note, 0, NoteMaxLength))
+
+	if isValid, errMessages := contribution.Validate(); !isValid {
+		return Contribution{}, false, errMessages
+	}
+
+	return contribution, true, nil
+}
@@ -0,0 +1,241 @@
+package savingsgoal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	pkPrefix             = "savingsgoal"
+	goalSKPrefix         = "goal"
+	contributionSKPrefix = "contribution"
+
+	// Maximum number of items in a single DynamoDB transaction.
+	maxTransactItems = 100
+)
+
+type DDBStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func getKey(vaultID, SK string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildPK(vaultID))
+	if err != nil {
+		panic(err)
+	}
+	SKValue, err := attributevalue.Marshal(SK)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SKValue}
+}
+
+func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
+	return &DDBStore{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+func (s *DDBStore) Create(ctx context.Context, goalFC Goal, userID, vaultID string) (Goal, error) {
+	goalFC.PK = buildPK(vaultID)
+	goalFC.CreatedBy = userID
+
+	item, err := attributevalue.MarshalMap(goalFC)
+	if err != nil {
+		return Goal{}, fmt.Errorf("failed to marshal savings goal: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &s.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		return Goal{}, fmt.Errorf("failed to put savings goal into DynamoDB: %w", err)
+	}
+
+	return goalFC, nil
+}
+
+func (s *DDBStore) FindOne(ctx context.Context, id, vaultID string) (Goal, error) {
+	response, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &s.tableName,
+		Key:       getKey(vaultID, buildGoalSK(id)),
+	})
+	if err != nil {
+		return Goal{}, fmt.Errorf("GetItem DynamoDB operation failed for savings goal ID='%s': %w", id, err)
+	}
+
+	if len(response.Item) == 0 {
+		return Goal{}, &NotFoundError{ID: id}
+	}
+
+	var goal Goal
+	err = attributevalue.UnmarshalMap(response.Item, &goal)
+	if err != nil {
+		return Goal{}, fmt.Errorf("failed to unmarshal savings goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// Returns all goals of given vault, the ones with the nearest target date first.
+func (s *DDBStore) FindAll(ctx context.Context, vaultID string) ([]Goal, error) {
+	goals := []Goal{}
+	err := s.query(ctx, vaultID, goalSKPrefix+"::", &goals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for savings goals: %w", err)
+	}
+
+	sort.Slice(goals, func(i, j int) bool { return goals[i].TargetDate < goals[j].TargetDate })
+
+	return goals, nil
+}
+
+// Deletes goal together with all of its contributions.
+func (s *DDBStore) Delete(ctx context.Context, id, vaultID string) error {
+	contributions, err := s.FindContributions(ctx, id, vaultID)
+	if err != nil {
+		return err
+	}
+
+	items := []types.TransactWriteItem{}
+	for _, c := range contributions {
+		items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+			TableName: &s.tableName,
+			Key:       getKey(vaultID, c.SK),
+		}})
+	}
+	items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+		TableName:           &s.tableName,
+		Key:                 getKey(vaultID, buildGoalSK(id)),
+		ConditionExpression: aws.String("attribute_exists(SK)"),
+	}})
+
+	for start := 0; start < len(items); start += maxTransactItems {
+		end := min(start+maxTransactItems, len(items))
+		_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items[start:end]})
+		if err != nil {
+			var txErr *types.TransactionCanceledException
+			if errors.As(err, &txErr) && end == len(items) {
+				reasons := txErr.CancellationReasons
+				if last := reasons[len(reasons)-1]; last.Code != nil && *last.Code == "ConditionalCheckFailed" {
+					return &NotFoundError{ID: id}
+				}
+			}
+			return fmt.Errorf("failed to delete savings goal with ID='%s': %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// AddContribution saves contribution to an existing goal.
+func (s *DDBStore) AddContribution(ctx context.Context, contributionFC Contribution, userID, vaultID string) (Contribution, error) {
+	contributionFC.PK = buildPK(vaultID)
+	contributionFC.CreatedBy = userID
+
+	item, err := attributevalue.MarshalMap(contributionFC)
+	if err != nil {
+		return Contribution{}, fmt.Errorf("failed to marshal savings goal contribution: %w", err)
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{ConditionCheck: &types.ConditionCheck{
+				TableName:           &s.tableName,
+				Key:                 getKey(vaultID, buildGoalSK(contributionFC.GoalID)),
+				ConditionExpression: aws.String("attribute_exists(SK)"),
+			}},
+			{Put: &types.Put{
+				TableName:           &s.tableName,
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(SK)"),
+			}},
+		},
+	})
+	if err != nil {
+		var txErr *types.TransactionCanceledException
+		if errors.As(err, &txErr) {
+			if reasons := txErr.CancellationReasons; len(reasons) > 0 && reasons[0].Code != nil && *reasons[0].Code == "ConditionalCheckFailed" {
+				return Contribution{}, &NotFoundError{ID: contributionFC.GoalID}
+			}
+		}
+		return Contribution{}, fmt.Errorf("failed to put savings goal contribution into DynamoDB: %w", err)
+	}
+
+	return contributionFC, nil
+}
+
+// Deletes contribution with given SK, which must belong to the goal of goalID.
+func (s *DDBStore) DeleteContribution(ctx context.Context, goalID, SK, vaultID string) error {
+	if !strings.HasPrefix(SK, buildContributionsSKPrefix(goalID)) {
+		return &NotFoundError{ID: SK}
+	}
+
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           &s.tableName,
+		Key:                 getKey(vaultID, SK),
+		ConditionExpression: aws.String("attribute_exists(SK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return &NotFoundError{ID: SK}
+		}
+		return fmt.Errorf("failed to delete savings goal contribution with SK='%s': %w", SK, err)
+	}
+
+	return nil
+}
+
+// Returns contributions to given goal ordered by date.
+func (s *DDBStore) FindContributions(ctx context.Context, goalID, vaultID string) ([]Contribution, error) {
+	contributions := []Contribution{}
+	err := s.query(ctx, vaultID, buildContributionsSKPrefix(goalID), &contributions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for savings goal contributions: %w", err)
+	}
+	return contributions, nil
+}
+
+func (s *DDBStore) query(ctx context.Context, vaultID, skPrefix string, out any) error {
+	keyCond := expression.
+		Key("PK").Equal(expression.Value(buildPK(vaultID))).
+		And(expression.Key("SK").BeginsWith(skPrefix))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return fmt.Errorf("failed to build expression for savings goal query %w", err)
+	}
+
+	items := []map[string]types.AttributeValue{}
+
+	queryPaginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:                 &s.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		items = append(items, response.Items...)
+	}
+
+	return attributevalue.UnmarshalListOfMaps(items, out)
+}
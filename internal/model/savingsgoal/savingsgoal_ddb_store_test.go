@@ -0,0 +1,76 @@
+package savingsgoal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+)
+
+func TestDDBSavingsGoalStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := savingsgoal.NewDDBStore(tableName, client)
+
+	goalFC, _, _ := savingsgoal.New("Vacation", 1000, "2027-01-31", "")
+	goal, err := store.Create(ctx, goalFC, "userID", "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	for _, amount := range []float64{100, -20} {
+		contributionFC, _, _ := savingsgoal.NewContribution(goal.ID, amount, "2026-10-01", "")
+		if _, err = store.AddContribution(ctx, contributionFC, "userID", "vaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	var notFoundErr *savingsgoal.NotFoundError
+	orphanFC, _, _ := savingsgoal.NewContribution("missing", 100, "2026-10-01", "")
+	if _, err = store.AddContribution(ctx, orphanFC, "userID", "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError for missing goal, got %v", err)
+	}
+
+	contributions, err := store.FindContributions(ctx, goal.ID, "vaultID")
+	if err != nil || len(contributions) != 2 {
+		t.Fatalf("expected two contributions, got %#v, %v", contributions, err)
+	}
+
+	if err = store.DeleteContribution(ctx, goal.ID, goal.SK, "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError for SK of the goal, got %v", err)
+	}
+	if err = store.DeleteContribution(ctx, "other", contributions[0].SK, "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError for contribution of another goal, got %v", err)
+	}
+	if err = store.DeleteContribution(ctx, goal.ID, contributions[0].SK, "vaultID"); err != nil {
+		t.Errorf("didn't expect an error but got one: %v", err)
+	}
+
+	goals, err := store.FindAll(ctx, "vaultID")
+	if err != nil || len(goals) != 1 {
+		t.Fatalf("expected one goal, got %#v, %v", goals, err)
+	}
+
+	if err = store.Delete(ctx, goal.ID, "vaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if _, err = store.FindOne(ctx, goal.ID, "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError after delete, got %v", err)
+	}
+	contributions, _ = store.FindContributions(ctx, goal.ID, "vaultID")
+	if len(contributions) != 0 {
+		t.Errorf("expected contributions to be deleted with goal, got %#v", contributions)
+	}
+	if err = store.Delete(ctx, goal.ID, "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+}
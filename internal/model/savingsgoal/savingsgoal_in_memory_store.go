@@ -0,0 +1,74 @@
+package savingsgoal
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+type InMemoryStore struct {
+	goals         []Goal
+	contributions []Contribution
+}
+
+func (s *InMemoryStore) Create(ctx context.Context, goalFC Goal, userID, vaultID string) (Goal, error) {
+	goalFC.PK = buildPK(vaultID)
+	goalFC.CreatedBy = userID
+	s.goals = append(s.goals, goalFC)
+	return goalFC, nil
+}
+
+func (s *InMemoryStore) FindOne(ctx context.Context, id, vaultID string) (Goal, error) {
+	for _, goal := range s.goals {
+		if goal.ID == id {
+			return goal, nil
+		}
+	}
+	return Goal{}, &NotFoundError{ID: id}
+}
+
+func (s *InMemoryStore) FindAll(ctx context.Context, vaultID string) ([]Goal, error) {
+	goals := slices.Clone(s.goals)
+	sort.Slice(goals, func(i, j int) bool { return goals[i].TargetDate < goals[j].TargetDate })
+	return goals, nil
+}
+
+func (s *InMemoryStore) Delete(ctx context.Context, id, vaultID string) error {
+	length := len(s.goals)
+	s.goals = slices.DeleteFunc(s.goals, func(goal Goal) bool { return goal.ID == id })
+	if len(s.goals) == length {
+		return &NotFoundError{ID: id}
+	}
+	s.contributions = slices.DeleteFunc(s.contributions, func(c Contribution) bool { return c.GoalID == id })
+	return nil
+}
+
+func (s *InMemoryStore) AddContribution(ctx context.Context, contributionFC Contribution, userID, vaultID string) (Contribution, error) {
+	if _, err := s.FindOne(ctx, contributionFC.GoalID, vaultID); err != nil {
+		return Contribution{}, err
+	}
+	contributionFC.PK = buildPK(vaultID)
+	contributionFC.CreatedBy = userID
+	s.contributions = append(s.contributions, contributionFC)
+	return contributionFC, nil
+}
+
+func (s *InMemoryStore) DeleteContribution(ctx context.Context, goalID, SK, vaultID string) error {
+	length := len(s.contributions)
+	s.contributions = slices.DeleteFunc(s.contributions, func(c Contribution) bool { return c.GoalID == goalID && c.SK == SK })
+	if len(s.contributions) == length {
+		return &NotFoundError{ID: SK}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) FindContributions(ctx context.Context, goalID, vaultID string) ([]Contribution, error) {
+	contributions := []Contribution{}
+	for _, c := range s.contributions {
+		if c.GoalID == goalID {
+			contributions = append(contributions, c)
+		}
+	}
+	sort.Slice(contributions, func(i, j int) bool { return contributions[i].SK < contributions[j].SK })
+	return contributions, nil
+}
@@ -0,0 +1,52 @@
+package savingsgoal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := &savingsgoal.InMemoryStore{}
+
+	goalFC, _, _ := savingsgoal.New("Vacation", 1000, "2027-01-31", "")
+	goal, err := store.Create(ctx, goalFC, "userID", "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	contributionFC, _, _ := savingsgoal.NewContribution(goal.ID, 100, "2026-10-01", "")
+	contribution, err := store.AddContribution(ctx, contributionFC, "userID", "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	var notFoundErr *savingsgoal.NotFoundError
+	orphanFC, _, _ := savingsgoal.NewContribution("missing", 100, "2026-10-01", "")
+	if _, err = store.AddContribution(ctx, orphanFC, "userID", "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError for missing goal, got %v", err)
+	}
+
+	contributions, _ := store.FindContributions(ctx, goal.ID, "vaultID")
+	if len(contributions) != 1 || contributions[0].SK != contribution.SK {
+		t.Errorf("expected one contribution, got %#v", contributions)
+	}
+
+	if err = store.DeleteContribution(ctx, "other", contribution.SK, "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError for contribution of another goal, got %v", err)
+	}
+
+	if err = store.Delete(ctx, goal.ID, "vaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	contributions, _ = store.FindContributions(ctx, goal.ID, "vaultID")
+	if len(contributions) != 0 {
+		t.Errorf("expected contributions to be deleted with goal, got %#v", contributions)
+	}
+	if err = store.Delete(ctx, goal.ID, "vaultID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package savingsgoal_test
+
+import (
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("returns errors for invalid goal", func(t *testing.T) {
+		_, isValid, errMessages := savingsgoal.New("a", 0, "2026-13-01", "")
+		if isValid {
+			t.Fatal("expected goal to be invalid")
+		}
+		for _, field := range []string{"name", "targetAmount", "targetDate"} {
+			if len(errMessages[field]) == 0 {
+				t.Errorf("expected %s error, got %v", field, errMessages)
+			}
+		}
+	})
+
+	t.Run("creates goal", func(t *testing.T) {
+		goal, isValid, errMessages := savingsgoal.New(" Vacation ", 3000, "2027-06-30", " Savings ")
+		if !isValid {
+			t.Fatalf("didn't expect error: %v", errMessages)
+		}
+		if goal.Name != "Vacation" || goal.Category != "Savings" || goal.ID == "" || goal.SK == "" {
+			t.Errorf("got %#v", goal)
+		}
+	})
+}
+
+func TestNewContribution(t *testing.T) {
+	t.Run("returns error for zero amount", func(t *testing.T) {
+		_, isValid, errMessages := savingsgoal.NewContribution("goalID", 0, "2026-10-01", "")
+		if isValid || len(errMessages["amount"]) == 0 {
+			t.Errorf("expected amount error, got %v", errMessages)
+		}
+	})
+
+	t.Run("accepts withdrawal", func(t *testing.T) {
+		c, isValid, errMessages := savingsgoal.NewContribution("goalID", -50, "2026-10-01", " unexpected bill ")
+		if !isValid {
+			t.Fatalf("didn't expect error: %v", errMessages)
+		}
+		if c.Amount != -50 || c.Note != "unexpected bill" || c.GoalID != "goalID" {
+			t.Errorf("got %#v", c)
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package undo
+
+import "fmt"
+
+type NotFoundError struct {
+	ID  string
+	Err error
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("undo record with ID='%s' not found or expired", e.ID)
+}
@@ -0,0 +1,7 @@
+package undo
+
+const latestSK = "latest"
+
+func buildPK(userID string) string {
+	return pkPrefix + "::" + userID
+}
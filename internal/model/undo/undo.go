@@ -0,0 +1,100 @@
+package undo
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+)
+
+// Window is how long the last destructive action can be undone for.
+const Window = 30 * time.Second
+
+type Action string
+
+const (
+	ActionDeleteExpense  Action = "deleteExpense"
+	ActionEditExpense    Action = "editExpense"
+	ActionDeleteCategory Action = "deleteCategory"
+	ActionEditCategory   Action = "editCategory"
+)
+
+// Record captures the state from before the last destructive action of a
+// user. Each user has at most one record, replaced by their next action.
+type Record struct {
+	PK       string                    `dynamodbav:"PK"                 json:"-"`
+	SK       string                    `dynamodbav:"SK"                 json:"-"`
+	ID       string                    `dynamodbav:"id"                 json:"id"`
+	VaultID  string                    `dynamodbav:"vaultID"            json:"-"`
+	Action   Action                    `dynamodbav:"action"             json:"action"`
+	Expense  *expense.Expense          `dynamodbav:"expense,omitempty"  json:"-"`
+	Category *expensecategory.Category `dynamodbav:"category,omitempty" json:"-"`
+
+	// SK and version of the edited expense, which undoing the edit has to
+	// match.
+	EditedSK      string `dynamodbav:"editedSK,omitempty"      json:"-"`
+	EditedVersion int    `dynamodbav:"editedVersion,omitempty" json:"-"`
+
+	ExpiresAt int64 `dynamodbav:"expiresAt" json:"expiresAt"`
+}
+
+func newRecord(action Action, vaultID string) Record {
+	return Record{
+		ID:        uuid.New().String(),
+		VaultID:   vaultID,
+		Action:    action,
+		ExpiresAt: time.Now().Add(Window).Unix(),
+	}
+}
+
+// NewExpenseDeletion creates a record restoring a deleted expense.
+func NewExpenseDeletion(deleted expense.Expense, vaultID string) Record {
+	r := newRecord(ActionDeleteExpense, vaultID)
+	r.Expense = &deleted
+	return r
+}
+
+// NewExpenseEdit creates a record reverting an expense from edited back to
+// previous state.
+func NewExpenseEdit(previous, edited expense.Expense, vaultID string) Record {
+	r := newRecord(ActionEditExpense, vaultID)
+	r.Expense = &previous
+	r.EditedSK = edited.SK
+	r.EditedVersion = edited.Version
+	return r
+}
+
+// NewCategoryDeletion creates a record restoring a deleted expense category.
+func NewCategoryDeletion(deleted expensecategory.Category, vaultID string) Record {
+	r := newRecord(ActionDeleteCategory, vaultID)
+	r.Category = &deleted
+	return r
+}
+
+// NewCategoryEdit creates a record restoring previous expense category metadata.
+func NewCategoryEdit(previous expensecategory.Category, vaultID string) Record {
+	r := newRecord(ActionEditCategory, vaultID)
+	r.Category = &previous
+	return r
+}
+
+// Describe returns a short summary of the action to undo.
+func (r Record) Describe() string {
+	switch r.Action {
+	case ActionDeleteExpense:
+		return "Deleted expense " + r.Expense.Name
+	case ActionEditExpense:
+		return "Edited expense " + r.Expense.Name
+	case ActionDeleteCategory:
+		return "Deleted category " + r.Category.Name
+	case ActionEditCategory:
+		return "Edited category " + r.Category.Name
+	}
+	return ""
+}
+
+func (r Record) expired(now time.Time) bool {
+	return r.ExpiresAt <= now.Unix()
+}
@@ -0,0 +1,122 @@
+package undo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const pkPrefix = "undo"
+
+type DDBStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func getKey(userID string) map[string]types.AttributeValue {
+	PK, err := attributevalue.Marshal(buildPK(userID))
+	if err != nil {
+		panic(err)
+	}
+	SK, err := attributevalue.Marshal(latestSK)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]types.AttributeValue{"PK": PK, "SK": SK}
+}
+
+func NewDDBStore(tableName string, client *dynamodb.Client) *DDBStore {
+	return &DDBStore{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+// Put replaces the undo record of given user.
+func (s *DDBStore) Put(ctx context.Context, r Record, userID string) error {
+	r.PK, r.SK = buildPK(userID), latestSK
+
+	item, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal undo record: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &s.tableName,
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put undo record into DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// Restore puts back a record returned by Take, so that the action can be
+// undone again after undoing it failed. It is skipped if the user took another
+// action since, as only the last one can be undone.
+func (s *DDBStore) Restore(ctx context.Context, r Record, userID string) error {
+	r.PK, r.SK = buildPK(userID), latestSK
+
+	item, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal undo record: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &s.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return nil
+		}
+		return fmt.Errorf("failed to put undo record back into DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// Take removes and returns the undo record of given user, as long as it has
+// given ID and did not expire yet, so that an action is undone at most once.
+func (s *DDBStore) Take(ctx context.Context, id, userID string) (Record, error) {
+	cond := expression.Name("id").Equal(expression.Value(id)).
+		And(expression.Name("expiresAt").GreaterThan(expression.Value(time.Now().Unix())))
+
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to build expression for taking undo record: %w", err)
+	}
+
+	response, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 &s.tableName,
+		Key:                       getKey(userID),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		ReturnValues:              types.ReturnValueAllOld,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return Record{}, &NotFoundError{ID: id, Err: err}
+		}
+		return Record{}, fmt.Errorf("failed to delete undo record with ID='%s': %w", id, err)
+	}
+
+	r := Record{}
+	if err := attributevalue.UnmarshalMap(response.Attributes, &r); err != nil {
+		return Record{}, fmt.Errorf("failed to unmarshal undo record: %w", err)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,67 @@
+package undo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/undo"
+)
+
+func TestDDBStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+
+	store := undo.NewDDBStore(tableName, client)
+
+	edit := undo.NewExpenseEdit(expense.Expense{SK: "2024-01-01::a", Name: "lunch", Amount: 12.5, Version: 1}, expense.Expense{SK: "2024-01-02::a", Version: 2}, "vaultID")
+	if err := store.Put(ctx, edit, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	deletion := undo.NewCategoryDeletion(expensecategory.Category{Name: "Food", Color: "#123456"}, "vaultID")
+	if err := store.Put(ctx, deletion, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	var notFoundErr *undo.NotFoundError
+	if _, err := store.Take(ctx, edit.ID, "userID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected replaced record to be gone, got %#v", err)
+	}
+
+	record, err := store.Take(ctx, deletion.ID, "userID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if record.Action != undo.ActionDeleteCategory || record.Category.Color != "#123456" || record.VaultID != "vaultID" {
+		t.Errorf("got %#v", record)
+	}
+
+	if _, err := store.Take(ctx, deletion.ID, "userID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected record to be taken only once, got %#v", err)
+	}
+
+	if err := store.Restore(ctx, record, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if _, err := store.Take(ctx, deletion.ID, "userID"); err != nil {
+		t.Errorf("expected restored record to be taken again, got %v", err)
+	}
+
+	newer := undo.NewExpenseDeletion(expense.Expense{Name: "newer"}, "vaultID")
+	_ = store.Put(ctx, newer, "userID")
+	if err := store.Restore(ctx, record, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if _, err := store.Take(ctx, newer.ID, "userID"); err != nil {
+		t.Errorf("expected restore to keep record of newer action, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package undo
+
+import (
+	"context"
+	"time"
+)
+
+type InMemoryStore struct {
+	records map[string]Record
+}
+
+func (s *InMemoryStore) Put(ctx context.Context, r Record, userID string) error {
+	if s.records == nil {
+		s.records = map[string]Record{}
+	}
+	r.PK, r.SK = buildPK(userID), latestSK
+	s.records[userID] = r
+	return nil
+}
+
+func (s *InMemoryStore) Restore(ctx context.Context, r Record, userID string) error {
+	if _, ok := s.records[userID]; ok {
+		return nil
+	}
+	return s.Put(ctx, r, userID)
+}
+
+func (s *InMemoryStore) Take(ctx context.Context, id, userID string) (Record, error) {
+	r, ok := s.records[userID]
+	if !ok || r.ID != id || r.expired(time.Now()) {
+		return Record{}, &NotFoundError{ID: id}
+	}
+	delete(s.records, userID)
+	return r, nil
+}
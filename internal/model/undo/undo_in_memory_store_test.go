@@ -0,0 +1,56 @@
+package undo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/undo"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := &undo.InMemoryStore{}
+
+	first := undo.NewExpenseDeletion(expense.Expense{Name: "first"}, "vaultID")
+	second := undo.NewExpenseDeletion(expense.Expense{Name: "second"}, "vaultID")
+	_ = store.Put(ctx, first, "userID")
+	_ = store.Put(ctx, second, "userID")
+
+	var notFoundErr *undo.NotFoundError
+	if _, err := store.Take(ctx, first.ID, "userID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected replaced record to be gone, got %#v", err)
+	}
+	if _, err := store.Take(ctx, second.ID, "otherUserID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected records to be scoped to a user, got %#v", err)
+	}
+
+	record, err := store.Take(ctx, second.ID, "userID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if record.Expense.Name != "second" || record.Describe() != "Deleted expense second" {
+		t.Errorf("got %#v", record)
+	}
+
+	if _, err := store.Take(ctx, second.ID, "userID"); !errors.As(err, &notFoundErr) {
+		t.Errorf("expected record to be taken only once, got %#v", err)
+	}
+
+	if err := store.Restore(ctx, record, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if _, err := store.Take(ctx, second.ID, "userID"); err != nil {
+		t.Errorf("expected restored record to be taken again, got %v", err)
+	}
+
+	newer := undo.NewExpenseDeletion(expense.Expense{Name: "newer"}, "vaultID")
+	_ = store.Put(ctx, newer, "userID")
+	if err := store.Restore(ctx, record, "userID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	if _, err := store.Take(ctx, newer.ID, "userID"); err != nil {
+		t.Errorf("expected restore to keep record of newer action, got %v", err)
+	}
+}
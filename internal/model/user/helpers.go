@@ -1,6 +1,8 @@
 package user
 
 import (
+	"slices"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -16,3 +18,23 @@ func CheckPassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+// VaultMembers returns users that have access to given vault.
+func VaultMembers(users []User, vaultID string) []User {
+	members := []User{}
+	for _, u := range users {
+		if u.ActiveVault == vaultID || slices.Contains(u.Vaults, vaultID) {
+			members = append(members, u)
+		}
+	}
+	return members
+}
+
+// vaultsOf returns IDs of all vaults user has access to.
+func vaultsOf(u User) []string {
+	vaults := slices.Clone(u.Vaults)
+	if u.ActiveVault != "" && !slices.Contains(vaults, u.ActiveVault) {
+		vaults = append(vaults, u.ActiveVault)
+	}
+	return vaults
+}
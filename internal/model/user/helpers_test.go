@@ -20,3 +20,16 @@ func TestCheckPassword(t *testing.T) {
 		t.Error("didn't return false for incorrect password")
 	}
 }
+
+func TestVaultMembers(t *testing.T) {
+	users := []User{
+		{ID: "active", ActiveVault: "vault"},
+		{ID: "member", ActiveVault: "other", Vaults: []string{"other", "vault"}},
+		{ID: "outsider", ActiveVault: "other", Vaults: []string{"other"}},
+	}
+
+	members := VaultMembers(users, "vault")
+	if len(members) != 2 || members[0].ID != "active" || members[1].ID != "member" {
+		t.Errorf("got %#v", members)
+	}
+}
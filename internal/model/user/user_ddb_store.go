@@ -12,6 +12,19 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+const vaultMemberPKPrefix = "vaultmember"
+
+// vaultMember lists a user as a member of a vault, so that members can be
+// queried without scanning all users.
+type vaultMember struct {
+	PK string `dynamodbav:"PK"`
+	SK string `dynamodbav:"SK"`
+}
+
+func buildVaultMemberPK(vaultID string) string {
+	return vaultMemberPKPrefix + "::" + vaultID
+}
+
 type DDBStore struct {
 	client    *dynamodb.Client
 	tableName string
@@ -72,15 +85,23 @@ func (s *DDBStore) Create(ctx context.Context, userFC User) (User, error) {
 		return User{}, fmt.Errorf("failed to marshal user: %w", err)
 	}
 
-	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+	items := []types.TransactWriteItem{{Put: &types.Put{
 		TableName:           &s.tableName,
 		Item:                item,
 		ConditionExpression: aws.String("attribute_not_exists(SK)"),
-	})
+	}}}
+	memberItems, err := s.vaultMemberPuts(newUser)
+	if err != nil {
+		return User{}, err
+	}
 
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: append(items, memberItems...),
+	})
 	if err != nil {
-		var condErr *types.ConditionalCheckFailedException
-		if errors.As(err, &condErr) {
+		var transactionErr *types.TransactionCanceledException
+		if errors.As(err, &transactionErr) && len(transactionErr.CancellationReasons) > 0 &&
+			aws.ToString(transactionErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
 			return User{}, &AlreadyExistsError{ID: newUser.ID}
 		}
 		return User{}, fmt.Errorf("failed to put item into DynamoDB: %w", err)
@@ -89,6 +110,78 @@ func (s *DDBStore) Create(ctx context.Context, userFC User) (User, error) {
 	return newUser, nil
 }
 
+// Returns items listing the user as a member of each of its vaults.
+func (s *DDBStore) vaultMemberPuts(u User) ([]types.TransactWriteItem, error) {
+	items := []types.TransactWriteItem{}
+	for _, vaultID := range vaultsOf(u) {
+		item, err := attributevalue.MarshalMap(vaultMember{PK: buildVaultMemberPK(vaultID), SK: u.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal vault member: %w", err)
+		}
+		items = append(items, types.TransactWriteItem{Put: &types.Put{TableName: &s.tableName, Item: item}})
+	}
+	return items, nil
+}
+
+// PutVaultMembers lists an existing user as a member of each of its vaults,
+// for users created before vault members were stored.
+func (s *DDBStore) PutVaultMembers(ctx context.Context, u User) error {
+	items, err := s.vaultMemberPuts(u)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		return fmt.Errorf("failed to put vault members of user with ID=%q: %w", u.ID, err)
+	}
+	return nil
+}
+
+// FindVaultMembers returns users that have access to given vault.
+func (s *DDBStore) FindVaultMembers(ctx context.Context, vaultID string) ([]User, error) {
+	keyCond := expression.Key("PK").Equal(expression.Value(buildVaultMemberPK(vaultID)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build expression for vault members query %w", err)
+	}
+
+	ids := []string{}
+	queryPaginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:                 &s.tableName,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+	for queryPaginator.HasMorePages() {
+		response, err := queryPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query for vault members: %w", err)
+		}
+		members := []vaultMember{}
+		if err = attributevalue.UnmarshalListOfMaps(response.Items, &members); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal query response for vault members: %w", err)
+		}
+		for _, member := range members {
+			ids = append(ids, member.SK)
+		}
+	}
+
+	found, err := s.FindAllByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	users := []User{}
+	for _, id := range ids {
+		if u, ok := found[id]; ok {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
 func (s *DDBStore) FindOneByEmail(ctx context.Context, email string) (User, error) {
 	result, err := s.client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              &s.tableName,
@@ -158,14 +251,23 @@ func (s *DDBStore) checkEmailExists(ctx context.Context, email string) (bool, er
 }
 
 func (s *DDBStore) Delete(ctx context.Context, id string) error {
-	if _, err := s.FindOneByID(ctx, id); err != nil {
+	found, err := s.FindOneByID(ctx, id)
+	if err != nil {
 		return &NotFoundError{ID: id}
 	}
 
-	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: &s.tableName,
-		Key:       getKey(id),
-	})
+	items := []types.TransactWriteItem{{Delete: &types.Delete{TableName: &s.tableName, Key: getKey(id)}}}
+	for _, vaultID := range vaultsOf(found) {
+		items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+			TableName: &s.tableName,
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: buildVaultMemberPK(vaultID)},
+				"SK": &types.AttributeValueMemberS{Value: id},
+			},
+		}})
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
 
 	if err != nil {
 		return fmt.Errorf("failed to delete user with ID=%q from table: %w", id, err)
@@ -190,6 +190,38 @@ func TestDDBFindAllByIDs(t *testing.T) {
 	})
 }
 
+func TestDDBFindVaultMembers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tableName, client, removeDDB, err := database.CreateLocalTestDDBTable(ctx)
+	if err != nil {
+		t.Fatalf("failed creating local test ddb table, %v", err)
+	}
+	defer removeDDB()
+	store := user.NewDDBStore(tableName, client)
+
+	userFC, _, _ := user.New(validFirstName, validLastName, validEmail, validPassword)
+	createdUser, err := store.Create(ctx, userFC)
+	assertNoError(t, err)
+	userFC2, _, _ := user.New(validFirstName, validLastName, "howdy@howdy.com", validPassword)
+	createdUser2, err := store.Create(ctx, userFC2)
+	assertNoError(t, err)
+
+	members, err := store.FindVaultMembers(ctx, createdUser.ActiveVault)
+	assertNoError(t, err)
+	assertEqual(t, len(members), 2)
+
+	assertNoError(t, store.Delete(ctx, createdUser2.ID))
+	members, err = store.FindVaultMembers(ctx, createdUser.ActiveVault)
+	assertNoError(t, err)
+	assertEqual(t, len(members), 1)
+	assertEqual(t, members[0].ID, createdUser.ID)
+
+	members, err = store.FindVaultMembers(ctx, "otherVaultID")
+	assertNoError(t, err)
+	assertEqual(t, len(members), 0)
+}
+
 func TestDDBDelete(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
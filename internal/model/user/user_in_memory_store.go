@@ -83,3 +83,7 @@ func (s *InMemoryStore) FindAllByIDs(ctx context.Context, ids []string) (map[str
 func (s *InMemoryStore) FindAll(ctx context.Context) ([]User, error) {
 	return s.users, nil
 }
+
+func (s *InMemoryStore) FindVaultMembers(ctx context.Context, vaultID string) ([]User, error) {
+	return VaultMembers(s.users, vaultID), nil
+}
@@ -152,6 +152,25 @@ func TestInMemoryFindAllByIDs(t *testing.T) {
 	})
 }
 
+func TestInMemoryFindVaultMembers(t *testing.T) {
+	ctx := context.Background()
+	store := &user.InMemoryStore{}
+
+	member, _, _ := user.New(validFirstName, validLastName, validEmail, validPassword)
+	member.ActiveVault = "vaultID"
+	_, err := store.Create(ctx, member)
+	assertNoError(t, err)
+	outsider, _, _ := user.New(validFirstName, validLastName, "howdy@howdy.com", validPassword)
+	outsider.ActiveVault = "otherVaultID"
+	_, err = store.Create(ctx, outsider)
+	assertNoError(t, err)
+
+	members, err := store.FindVaultMembers(ctx, "vaultID")
+	assertNoError(t, err)
+	assertEqual(t, len(members), 1)
+	assertEqual(t, members[0].ID, member.ID)
+}
+
 func TestInMemoryFindOneByEmail(t *testing.T) {
 	ctx := context.Background()
 	store := &user.InMemoryStore{}
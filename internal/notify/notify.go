@@ -0,0 +1,104 @@
+// Package notify delivers messages to users through configured channels, such
+// as in-app notifications or email.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+type Message struct {
+	VaultID string
+	Title   string
+	Body    string
+}
+
+// Channel sends a message to a single recipient.
+type Channel interface {
+	Send(ctx context.Context, recipient user.User, msg Message) error
+}
+
+// Notifier sends messages through all of its channels. A failing channel
+// doesn't stop delivery through the remaining ones.
+type Notifier struct {
+	channels []Channel
+}
+
+func New(channels ...Channel) *Notifier {
+	return &Notifier{channels: channels}
+}
+
+func (n *Notifier) Notify(ctx context.Context, recipients []user.User, msg Message) error {
+	var errs []error
+	for _, recipient := range recipients {
+		for _, channel := range n.channels {
+			if err := channel.Send(ctx, recipient, msg); err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify user with ID='%s' through %T: %w", recipient.ID, channel, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type notificationStore interface {
+	Create(ctx context.Context, n notification.Notification, userID string) error
+}
+
+// InAppChannel saves messages to the recipient's notification list.
+type InAppChannel struct {
+	store notificationStore
+}
+
+func NewInAppChannel(store notificationStore) *InAppChannel {
+	return &InAppChannel{store: store}
+}
+
+func (c *InAppChannel) Send(ctx context.Context, recipient user.User, msg Message) error {
+	return c.store.Create(ctx, notification.New(msg.VaultID, msg.Title, msg.Body), recipient.ID)
+}
+
+// BackgroundChannel sends messages through a slow channel, such as email,
+// without making the caller wait. Send always succeeds, failures are logged.
+type BackgroundChannel struct {
+	channel Channel
+	logger  *slog.Logger
+	timeout time.Duration
+}
+
+func NewBackgroundChannel(channel Channel, logger *slog.Logger, timeout time.Duration) *BackgroundChannel {
+	return &BackgroundChannel{channel: channel, logger: logger, timeout: timeout}
+}
+
+func (c *BackgroundChannel) Send(ctx context.Context, recipient user.User, msg Message) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	go func() {
+		defer cancel()
+		if err := c.channel.Send(ctx, recipient, msg); err != nil {
+			c.logger.Error("failed to send notification in background", "userID", recipient.ID, "channel", fmt.Sprintf("%T", c.channel), "error", err)
+		}
+	}()
+	return nil
+}
+
+// TimeoutChannel sends messages through a slow channel, such as email, on the
+// caller's behalf, but gives up once timeout passes.
+type TimeoutChannel struct {
+	channel Channel
+	timeout time.Duration
+}
+
+func NewTimeoutChannel(channel Channel, timeout time.Duration) *TimeoutChannel {
+	return &TimeoutChannel{channel: channel, timeout: timeout}
+}
+
+func (c *TimeoutChannel) Send(ctx context.Context, recipient user.User, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.channel.Send(ctx, recipient, msg)
+}
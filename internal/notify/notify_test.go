@@ -0,0 +1,90 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
+)
+
+type failingChannel struct{}
+
+func (failingChannel) Send(ctx context.Context, recipient user.User, msg notify.Message) error {
+	return errors.New("unavailable")
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+	store := &notification.InMemoryStore{}
+	notifier := notify.New(failingChannel{}, notify.NewInAppChannel(store))
+
+	err := notifier.Notify(ctx, []user.User{{ID: "a"}, {ID: "b"}}, notify.Message{VaultID: "vaultID", Title: "title", Body: "body"})
+	if err == nil {
+		t.Error("expected error of failing channel")
+	}
+
+	for _, userID := range []string{"a", "b"} {
+		notifications, _ := store.FindLatest(ctx, userID, 10)
+		if len(notifications) != 1 || notifications[0].Title != "title" || notifications[0].Read {
+			t.Errorf("expected unread in-app notification for %s despite failing channel, got %#v", userID, notifications)
+		}
+	}
+}
+
+type recordingChannel struct {
+	sent chan error
+}
+
+func (c recordingChannel) Send(ctx context.Context, recipient user.User, msg notify.Message) error {
+	c.sent <- ctx.Err()
+	return nil
+}
+
+func TestBackgroundChannel(t *testing.T) {
+	recorder := recordingChannel{sent: make(chan error, 1)}
+	channel := notify.NewBackgroundChannel(recorder, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := channel.Send(ctx, user.User{ID: "a"}, notify.Message{Title: "title"}); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	cancel()
+
+	select {
+	case err := <-recorder.sent:
+		if err != nil {
+			t.Errorf("expected message to be sent despite canceled request, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected message to be sent in background")
+	}
+}
+
+type blockingChannel struct{}
+
+func (blockingChannel) Send(ctx context.Context, recipient user.User, msg notify.Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTimeoutChannel(t *testing.T) {
+	channel := notify.NewTimeoutChannel(blockingChannel{}, 50*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- channel.Send(context.Background(), user.User{ID: "a"}, notify.Message{Title: "title"}) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected send to give up after timeout")
+	}
+}
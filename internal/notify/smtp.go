@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// SMTPChannel emails messages to the recipient's address.
+type SMTPChannel struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPChannel creates a channel sending mail through SMTP server at addr
+// (host:port). Authentication is skipped when username is empty.
+func NewSMTPChannel(addr, from, username, password string) *SMTPChannel {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPChannel{addr: addr, from: from, auth: auth}
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, recipient user.User, msg Message) error {
+	if recipient.Email == "" {
+		return nil
+	}
+
+	body := strings.Join([]string{
+		"From: " + c.from,
+		"To: " + recipient.Email,
+		"Subject: " + sanitizeHeader(msg.Title),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	if err := c.sendMail(ctx, recipient.Email, []byte(body)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// sendMail does what smtp.SendMail does, but gives up once ctx is done.
+func (c *SMTPChannel) sendMail(ctx context.Context, to string, body []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	err = c.converse(conn, to, body)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func (c *SMTPChannel) converse(conn net.Conn, to string, body []byte) error {
+	host, _, _ := net.SplitHostPort(c.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if c.auth != nil {
+		if err = client.Auth(c.auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(c.from); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,135 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
+)
+
+// smtpStub is a minimal SMTP server accepting every message.
+type smtpStub struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []string
+}
+
+func newSMTPStub(t testing.TB) *smtpStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start smtp stub: %v", err)
+	}
+	stub := &smtpStub{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+
+	return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 stub ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 end with <CR><LF>.<CR><LF>")
+			data := []string{}
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(line, "\r\n") == "." {
+					break
+				}
+				data = append(data, line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, strings.Join(data, ""))
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStub) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.messages...)
+}
+
+func TestSMTPChannel(t *testing.T) {
+	stub := newSMTPStub(t)
+	channel := notify.NewSMTPChannel(stub.listener.Addr().String(), "tener@example.com", "", "")
+
+	err := channel.Send(context.Background(), user.User{Email: "john@example.com"}, notify.Message{Title: "Budget alert", Body: "Food budget exceeded"})
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	messages := stub.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	for _, want := range []string{"To: john@example.com", "Subject: Budget alert", "Food budget exceeded"} {
+		if !strings.Contains(messages[0], want) {
+			t.Errorf("expected message to contain %q, got %q", want, messages[0])
+		}
+	}
+}
+
+func TestSMTPChannelGivesUpWhenContextIsDone(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start silent server: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	channel := notify.NewSMTPChannel(listener.Addr().String(), "tener@example.com", "", "")
+
+	err = channel.Send(ctx, user.User{Email: "john@example.com"}, notify.Message{Title: "Budget alert"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded error, got %v", err)
+	}
+}
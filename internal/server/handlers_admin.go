@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// Reports sums of the vault (active one by default) that don't match its
+// expenses.
+func (app *Application) reconcileSumsJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	result, err := app.expense.ReconcileSums(r.Context(), adminVault(r, u), false)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile sums: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, result)
+}
+
+func (app *Application) repairSumsJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	vaultID := adminVault(r, u)
+
+	result, err := app.expense.ReconcileSums(r.Context(), vaultID, true)
+	if err != nil {
+		app.emitActionTrail("repair_sums", false, &u, err, map[string]interface{}{"vaultID": vaultID})
+		return fmt.Errorf("failed to repair sums: %w", err)
+	}
+
+	app.emitActionTrail("repair_sums", true, &u, nil, map[string]interface{}{"result": result})
+
+	return writeJSON(w, http.StatusOK, result)
+}
+
+// Returns the vault's monthly expense limit with its usage in current month.
+func (app *Application) getExpenseLimitJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	usage, err := app.expense.GetUsage(r.Context(), helpers.DaysAgo(0), adminVault(r, u))
+	if err != nil {
+		return fmt.Errorf("failed to get expense usage: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, usage)
+}
+
+func (app *Application) setExpenseLimitJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	vaultID := adminVault(r, u)
+
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"limit": {"must be a whole number"}})
+	}
+	if isValid, errMessages := expense.ValidateMonthLimit(limit); !isValid {
+		return InvalidRequestData(errMessages)
+	}
+
+	if err := app.expense.SetMonthLimit(r.Context(), limit, vaultID); err != nil {
+		app.emitActionTrail("set_expense_limit", false, &u, err, map[string]interface{}{"vaultID": vaultID, "limit": limit})
+		return fmt.Errorf("failed to set monthly expense limit: %w", err)
+	}
+
+	app.emitActionTrail("set_expense_limit", true, &u, nil, map[string]interface{}{"vaultID": vaultID, "limit": limit})
+
+	return app.getExpenseLimitJSON(w, r, u)
+}
+
+func adminVault(r *http.Request, u user.User) string {
+	if vaultID := r.URL.Query().Get("vault"); vaultID != "" {
+		return vaultID
+	}
+	return u.ActiveVault
+}
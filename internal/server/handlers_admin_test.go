@@ -0,0 +1,92 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestReconcileSums(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+
+	reconcile := func(t *testing.T, method, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("returns forbidden for non admin users", func(t *testing.T) {
+		t.Setenv("ADMIN_EMAILS", "someone@else.com")
+		assertStatus(t, reconcile(t, http.MethodGet, "/admin/sums").Code, http.StatusForbidden)
+		assertStatus(t, reconcile(t, http.MethodPost, "/admin/sums/repair").Code, http.StatusForbidden)
+	})
+
+	t.Run("reports sums of given vault to admins", func(t *testing.T) {
+		t.Setenv("ADMIN_EMAILS", "someone@else.com, "+validEmail)
+		response := reconcile(t, http.MethodGet, "/admin/sums?vault=vaultID")
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var got expense.ReconcileResult
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if got.VaultID != "vaultID" || len(got.Discrepancies) != 0 {
+			t.Errorf("unexpected result: %#v", got)
+		}
+	})
+}
+
+func TestExpenseLimit(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+
+	send := func(t *testing.T, method, path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+	decodeUsage := func(t *testing.T, response *httptest.ResponseRecorder) expense.Usage {
+		t.Helper()
+		var usage expense.Usage
+		if err := json.NewDecoder(response.Body).Decode(&usage); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return usage
+	}
+
+	t.Run("returns usage of current month", func(t *testing.T) {
+		response := send(t, http.MethodGet, "/expense/usage", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		if usage := decodeUsage(t, response); usage.Count != 3 || usage.NearLimit {
+			t.Errorf("unexpected usage: %#v", usage)
+		}
+	})
+
+	t.Run("returns forbidden for non admin users", func(t *testing.T) {
+		t.Setenv("ADMIN_EMAILS", "someone@else.com")
+		form := url.Values{"limit": {"3"}}
+		assertStatus(t, send(t, http.MethodPut, "/admin/expenselimit?vault=vaultID", form).Code, http.StatusForbidden)
+	})
+
+	t.Run("lets admins change the limit", func(t *testing.T) {
+		t.Setenv("ADMIN_EMAILS", validEmail)
+		assertStatus(t, send(t, http.MethodPut, "/admin/expenselimit?vault=vaultID", url.Values{"limit": {"0"}}).Code, http.StatusBadRequest)
+
+		response := send(t, http.MethodPut, "/admin/expenselimit?vault=vaultID", url.Values{"limit": {"3"}})
+		assertStatus(t, response.Code, http.StatusOK)
+		if usage := decodeUsage(t, response); usage.Limit != 3 || usage.Count != 3 || !usage.NearLimit {
+			t.Errorf("unexpected usage: %#v", usage)
+		}
+	})
+}
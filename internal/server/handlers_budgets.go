@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kkstas/tener/internal/components"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
+)
+
+func (app *Application) renderBudgetsPage(w http.ResponseWriter, r *http.Request, u user.User) error {
+	month, err := budgetMonth(r)
+	if err != nil {
+		return err
+	}
+
+	progress, err := app.budgetOverview(r.Context(), month, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	return app.renderTempl(w, r, components.BudgetsPage(r.Context(), month, progress, categories, u))
+}
+
+// Returns budgeted, spent and remaining amounts of every budget in the month
+// given as `month` query param, or in the current month.
+func (app *Application) getBudgetOverviewJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	month, err := budgetMonth(r)
+	if err != nil {
+		return err
+	}
+
+	progress, err := app.budgetOverview(r.Context(), month, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"month":   month,
+		"budgets": progress,
+	})
+}
+
+func (app *Application) renderBudgetProgress(w http.ResponseWriter, r *http.Request, u user.User) error {
+	month, err := budgetMonth(r)
+	if err != nil {
+		return err
+	}
+
+	progress, err := app.budgetOverview(r.Context(), month, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	return app.renderTempl(w, r, components.BudgetProgress(r.Context(), month, progress))
+}
+
+func (app *Application) createAndRenderSingleBudget(w http.ResponseWriter, r *http.Request, u user.User) error {
+	amount, err := strconv.ParseFloat(strings.Replace(r.FormValue("amount"), ",", ".", 1), 64)
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"amount": {"must be a valid decimal number"}})
+	}
+
+	budgetFC, isValid, errMessages := budget.New(r.FormValue("category"), amount, r.FormValue("rollover") == "true")
+	if !isValid {
+		validationErr := InvalidRequestData(errMessages)
+		app.emitActionTrail("put_budget", false, &u, validationErr, map[string]interface{}{"inputForm": r.Form})
+		return validationErr
+	}
+
+	b, err := app.budget.Put(r.Context(), budgetFC, u.ID, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("put_budget", false, &u, err, map[string]interface{}{"inputForm": r.Form})
+		return fmt.Errorf("failed to put budget: %w", err)
+	}
+
+	app.emitActionTrail("put_budget", true, &u, nil, map[string]interface{}{"budget": b})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (app *Application) deleteSingleBudget(w http.ResponseWriter, r *http.Request, u user.User) error {
+	category := r.PathValue("category")
+
+	err := app.budget.Delete(r.Context(), category, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("delete_budget", false, &u, err, map[string]interface{}{"category": category})
+		var notFoundErr *budget.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed deleting budget: %w", err)
+	}
+
+	app.emitActionTrail("delete_budget", true, &u, nil, map[string]interface{}{"category": category})
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// Reads YYYY-MM month from `month` query param, defaulting to current month.
+func budgetMonth(r *http.Request) (string, error) {
+	month := r.FormValue("month")
+	if month == "" {
+		return helpers.DaysAgo(0)[:7], nil
+	}
+	if !helpers.IsValidYYYYMM(month) {
+		return "", InvalidRequestData(map[string][]string{"month": {"must be in YYYY-MM format"}})
+	}
+	return month, nil
+}
+
+// Computes budget progress in given month from monthly sums, including
+// enough preceding months to account for rollover.
+func (app *Application) budgetOverview(ctx context.Context, month, vaultID string) ([]budget.Progress, error) {
+	budgets, err := app.budget.FindAll(ctx, vaultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budgets: %w", err)
+	}
+	if len(budgets) == 0 {
+		return []budget.Progress{}, nil
+	}
+
+	categories, err := app.expenseCategory.FindAll(ctx, vaultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	monthsAgo, err := helpers.MonthsBetween(month, helpers.DaysAgo(0)[:7])
+	if err != nil {
+		return nil, fmt.Errorf("failed to count months since %s: %w", month, err)
+	}
+
+	monthlySums, err := app.expense.GetMonthlySums(ctx, monthsAgo+budget.RolloverMaxMonths, vaultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find monthly sums: %w", err)
+	}
+
+	return budget.Overview(budgets, categories, monthlySums, month), nil
+}
+
+// Alerts vault members when spending in given category pushed any budget that
+// includes it past an alert threshold in the month of date. Failures are only
+// logged, as the expense itself was already saved. With stream aggregation,
+// sums aren't updated yet, so alerts are left to the stream worker.
+func (app *Application) alertBudgetThresholds(ctx context.Context, u user.User, date, category string) {
+	if app.streamAggregation {
+		return
+	}
+	app.evaluateBudgetAlerts(ctx, u, date, category)
+}
+
+// AlertBudgetThresholds alerts vault members about budgets pushed past an alert
+// threshold by a written expense. The stream worker calls it once it has
+// applied sums of the expense.
+func (app *Application) AlertBudgetThresholds(ctx context.Context, exp expense.Expense, vaultID string) {
+	actor, err := app.user.FindOneByID(ctx, exp.CreatedBy)
+	if err != nil {
+		actor = user.User{ID: exp.CreatedBy}
+	}
+	actor.ActiveVault = vaultID
+	app.evaluateBudgetAlerts(ctx, actor, exp.Date, exp.Category)
+}
+
+func (app *Application) evaluateBudgetAlerts(ctx context.Context, u user.User, date, category string) {
+	month := date[:7]
+
+	progress, err := app.budgetOverview(ctx, month, u.ActiveVault)
+	if err != nil {
+		app.logger.Error("failed to compute budget overview for alerts", "error", err)
+		return
+	}
+	if len(progress) == 0 {
+		return
+	}
+
+	categories, err := app.expenseCategory.FindAll(ctx, u.ActiveVault)
+	if err != nil {
+		app.logger.Error("failed to query expense categories for budget alerts", "error", err)
+		return
+	}
+	affected := append(expensecategory.Ancestors(expensecategory.Parents(categories), category), category)
+
+	for _, p := range progress {
+		if !slices.Contains(affected, p.Category) {
+			continue
+		}
+
+		marked := []int{}
+		for _, threshold := range budget.ReachedThresholds(p) {
+			created, err := app.budget.MarkAlerted(ctx, p.Category, month, threshold, u.ActiveVault)
+			if err != nil {
+				app.logger.Error("failed to record budget alert", "error", err)
+				app.clearBudgetAlerts(ctx, p.Category, month, marked, u.ActiveVault)
+				return
+			}
+			if created {
+				marked = append(marked, threshold)
+			}
+		}
+		if len(marked) == 0 {
+			continue
+		}
+		alerted := marked[len(marked)-1]
+
+		members, err := app.user.FindVaultMembers(ctx, u.ActiveVault)
+		if err == nil {
+			title, body := budget.DescribeAlert(p, month, alerted)
+			err = app.notifier.Notify(ctx, members, notify.Message{VaultID: u.ActiveVault, Title: title, Body: body})
+		}
+		if err != nil {
+			app.logger.Error("failed to send budget alert", "error", err)
+			app.clearBudgetAlerts(ctx, p.Category, month, marked, u.ActiveVault)
+		}
+		app.emitActionTrail("budget_alert", err == nil, &u, err, map[string]interface{}{"category": p.Category, "month": month, "threshold": alerted})
+	}
+}
+
+// Removes records of alerts that weren't sent, so that they are retried with
+// the next expense in that category.
+func (app *Application) clearBudgetAlerts(ctx context.Context, category, month string, thresholds []int, vaultID string) {
+	for _, threshold := range thresholds {
+		if err := app.budget.ClearAlerted(ctx, category, month, threshold, vaultID); err != nil {
+			app.logger.Error("failed to clear budget alert", "error", err)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/kkstas/tener/internal/auth"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func putBudget(t testing.TB, app *server.Application, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/budgets/create", bytes.NewBufferString(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+	return response
+}
+
+func getBudgetOverview(t testing.TB, app *server.Application, query string) []budget.Progress {
+	t.Helper()
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/budgets/overview"+query, nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+	assertStatus(t, response.Code, http.StatusOK)
+
+	var got struct {
+		Budgets []budget.Progress `json:"budgets"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return got.Budgets
+}
+
+func TestBudgetOverview(t *testing.T) {
+	t.Run("returns progress of budgets in current month", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		assertStatus(t, putBudget(t, app, url.Values{"category": {"Transport"}, "amount": {"200"}}).Code, http.StatusOK)
+		assertStatus(t, putBudget(t, app, url.Values{"category": {"Food"}, "amount": {"400"}}).Code, http.StatusOK)
+
+		got := getBudgetOverview(t, app, "")
+		if len(got) != 2 {
+			t.Fatalf("expected 2 budgets, got %#v", got)
+		}
+		if got[0].Category != "Food" || got[0].Spent != 300 || got[0].Remaining != 100 || got[0].OverBudget {
+			t.Errorf("unexpected Food progress: %#v", got[0])
+		}
+		if got[1].Category != "Transport" || got[1].Spent != 250 || !got[1].OverBudget {
+			t.Errorf("expected Transport including subcategories to be over budget, got %#v", got[1])
+		}
+	})
+
+	t.Run("returns bad request for invalid budget", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		response := putBudget(t, app, url.Values{"category": {"Food"}, "amount": {"-5"}})
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("returns bad request for invalid month", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/budgets/overview?month=2024-13", nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+}
+
+func TestBudgetAlerts(t *testing.T) {
+	ctx := context.Background()
+
+	userStore := &user.InMemoryStore{}
+	memberFC, _, _ := user.New(validFirstName, validLastName, validEmail, validPassword)
+	memberFC.ActiveVault = "vaultID"
+	member, _ := userStore.Create(ctx, memberFC)
+	outsiderFC, _, _ := user.New(validFirstName, validLastName, "outsider@example.com", validPassword)
+	outsiderFC.ActiveVault = "otherVaultID"
+	outsider, _ := userStore.Create(ctx, outsiderFC)
+
+	categoryStore := &expensecategory.InMemoryStore{}
+	_ = categoryStore.Create(ctx, expensecategory.Category{Name: "Food"}, member.ID, "vaultID")
+	_ = categoryStore.Create(ctx, expensecategory.Category{Name: "Groceries", Parent: "Food"}, member.ID, "vaultID")
+
+	budgetStore := &budget.InMemoryStore{}
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	_, _ = budgetStore.Put(ctx, budgetFC, member.ID, "vaultID")
+
+	notificationStore := &notification.InMemoryStore{}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	app := server.NewApplication(logger, &expense.InMemoryStore{}, categoryStore, &expenserule.InMemoryStore{}, budgetStore, notificationStore, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
+
+	token, err := auth.CreateToken(member)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	createExpense := func(t *testing.T, amount string) {
+		t.Helper()
+		form := url.Values{"name": {"shopping"}, "amount": {amount}, "category": {"Groceries"}, "paymentMethod": {expense.PaymentMethods[0]}, "date": {helpers.DaysAgo(0)}, "allowDuplicate": {"true"}}
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/create", bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Add("cookie", "token="+token)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+	}
+
+	titles := func(userID string) []string {
+		notifications, _ := notificationStore.FindLatest(ctx, userID, 10)
+		titles := []string{}
+		for _, n := range notifications {
+			titles = append(titles, n.Title)
+		}
+		return titles
+	}
+
+	createExpense(t, "50")
+	if got := titles(member.ID); len(got) != 0 {
+		t.Fatalf("didn't expect alerts below threshold, got %v", got)
+	}
+
+	createExpense(t, "35")
+	createExpense(t, "5")
+	if got := titles(member.ID); len(got) != 1 || got[0] != "80% of Food budget used" {
+		t.Fatalf("expected single 80%% alert, got %v", got)
+	}
+
+	createExpense(t, "20")
+	createExpense(t, "20")
+	if got := titles(member.ID); len(got) != 2 || got[0] != "Budget of Food exceeded" {
+		t.Errorf("expected single exceeded alert, got %v", got)
+	}
+
+	if got := titles(outsider.ID); len(got) != 0 {
+		t.Errorf("didn't expect alerts for users outside the vault, got %v", got)
+	}
+}
+
+// failingOnceChannel fails to deliver its first message.
+type failingOnceChannel struct {
+	calls     int
+	delivered int
+}
+
+func (c *failingOnceChannel) Send(ctx context.Context, recipient user.User, msg notify.Message) error {
+	c.calls++
+	if c.calls == 1 {
+		return errors.New("unavailable")
+	}
+	c.delivered++
+	return nil
+}
+
+func TestBudgetAlertsRetriedAfterFailedSend(t *testing.T) {
+	ctx := context.Background()
+
+	userStore := &user.InMemoryStore{}
+	memberFC, _, _ := user.New(validFirstName, validLastName, validEmail, validPassword)
+	memberFC.ActiveVault = "vaultID"
+	member, _ := userStore.Create(ctx, memberFC)
+
+	categoryStore := &expensecategory.InMemoryStore{}
+	_ = categoryStore.Create(ctx, expensecategory.Category{Name: "Food"}, member.ID, "vaultID")
+
+	budgetStore := &budget.InMemoryStore{}
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	_, _ = budgetStore.Put(ctx, budgetFC, member.ID, "vaultID")
+
+	channel := &failingOnceChannel{}
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	app := server.NewApplication(logger, &expense.InMemoryStore{}, categoryStore, &expenserule.InMemoryStore{}, budgetStore, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore, channel)
+
+	token, err := auth.CreateToken(member)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	for _, amount := range []string{"85", "1", "1"} {
+		form := url.Values{"name": {"shopping"}, "amount": {amount}, "category": {"Food"}, "paymentMethod": {expense.PaymentMethods[0]}, "date": {helpers.DaysAgo(0)}, "allowDuplicate": {"true"}}
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/create", bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Add("cookie", "token="+token)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+	}
+
+	if channel.calls != 2 || channel.delivered != 1 {
+		t.Errorf("expected failed alert to be sent again once, got %d calls and %d delivered", channel.calls, channel.delivered)
+	}
+}
+
+// streamAggregatingStore reports stream aggregation, leaving budget alerts to
+// the stream worker.
+type streamAggregatingStore struct {
+	*expense.InMemoryStore
+}
+
+func (streamAggregatingStore) StreamAggregation() bool { return true }
+
+func TestBudgetAlertsWithStreamAggregation(t *testing.T) {
+	ctx := context.Background()
+
+	userStore := &user.InMemoryStore{}
+	memberFC, _, _ := user.New(validFirstName, validLastName, validEmail, validPassword)
+	memberFC.ActiveVault = "vaultID"
+	member, _ := userStore.Create(ctx, memberFC)
+
+	categoryStore := &expensecategory.InMemoryStore{}
+	_ = categoryStore.Create(ctx, expensecategory.Category{Name: "Food"}, member.ID, "vaultID")
+
+	budgetStore := &budget.InMemoryStore{}
+	budgetFC, _, _ := budget.New("Food", 100, false)
+	_, _ = budgetStore.Put(ctx, budgetFC, member.ID, "vaultID")
+
+	expenseStore := streamAggregatingStore{&expense.InMemoryStore{}}
+	notificationStore := &notification.InMemoryStore{}
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	app := server.NewApplication(logger, expenseStore, categoryStore, &expenserule.InMemoryStore{}, budgetStore, notificationStore, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
+
+	token, err := auth.CreateToken(member)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	form := url.Values{"name": {"shopping"}, "amount": {"120"}, "category": {"Food"}, "paymentMethod": {expense.PaymentMethods[0]}, "date": {helpers.DaysAgo(0)}}
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/expense/create", bytes.NewBufferString(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Add("cookie", "token="+token)
+	app.ServeHTTP(response, request)
+	assertStatus(t, response.Code, http.StatusOK)
+
+	if notifications, _ := notificationStore.FindLatest(ctx, member.ID, 10); len(notifications) != 0 {
+		t.Fatalf("expected alerts to be left to the stream worker, got %v", notifications)
+	}
+
+	expenses, err := expenseStore.Query(ctx, helpers.DaysAgo(0), helpers.DaysAgo(0), nil, "vaultID")
+	if err != nil || len(expenses) != 1 {
+		t.Fatalf("expected created expense, got %v, %v", expenses, err)
+	}
+	app.AlertBudgetThresholds(ctx, expenses[0], "vaultID")
+
+	notifications, _ := notificationStore.FindLatest(ctx, member.ID, 10)
+	if len(notifications) != 1 || notifications[0].Title != "Budget of Food exceeded" {
+		t.Errorf("expected exceeded alert from stream worker, got %v", notifications)
+	}
+}
@@ -1,8 +1,10 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"slices"
 	"strconv"
@@ -12,13 +14,21 @@ import (
 	"github.com/kkstas/tener/internal/helpers"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
 )
 
 var (
 	MonthlySumsLastMonthsCount = 6
+	CategoryMigrationBatchSize = 100
+	DailySumsMaxRangeDays      = 366
 )
 
+// ChartLevelTop rolls monthly sums of subcategories up into their top level
+// categories when passed as the chart's `level` parameter.
+const ChartLevelTop = "top"
+
 func (app *Application) renderHomePage(w http.ResponseWriter, r *http.Request, u user.User) error {
 	expenses := []expense.Expense{}
 	categories := []expensecategory.Category{}
@@ -74,20 +84,50 @@ func (app *Application) renderHomePage(w http.ResponseWriter, r *http.Request, u
 		return fmt.Errorf("failed to find matching users for expenses & expense categories: %w", err)
 	}
 
+	usage, err := app.expense.GetUsage(r.Context(), helpers.DaysAgo(0), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to get expense usage: %w", err)
+	}
+
+	forecast, err := app.forecastCurrentMonth(r.Context(), []string{}, nil, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
 	return app.renderTempl(
 		w, r,
-		components.Home(r.Context(), expenses, expense.PaymentMethods, categories, u, users, monthlySums),
+		components.Home(r.Context(), expenses, expense.PaymentMethods, categories, u, users, monthlySums, usage, forecast),
 	)
 }
 
+// Returns chart data of monthly sums by category, or by payment method or
+// member with the `groupBy` parameter, together with the forecast of the
+// current month. Category filter and level only apply to sums by category.
 func (app *Application) getMonthlySumsJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	groupBy := expense.GroupByCategory
+	if value := r.FormValue("groupBy"); value != "" {
+		var ok bool
+		if groupBy, ok = expense.ParseGroupBy(value); !ok {
+			return InvalidRequestData(map[string][]string{"groupBy": {"must be one of category, paymentMethod or user"}})
+		}
+	}
+	if groupBy != expense.GroupByCategory {
+		return app.getMonthlySumsByJSON(w, r, groupBy, u)
+	}
+
 	_, _, selectedCategories := queryFilters(r)
 	monthlySums, err := app.expense.GetMonthlySums(r.Context(), MonthlySumsLastMonthsCount, u.ActiveVault)
 	if err != nil {
 		return fmt.Errorf("failed to find monthly sums: %w", err)
 	}
 
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
 	if len(selectedCategories) > 0 {
+		selectedCategories = expensecategory.ExpandSelection(categories, selectedCategories)
 		filteredSums := []expense.MonthlySum{}
 		for _, s := range monthlySums {
 			if slices.Contains(selectedCategories, s.Category) {
@@ -97,11 +137,208 @@ func (app *Application) getMonthlySumsJSON(w http.ResponseWriter, r *http.Reques
 		monthlySums = filteredSums
 	}
 
-	return writeJSON(w, http.StatusOK, expense.TransformToChartData(monthlySums))
+	var rollUp func(category string) string
+	if r.FormValue("level") == ChartLevelTop {
+		parents := expensecategory.Parents(categories)
+		rollUp = func(category string) string {
+			return expensecategory.Root(parents, category)
+		}
+		monthlySums = expense.RollUpMonthlySums(monthlySums, rollUp)
+	}
+
+	forecast, err := app.forecastCurrentMonth(r.Context(), selectedCategories, rollUp, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, expense.TransformToChartData(monthlySums).WithColors(expensecategory.ColorPicker(categories)).WithForecast(forecast))
+}
+
+// Forecasts spending of the current month out of its expenses and daily sums
+// of the months of history before it, of selected categories, all when none
+// are selected. Categories are rolled up with rollUp when it is set.
+func (app *Application) forecastCurrentMonth(ctx context.Context, selectedCategories []string, rollUp func(category string) string, vaultID string) (expense.Forecast, error) {
+	today := helpers.DaysAgo(0)
+	firstDay := helpers.GetFirstDayOfCurrentMonth()
+	historyFrom, err := helpers.AddMonths(firstDay, -expense.ForecastHistoryMonths)
+	if err != nil {
+		return expense.Forecast{}, err
+	}
+	historyTo, err := helpers.PreviousDay(firstDay)
+	if err != nil {
+		return expense.Forecast{}, err
+	}
+	_, to, err := helpers.GetFirstAndLastDayOfMonth(today)
+	if err != nil {
+		return expense.Forecast{}, err
+	}
+
+	expenses, err := app.expense.Query(ctx, firstDay, to, selectedCategories, vaultID)
+	if err != nil {
+		return expense.Forecast{}, fmt.Errorf("failed to query expenses for forecast: %w", err)
+	}
+	history, err := app.expense.GetSums(ctx, expense.GranularityDay, historyFrom, historyTo, vaultID)
+	if err != nil {
+		return expense.Forecast{}, fmt.Errorf("failed to find daily sums for forecast: %w", err)
+	}
+	if len(selectedCategories) > 0 {
+		history = slices.DeleteFunc(history, func(s expense.PeriodSum) bool {
+			return !slices.Contains(selectedCategories, s.Category)
+		})
+	}
+	if rollUp != nil {
+		for i := range expenses {
+			expenses[i].Category = rollUp(expenses[i].Category)
+		}
+		for i := range history {
+			history[i].Category = rollUp(history[i].Category)
+		}
+	}
+
+	forecast, err := expense.NewForecast(today, expenses, history)
+	if err != nil {
+		return expense.Forecast{}, fmt.Errorf("failed to forecast spending: %w", err)
+	}
+	return forecast, nil
+}
+
+func (app *Application) getMonthlySumsByJSON(w http.ResponseWriter, r *http.Request, groupBy expense.GroupBy, u user.User) error {
+	monthlySums, err := app.expense.GetMonthlySumsBy(r.Context(), groupBy, MonthlySumsLastMonthsCount, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to find monthly sums by %s: %w", groupBy, err)
+	}
+
+	if groupBy == expense.GroupByUser {
+		userIDs := []string{}
+		for _, s := range monthlySums {
+			if !slices.Contains(userIDs, s.Category) {
+				userIDs = append(userIDs, s.Category)
+			}
+		}
+		users, err := app.user.FindAllByIDs(r.Context(), userIDs)
+		if err != nil {
+			return fmt.Errorf("failed to find users of monthly sums: %w", err)
+		}
+		monthlySums = expense.RollUpMonthlySums(monthlySums, func(userID string) string {
+			if usr, found := users[userID]; found {
+				return usr.FirstName + " " + usr.LastName
+			}
+			return userID
+		})
+	}
+
+	forecast, err := app.forecastCurrentMonth(r.Context(), nil, nil, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+	// Forecast is projected per category, which doesn't match datasets of
+	// other groupings, so only its total is returned.
+	forecast.Categories = []expense.CategoryForecast{}
+
+	return writeJSON(w, http.StatusOK, expense.TransformToChartData(monthlySums).WithForecast(forecast))
+}
+
+// Returns per category sums of days, ISO weeks, months or years between `from`
+// and `to` dates, read from rollups maintained on every write.
+func (app *Application) getSumsJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	from, to, selectedCategories := queryFilters(r)
+
+	granularity := expense.GranularityMonth
+	if value := r.FormValue("granularity"); value != "" {
+		var ok bool
+		if granularity, ok = expense.ParseGranularity(value); !ok {
+			return InvalidRequestData(map[string][]string{"granularity": {"must be one of day, week, month or year"}})
+		}
+	}
+
+	days, err := helpers.DaysBetween(from, to)
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"from": {"must be a valid YYYY-MM-DD date"}, "to": {"must be a valid YYYY-MM-DD date"}})
+	}
+	if days < 0 {
+		return InvalidRequestData(map[string][]string{"to": {"must not be before from"}})
+	}
+	if granularity == expense.GranularityDay && days > DailySumsMaxRangeDays {
+		return InvalidRequestData(map[string][]string{"to": {fmt.Sprintf("daily sums span at most %d days", DailySumsMaxRangeDays)}})
+	}
+
+	sums, err := app.expense.GetSums(r.Context(), granularity, from, to, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to find %s sums: %w", granularity, err)
+	}
+
+	selectedCategories, err = app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+	if len(selectedCategories) > 0 {
+		sums = slices.DeleteFunc(sums, func(s expense.PeriodSum) bool {
+			return !slices.Contains(selectedCategories, s.Category)
+		})
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"granularity": granularity,
+		"from":        from,
+		"to":          to,
+		"sums":        sums,
+	})
+}
+
+// Returns the number of expenses in the month of `date` (current one by
+// default) against the vault's monthly limit.
+func (app *Application) getExpenseUsageJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	date := r.FormValue("date")
+	if date == "" {
+		date = helpers.DaysAgo(0)
+	}
+	if _, err := helpers.DaysBetween(date, date); err != nil {
+		return InvalidRequestData(map[string][]string{"date": {"must be a valid YYYY-MM-DD date"}})
+	}
+
+	usage, err := app.expense.GetUsage(r.Context(), date, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to get expense usage: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, usage)
+}
+
+// Suggests category, payment method and amount for the expense name typed into
+// the create form. Explicit rules take precedence over history based suggestions.
+func (app *Application) suggestExpenseJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	name := strings.TrimSpace(r.FormValue("name"))
+	amount, _ := strconv.ParseFloat(strings.Replace(r.FormValue("amount"), ",", ".", 1), 64)
+
+	suggestion, err := app.expense.Suggest(r.Context(), name, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to suggest expense details: %w", err)
+	}
+
+	rules, err := app.expenseRule.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense rules: %w", err)
+	}
+
+	if rule, found := expenserule.Match(rules, name, amount); found {
+		suggestion.Found = true
+		if rule.Category != "" {
+			suggestion.Category = rule.Category
+		}
+		if rule.PaymentMethod != "" {
+			suggestion.PaymentMethod = rule.PaymentMethod
+		}
+	}
+
+	return writeJSON(w, http.StatusOK, suggestion)
 }
 
 func (app *Application) getExpensesJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
 	from, to, selectedCategories := queryFilters(r)
+	selectedCategories, err := app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
 
 	expenses, err := app.expense.Query(r.Context(), from, to, selectedCategories, u.ActiveVault)
 	if err != nil {
@@ -127,6 +364,10 @@ func (app *Application) getExpensesJSON(w http.ResponseWriter, r *http.Request,
 
 func (app *Application) createSingleExpenseJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
 	from, to, selectedCategories := queryFilters(r)
+	selectedCategories, err := app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
 
 	category := r.FormValue("category")
 	paymentMethod := r.FormValue("paymentMethod")
@@ -140,14 +381,69 @@ func (app *Application) createSingleExpenseJSON(w http.ResponseWriter, r *http.R
 		return InvalidRequestData(map[string][]string{"amount": {"must be a valid decimal number"}})
 	}
 
-	exp, isValid, errMessages := expense.New(name, date, category, amount, paymentMethod)
+	if strings.TrimSpace(category) == "" || paymentMethod == "" {
+		rules, err := app.expenseRule.FindAll(r.Context(), u.ActiveVault)
+		if err != nil {
+			return fmt.Errorf("failed to query expense rules: %w", err)
+		}
+		categorized := expenserule.Apply(rules, expense.Expense{
+			Name:          strings.TrimSpace(name),
+			Amount:        amount,
+			Category:      strings.TrimSpace(category),
+			PaymentMethod: paymentMethod,
+		})
+		category, paymentMethod = categorized.Category, categorized.PaymentMethod
+	}
+
+	installmentCount := 1
+	if raw := r.FormValue("installments"); raw != "" {
+		installmentCount, err = strconv.Atoi(raw)
+		if err != nil {
+			return InvalidRequestData(map[string][]string{"installments": {"must be a whole number"}})
+		}
+	}
+
+	var created []expense.Expense
+	var isValid bool
+	var errMessages map[string][]string
+	if installmentCount > 1 {
+		created, isValid, errMessages = expense.NewInstallments(name, date, category, amount, installmentCount, paymentMethod)
+	} else {
+		var exp expense.Expense
+		exp, isValid, errMessages = expense.New(name, date, category, amount, paymentMethod)
+		created = []expense.Expense{exp}
+	}
 	if !isValid {
 		validationErr := InvalidRequestData(errMessages)
 		app.emitActionTrail("create_expense", false, &u, validationErr, map[string]interface{}{"inputForm": r.Form})
 		return validationErr
 	}
+	if status := r.FormValue("status"); status != "" {
+		if isValid, errMessages := expense.ValidateStatus(status); !isValid {
+			return InvalidRequestData(errMessages)
+		}
+		for i := range created {
+			created[i].Status = status
+		}
+	}
+	exp := created[0]
 
-	_, err = app.expense.Create(r.Context(), exp, u.ID, u.ActiveVault)
+	if r.FormValue("allowDuplicate") != "true" {
+		duplicates, err := app.findPossibleDuplicates(r.Context(), exp, u.ActiveVault)
+		if err != nil {
+			return err
+		}
+		if len(duplicates) > 0 {
+			app.emitActionTrail("create_expense", false, &u, errors.New("possible duplicate"), map[string]interface{}{"inputForm": r.Form})
+			return writeJSON(w, http.StatusConflict, map[string]any{
+				"message":    "similar expense already exists",
+				"statusCode": http.StatusConflict,
+				"duplicates": duplicates,
+			})
+		}
+	}
+
+	err = app.createExpenses(r.Context(), created, u)
 	if err != nil {
 		app.emitActionTrail("create_expense", false, &u, err, map[string]interface{}{"inputForm": r.Form})
 
@@ -160,6 +456,12 @@ func (app *Application) createSingleExpenseJSON(w http.ResponseWriter, r *http.R
 	}
 
 	app.emitActionTrail("create_expense", true, &u, nil, map[string]interface{}{"inputForm": r.Form})
+	app.alertBudgetThresholds(r.Context(), u, exp.Date, exp.Category)
+
+	usage, err := app.expense.GetUsage(r.Context(), exp.Date, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to get expense usage: %w", err)
+	}
 
 	expenses, err := app.expense.Query(r.Context(), from, to, selectedCategories, u.ActiveVault)
 	if err != nil {
@@ -180,11 +482,16 @@ func (app *Application) createSingleExpenseJSON(w http.ResponseWriter, r *http.R
 		"expenses":   expenses,
 		"categories": categories,
 		"users":      users,
+		"usage":      usage,
 	})
 }
 
 func (app *Application) updateSingleExpenseJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
 	from, to, selectedCategories := queryFilters(r)
+	selectedCategories, err := app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
 
 	SK := r.PathValue("SK")
 	category := strings.TrimSpace(r.FormValue("category"))
@@ -199,14 +506,39 @@ func (app *Application) updateSingleExpenseJSON(w http.ResponseWriter, r *http.R
 		return InvalidRequestData(map[string][]string{"amount": {"invalid amount value"}})
 	}
 
+	version, ok := parseIfMatch(r)
+	if !ok {
+		return NewAPIError(http.StatusPreconditionRequired, errors.New("If-Match header with expense version is required"))
+	}
+
 	expenseFU, isValid, errMessages := expense.NewFU(SK, name, date, category, amount, paymentMethod)
 	if !isValid {
 		validationErr := InvalidRequestData(errMessages)
 		app.emitActionTrail("update_expense", false, &u, validationErr, map[string]interface{}{"inputForm": r.Form, "expenseFU": expenseFU})
 		return validationErr
 	}
+	expenseFU.Version = version
+	if status := r.FormValue("status"); status != "" {
+		if isValid, errMessages := expense.ValidateStatus(status); !isValid {
+			return InvalidRequestData(errMessages)
+		}
+		expenseFU.Status = status
+	}
 
-	err = app.expense.Update(r.Context(), expenseFU, u.ActiveVault)
+	previous, err := app.expense.FindOne(r.Context(), SK, u.ActiveVault)
+	if previous.Refund != nil {
+		// the edit form only accepts positive amounts, while refunds are negative
+		expenseFU.Amount = -math.Abs(expenseFU.Amount)
+		if errMessages := refundEditErrors(previous, expenseFU); len(errMessages) > 0 {
+			return InvalidRequestData(errMessages)
+		}
+	}
+	allInstallments := r.FormValue("allInstallments") == "true" && previous.Installment != nil
+	if err == nil && allInstallments {
+		err = app.updateInstallments(r.Context(), previous, expenseFU, u.ActiveVault)
+	} else if err == nil {
+		err = app.expense.Update(r.Context(), expenseFU, u.ActiveVault)
+	}
 	if err != nil {
 		app.emitActionTrail("update_expense", false, &u, err, map[string]interface{}{"inputForm": r.Form, "expenseFU": expenseFU})
 
@@ -215,15 +547,39 @@ func (app *Application) updateSingleExpenseJSON(w http.ResponseWriter, r *http.R
 			return NewAPIError(http.StatusNotFound, err)
 		}
 
+		var conflictErr *expense.VersionConflictError
+		if errors.As(err, &conflictErr) {
+			w.Header().Set("ETag", versionETag(conflictErr.Current.Version))
+			return writeJSON(w, http.StatusConflict, map[string]any{
+				"message":    "expense was changed by someone else in the meantime",
+				"statusCode": http.StatusConflict,
+				"current":    conflictErr.Current,
+			})
+		}
+
 		var maxCountErr *expense.MaxMonthExpenseCountExceededError
 		if errors.As(err, &maxCountErr) {
 			return NewAPIError(http.StatusForbidden, err)
 		}
 
+		var exceedsErr *expense.RefundExceedsOriginalError
+		if errors.As(err, &exceedsErr) {
+			return InvalidRequestData(map[string][]string{"amount": {fmt.Sprintf("cannot be lower than %.2f already refunded", exceedsErr.Refunded)}})
+		}
+
 		return fmt.Errorf("failed to put item: %w", err)
 	}
 
 	app.emitActionTrail("update_expense", true, &u, nil, map[string]interface{}{"inputForm": r.Form})
+	app.alertBudgetThresholds(r.Context(), u, expenseFU.Date, expenseFU.Category)
+	w.Header().Set("ETag", versionETag(version+1))
+
+	if !allInstallments {
+		edited := expenseFU
+		edited.SK = previous.MovedSK(expenseFU.Date)
+		edited.Version = version + 1
+		app.offerUndo(w, r, u, undo.NewExpenseEdit(previous, edited, u.ActiveVault))
+	}
 
 	expenses, err := app.expense.Query(r.Context(), from, to, selectedCategories, u.ActiveVault)
 	if err != nil {
@@ -247,22 +603,51 @@ func (app *Application) updateSingleExpenseJSON(w http.ResponseWriter, r *http.R
 	})
 }
 
+// Returns errors of fields that cannot change in an edit of a refund, which
+// keeps the amount and date checked against the expense it refunds and
+// follows its category.
+func refundEditErrors(refund, refundFU expense.Expense) map[string][]string {
+	errMessages := map[string][]string{}
+	if refundFU.Amount != refund.Amount {
+		errMessages["amount"] = []string{"cannot be changed for a refund"}
+	}
+	if refundFU.Date != refund.Date {
+		errMessages["date"] = []string{"cannot be changed for a refund"}
+	}
+	if refundFU.Category != refund.Category {
+		errMessages["category"] = []string{"follows the refunded expense"}
+	}
+	return errMessages
+}
+
 func (app *Application) deleteSingleExpenseJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
 	sk := r.PathValue("SK")
 
-	err := app.expense.Delete(r.Context(), sk, u.ActiveVault)
+	deleted, err := app.expense.FindOne(r.Context(), sk, u.ActiveVault)
+	if err == nil {
+		err = app.expense.Delete(r.Context(), sk, u.ActiveVault)
+	}
 	if err != nil {
 		app.emitActionTrail("delete_expense", false, &u, err, map[string]interface{}{"SK": sk})
 		var notFoundErr *expense.NotFoundError
 		if errors.As(err, &notFoundErr) {
 			return NewAPIError(http.StatusNotFound, err)
 		}
+		var hasRefundsErr *expense.HasRefundsError
+		if errors.As(err, &hasRefundsErr) {
+			return NewAPIError(http.StatusConflict, err)
+		}
 		return fmt.Errorf("failed to delete item: %w", err)
 	}
 
 	app.emitActionTrail("delete_expense", true, &u, nil, map[string]interface{}{"SK": sk})
+	app.offerUndo(w, r, u, undo.NewExpenseDeletion(deleted, u.ActiveVault))
 
 	from, to, selectedCategories := queryFilters(r)
+	selectedCategories, err = app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
 
 	expenses, err := app.expense.Query(r.Context(), from, to, selectedCategories, u.ActiveVault)
 	if err != nil {
@@ -285,3 +670,23 @@ func (app *Application) deleteSingleExpenseJSON(w http.ResponseWriter, r *http.R
 		"users":      users,
 	})
 }
+
+// Finds expenses in the vault that look like the same purchase as exp, so that
+// two vault members don't log it twice.
+func (app *Application) findPossibleDuplicates(ctx context.Context, exp expense.Expense, vaultID string) ([]expense.Expense, error) {
+	from, err := helpers.PreviousDay(exp.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute previous day: %w", err)
+	}
+	to, err := helpers.NextDay(exp.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute next day: %w", err)
+	}
+
+	candidates, err := app.expense.Query(ctx, from, to, []string{}, vaultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query possible duplicates: %w", err)
+	}
+
+	return expense.FindPossibleDuplicates(exp, candidates), nil
+}
@@ -1,13 +1,18 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/kkstas/tener/internal/components"
+	"github.com/kkstas/tener/internal/model/budget"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
 )
 
@@ -21,13 +26,15 @@ func (app *Application) renderExpenseCategoriesPage(w http.ResponseWriter, r *ht
 	if err != nil {
 		return fmt.Errorf("failed to find matching users for expenses & expense categories: %w", err)
 	}
-	return app.renderTempl(w, r, components.ExpenseCategoriesPage(r.Context(), categories, u, users))
+	sorted, depths := expensecategory.SortHierarchically(categories)
+	return app.renderTempl(w, r, components.ExpenseCategoriesPage(r.Context(), sorted, depths, u, users))
 }
 
 func (app *Application) createAndRenderSingleExpenseCategory(w http.ResponseWriter, r *http.Request, u user.User) error {
 	name := r.FormValue("name")
+	parent := r.FormValue("parent")
 
-	categoryFC, isValid, errMessages := expensecategory.New(name)
+	categoryFC, isValid, errMessages := expensecategory.New(name, parent)
 	if !isValid {
 		return InvalidRequestData(errMessages)
 	}
@@ -38,20 +45,252 @@ func (app *Application) createAndRenderSingleExpenseCategory(w http.ResponseWrit
 		if errors.As(err, &alreadyExistsErr) {
 			return NewAPIError(http.StatusConflict, err)
 		}
+		var parentNotFoundErr *expensecategory.ParentNotFoundError
+		if errors.As(err, &parentNotFoundErr) {
+			return InvalidRequestData(map[string][]string{"parent": {"parent category does not exist"}})
+		}
 		return fmt.Errorf("failed to put item: %w", err)
 	}
 
-	return app.renderTempl(w, r, components.SingleExpenseCategory(r.Context(), categoryFC, u))
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+	depth := len(expensecategory.Ancestors(expensecategory.Parents(categories), categoryFC.Name))
+
+	return app.renderTempl(w, r, components.SingleExpenseCategory(r.Context(), categoryFC, depth, u))
 }
 
+// Updates display settings of a category and renders it again.
+func (app *Application) updateAndRenderSingleExpenseCategory(w http.ResponseWriter, r *http.Request, u user.User) error {
+	name := r.PathValue("name")
+	order, err := strconv.Atoi(r.FormValue("order"))
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"order": {"invalid order value"}})
+	}
+
+	categoryFU, isValid, errMessages := expensecategory.NewMetadataFU(name, r.FormValue("color"), r.FormValue("icon"), order, r.FormValue("archived") == "true")
+	if !isValid {
+		return InvalidRequestData(errMessages)
+	}
+
+	previous, err := app.expenseCategory.FindOne(r.Context(), name, u.ActiveVault)
+	if err == nil {
+		err = app.expenseCategory.UpdateMetadata(r.Context(), categoryFU, u.ActiveVault)
+	}
+	if err != nil {
+		var notFoundErr *expensecategory.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed to update expense category: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+	category, err := app.expenseCategory.FindOne(r.Context(), name, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to find expense category: %w", err)
+	}
+	depth := len(expensecategory.Ancestors(expensecategory.Parents(categories), name))
+	app.offerUndo(w, r, u, undo.NewCategoryEdit(previous, u.ActiveVault))
+
+	return app.renderTempl(w, r, components.SingleExpenseCategory(r.Context(), category, depth, u))
+}
+
+// Deletes a category. When expenses still use it, the request has to either
+// name a `replacement` category to move them into, or explicitly ask to
+// `keepOrphans`; otherwise status conflict with the number of affected expenses
+// is returned. Budget of the category is deleted along with it.
 func (app *Application) deleteSingleExpenseCategory(w http.ResponseWriter, r *http.Request, u user.User) error {
 	name := r.PathValue("name")
+	replacement := strings.TrimSpace(r.FormValue("replacement"))
+	keepOrphans := r.FormValue("keepOrphans") == "true"
+
+	if replacement != "" {
+		migration, err := app.startCategoryMigration(r.Context(), u, name, replacement, true, "replacement")
+		if err != nil {
+			return err
+		}
+		return app.renderTempl(w, r, components.ExpenseCategoryMigrationProgress(r.Context(), name, migration, false, true))
+	}
+
+	if !keepOrphans {
+		count, err := app.expense.CountByCategory(r.Context(), name, u.ActiveVault)
+		if err != nil {
+			return fmt.Errorf("failed to count expenses in category: %w", err)
+		}
+		if count > 0 {
+			return writeJSON(w, http.StatusConflict, map[string]any{
+				"message":      fmt.Sprintf("%d expense(s) still use this category", count),
+				"statusCode":   http.StatusConflict,
+				"expenseCount": count,
+			})
+		}
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+	// Delete reparents children of the category, which undo does not revert.
+	hasChildren := len(expensecategory.Subtree(categories, name)) > 1
 
-	err := app.expenseCategory.Delete(r.Context(), name, u.ActiveVault)
+	deleted, err := app.expenseCategory.FindOne(r.Context(), name, u.ActiveVault)
+	if err == nil {
+		err = app.expenseCategory.Delete(r.Context(), name, u.ActiveVault)
+	}
 	if err != nil {
+		app.emitActionTrail("delete_expense_category", false, &u, err, map[string]interface{}{"name": name, "keepOrphans": keepOrphans})
 		return fmt.Errorf("failed deleting item: %w", err)
 	}
 
+	err = app.budget.Delete(r.Context(), name, u.ActiveVault)
+	var budgetNotFoundErr *budget.NotFoundError
+	if err != nil && !errors.As(err, &budgetNotFoundErr) {
+		return fmt.Errorf("failed to delete budget of deleted category: %w", err)
+	}
+	hadBudget := err == nil
+
+	app.emitActionTrail("delete_expense_category", true, &u, nil, map[string]interface{}{"name": name, "keepOrphans": keepOrphans})
+	if !hasChildren && !hadBudget {
+		app.offerUndo(w, r, u, undo.NewCategoryDeletion(deleted, u.ActiveVault))
+	}
+
 	w.WriteHeader(http.StatusOK)
 	return nil
 }
+
+// Starts renaming a category, or merging it into another one when `merge` form
+// value is "true". Expenses are then moved in batches by consecutive calls to
+// stepExpenseCategoryMigration, triggered by the rendered progress component.
+func (app *Application) startExpenseCategoryMigration(w http.ResponseWriter, r *http.Request, u user.User) error {
+	name := r.PathValue("name")
+	target := strings.TrimSpace(r.FormValue("target"))
+	merge := r.FormValue("merge") == "true"
+
+	migration, err := app.startCategoryMigration(r.Context(), u, name, target, merge, "target")
+	if err != nil {
+		return err
+	}
+
+	return app.renderTempl(w, r, components.ExpenseCategoryMigrationProgress(r.Context(), name, migration, false, true))
+}
+
+// Validates and starts moving expenses of category `name` into `target`.
+// Validation errors are reported under the `field` key.
+func (app *Application) startCategoryMigration(ctx context.Context, u user.User, name, target string, merge bool, field string) (expensecategory.Migration, error) {
+	if _, isValid, errMessages := expensecategory.New(target, ""); !isValid {
+		return expensecategory.Migration{}, InvalidRequestData(map[string][]string{field: errMessages["name"]})
+	}
+
+	categories, err := app.expenseCategory.FindAll(ctx, u.ActiveVault)
+	if err != nil {
+		return expensecategory.Migration{}, fmt.Errorf("failed to query expense categories: %w", err)
+	}
+	if !expensecategory.CanMigrate(categories, name, target) {
+		return expensecategory.Migration{}, InvalidRequestData(map[string][]string{field: {"category cannot be moved into itself or its subcategory"}})
+	}
+
+	total, err := app.expense.CountByCategory(ctx, name, u.ActiveVault)
+	if err != nil {
+		return expensecategory.Migration{}, fmt.Errorf("failed to count expenses in category: %w", err)
+	}
+
+	migration := expensecategory.Migration{Target: target, Merge: merge, Total: total}
+	err = app.expenseCategory.StartMigration(ctx, name, migration, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("start_expense_category_migration", false, &u, err, map[string]interface{}{"name": name, "migration": migration})
+		var notFoundErr *expensecategory.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			if merge {
+				return expensecategory.Migration{}, InvalidRequestData(map[string][]string{field: {"category does not exist"}})
+			}
+			return expensecategory.Migration{}, NewAPIError(http.StatusNotFound, err)
+		}
+		var alreadyExistsErr *expensecategory.AlreadyExistsError
+		if errors.As(err, &alreadyExistsErr) {
+			return expensecategory.Migration{}, InvalidRequestData(map[string][]string{field: {"category with this name already exists; merge into it instead"}})
+		}
+		var inProgressErr *expensecategory.MigrationInProgressError
+		if errors.As(err, &inProgressErr) {
+			return expensecategory.Migration{}, NewAPIError(http.StatusConflict, err)
+		}
+		return expensecategory.Migration{}, fmt.Errorf("failed to start expense category migration: %w", err)
+	}
+
+	app.emitActionTrail("start_expense_category_migration", true, &u, nil, map[string]interface{}{"name": name, "migration": migration})
+
+	return migration, nil
+}
+
+// Moves the next batch of expenses of a category being migrated and renders
+// progress. After the last batch, budget of the category is moved to the
+// target and the category is removed.
+func (app *Application) stepExpenseCategoryMigration(w http.ResponseWriter, r *http.Request, u user.User) error {
+	name := r.PathValue("name")
+
+	category, err := app.expenseCategory.FindOne(r.Context(), name, u.ActiveVault)
+	if err != nil {
+		var notFoundErr *expensecategory.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed to find expense category: %w", err)
+	}
+	if category.Migration == nil {
+		return NewAPIError(http.StatusNotFound, fmt.Errorf("expense category '%s' is not being migrated", name))
+	}
+	migration := *category.Migration
+
+	result, err := app.expense.Recategorize(r.Context(), name, migration.Target, migration.Cursor, CategoryMigrationBatchSize, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to move expenses to category '%s': %w", migration.Target, err)
+	}
+
+	migration.Cursor = result.Cursor
+	migration.Processed += result.Updated
+
+	if !result.Done {
+		err = app.expenseCategory.UpdateMigration(r.Context(), name, migration, u.ActiveVault)
+		if err != nil {
+			return fmt.Errorf("failed to save expense category migration progress: %w", err)
+		}
+		return app.renderTempl(w, r, components.ExpenseCategoryMigrationProgress(r.Context(), name, migration, false, true))
+	}
+
+	err = app.budget.Move(r.Context(), name, migration.Target, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to move budget to category '%s': %w", migration.Target, err)
+	}
+
+	err = app.expenseCategory.FinishMigration(r.Context(), name, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to finish expense category migration: %w", err)
+	}
+
+	app.emitActionTrail("finish_expense_category_migration", true, &u, nil, map[string]interface{}{"name": name, "migration": migration})
+
+	w.Header().Set("HX-Refresh", "true")
+
+	return app.renderTempl(w, r, components.ExpenseCategoryMigrationProgress(r.Context(), name, migration, true, false))
+}
+
+// Lists category names still used by expenses that no longer have a category.
+func (app *Application) getOrphanedExpenseCategoriesJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	counts, err := app.expense.CountCategories(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to count expenses per category: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"orphans": expensecategory.FindOrphans(categories, counts),
+	})
+}
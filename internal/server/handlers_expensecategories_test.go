@@ -0,0 +1,336 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func newTestApplicationWithCategoryTree(t testing.TB) *server.Application {
+	t.Helper()
+	ctx := context.Background()
+
+	userStore := &user.InMemoryStore{}
+	userFC, _, _ := user.New(validFirstName, validLastName, validEmail, validPassword)
+	usr, err := userStore.Create(ctx, userFC)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	categoryStore := &expensecategory.InMemoryStore{}
+	for _, c := range []expensecategory.Category{
+		{Name: "Transport"},
+		{Name: "Fuel", Parent: "Transport"},
+		{Name: "Public transport", Parent: "Transport"},
+		{Name: "Food"},
+	} {
+		if err := categoryStore.Create(ctx, c, usr.ID, "vaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	expenseStore := &expense.InMemoryStore{}
+	for _, e := range []struct {
+		category string
+		amount   float64
+	}{{"Fuel", 200}, {"Public transport", 50}, {"Food", 300}} {
+		expenseFC, _, _ := expense.New("name", helpers.DaysAgo(0), e.category, e.amount, expense.PaymentMethods[0])
+		if _, err := expenseStore.Create(ctx, expenseFC, usr.ID, "vaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return server.NewApplication(logger, expenseStore, categoryStore, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
+}
+
+func TestCategorySubtreeFilter(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/expense/all?categories=Transport&from="+helpers.DaysAgo(0)+"&to="+helpers.DaysAgo(0), nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+
+	assertStatus(t, response.Code, http.StatusOK)
+
+	var got struct {
+		Expenses []expense.Expense `json:"expenses"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(got.Expenses) != 2 {
+		t.Errorf("expected expenses from both Transport subcategories, got %#v", got.Expenses)
+	}
+}
+
+func TestMonthlySumsLevel(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+
+	t.Run("returns sums per subcategory by default", func(t *testing.T) {
+		got := getSumsTotals(t, app, "")
+		if got["Fuel"] != 200 || got["Public transport"] != 50 || got["Food"] != 300 {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("rolls sums up to top level categories", func(t *testing.T) {
+		got := getSumsTotals(t, app, "?level=top")
+		if len(got) != 2 || got["Transport"] != 250 || got["Food"] != 300 {
+			t.Errorf("got %v", got)
+		}
+	})
+}
+
+func TestExpenseCategoryMigration(t *testing.T) {
+	server.CategoryMigrationBatchSize = 1
+	defer func() { server.CategoryMigrationBatchSize = 100 }()
+
+	migrate := func(t *testing.T, app *server.Application, name string, form url.Values) {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expensecategories/"+name+"/migrate", bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		for range 10 {
+			response = httptest.NewRecorder()
+			request = httptest.NewRequest(http.MethodPost, "/expensecategories/"+name+"/migrate/step", nil)
+			addTokenCookie(t, request)
+			app.ServeHTTP(response, request)
+			assertStatus(t, response.Code, http.StatusOK)
+			if response.Header().Get("HX-Refresh") == "true" {
+				return
+			}
+		}
+		t.Fatal("migration did not finish")
+	}
+
+	t.Run("renames category and its expenses", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		migrate(t, app, "Fuel", url.Values{"target": {"Gas"}})
+
+		got := getSumsTotals(t, app, "")
+		if got["Gas"] != 200 || got["Fuel"] != 0 {
+			t.Errorf("expected Fuel sums to move to Gas, got %v", got)
+		}
+		if got := getSumsTotals(t, app, "?level=top"); got["Transport"] != 250 {
+			t.Errorf("expected renamed category to keep its parent, got %v", got)
+		}
+	})
+
+	t.Run("merges category into another one", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		migrate(t, app, "Public%20transport", url.Values{"target": {"Fuel"}, "merge": {"true"}})
+
+		if got := getSumsTotals(t, app, ""); got["Fuel"] != 250 {
+			t.Errorf("expected merged sums, got %v", got)
+		}
+	})
+
+	t.Run("moves budget to renamed category", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		assertStatus(t, putBudget(t, app, url.Values{"category": {"Fuel"}, "amount": {"150"}}).Code, http.StatusOK)
+		migrate(t, app, "Fuel", url.Values{"target": {"Gas"}})
+
+		got := getBudgetOverview(t, app, "")
+		if len(got) != 1 || got[0].Category != "Gas" || got[0].Amount != 150 || got[0].Spent != 200 {
+			t.Errorf("expected budget to move to Gas, got %#v", got)
+		}
+	})
+
+	t.Run("adds budget of merged category to budget of target", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		assertStatus(t, putBudget(t, app, url.Values{"category": {"Fuel"}, "amount": {"150"}}).Code, http.StatusOK)
+		assertStatus(t, putBudget(t, app, url.Values{"category": {"Public transport"}, "amount": {"60.5"}}).Code, http.StatusOK)
+		migrate(t, app, "Public%20transport", url.Values{"target": {"Fuel"}, "merge": {"true"}})
+
+		got := getBudgetOverview(t, app, "")
+		if len(got) != 1 || got[0].Category != "Fuel" || got[0].Amount != 210.5 {
+			t.Errorf("expected merged budget of Fuel, got %#v", got)
+		}
+	})
+
+	t.Run("refuses to move category into its subcategory", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		form := url.Values{"target": {"Fuel"}, "merge": {"true"}}
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expensecategories/Transport/migrate", bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+}
+
+func getSumsTotals(t testing.TB, app *server.Application, query string) map[string]float64 {
+	t.Helper()
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/expense/sums"+query, nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+	assertStatus(t, response.Code, http.StatusOK)
+
+	var chartData expense.ChartData
+	if err := json.NewDecoder(response.Body).Decode(&chartData); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	totals := map[string]float64{}
+	for _, dataset := range chartData.Datasets {
+		if dataset.Forecast {
+			continue
+		}
+		for _, v := range dataset.Data {
+			totals[dataset.Label] += v
+		}
+	}
+	return totals
+}
+
+func TestDeleteExpenseCategory(t *testing.T) {
+	deleteCategory := func(t *testing.T, app *server.Application, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodDelete, "/expensecategories/Food"+query, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	getOrphans := func(t *testing.T, app *server.Application) []expensecategory.Orphan {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/expensecategories/orphans", nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var got struct {
+			Orphans []expensecategory.Orphan `json:"orphans"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return got.Orphans
+	}
+
+	t.Run("returns status conflict when category is in use", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := deleteCategory(t, app, "")
+		assertStatus(t, response.Code, http.StatusConflict)
+
+		if orphans := getOrphans(t, app); len(orphans) != 0 {
+			t.Errorf("didn't expect orphans, got %#v", orphans)
+		}
+	})
+
+	t.Run("keeps orphans when explicitly asked to", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := deleteCategory(t, app, "?keepOrphans=true")
+		assertStatus(t, response.Code, http.StatusOK)
+
+		orphans := getOrphans(t, app)
+		want := []expensecategory.Orphan{{Name: "Food", ExpenseCount: 1}}
+		if len(orphans) != 1 || orphans[0] != want[0] {
+			t.Errorf("got %#v, want %#v", orphans, want)
+		}
+	})
+
+	t.Run("deletes budget of deleted category", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		assertStatus(t, putBudget(t, app, url.Values{"category": {"Food"}, "amount": {"400"}}).Code, http.StatusOK)
+
+		response := deleteCategory(t, app, "?keepOrphans=true")
+		assertStatus(t, response.Code, http.StatusOK)
+
+		if got := getBudgetOverview(t, app, ""); len(got) != 0 {
+			t.Errorf("expected budget to be deleted, got %#v", got)
+		}
+	})
+
+	t.Run("moves expenses to replacement category", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := deleteCategory(t, app, "?replacement=Fuel")
+		assertStatus(t, response.Code, http.StatusOK)
+
+		response = httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expensecategories/Food/migrate/step", nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		if got := getSumsTotals(t, app, ""); got["Fuel"] != 500 || got["Food"] != 0 {
+			t.Errorf("expected Food expenses to move to Fuel, got %v", got)
+		}
+		if orphans := getOrphans(t, app); len(orphans) != 0 {
+			t.Errorf("didn't expect orphans, got %#v", orphans)
+		}
+	})
+}
+
+func TestUpdateExpenseCategory(t *testing.T) {
+	edit := func(t *testing.T, app *server.Application, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expensecategories/Food/edit", bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("uses category color on monthly sums chart", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := edit(t, app, url.Values{"color": {"#123456"}, "icon": {"food"}, "order": {"1"}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		response = httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/expense/sums", nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+
+		var chartData expense.ChartData
+		if err := json.NewDecoder(response.Body).Decode(&chartData); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		for _, dataset := range chartData.Datasets {
+			if dataset.Label == "Food" && dataset.BackgroundColor != "#123456" {
+				t.Errorf("got color %q for Food, want %q", dataset.BackgroundColor, "#123456")
+			}
+			if dataset.BackgroundColor == "" {
+				t.Errorf("expected color for %q", dataset.Label)
+			}
+		}
+	})
+
+	t.Run("returns bad request for invalid color", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		response := edit(t, app, url.Values{"color": {"red"}, "order": {"0"}})
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kkstas/tener/internal/components"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+const ruleHistoryDays = 365
+
+func (app *Application) renderExpenseRulesPage(w http.ResponseWriter, r *http.Request, u user.User) error {
+	rules, err := app.expenseRule.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense rules: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	userIDs := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		userIDs = append(userIDs, rule.CreatedBy)
+	}
+
+	users, err := app.user.FindAllByIDs(r.Context(), userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to find matching users for expense rules: %w", err)
+	}
+
+	return app.renderTempl(w, r, components.ExpenseRulesPage(r.Context(), rules, categories, expense.PaymentMethods, u, users))
+}
+
+func (app *Application) createAndRenderSingleExpenseRule(w http.ResponseWriter, r *http.Request, u user.User) error {
+	minAmount, err := parseOptionalAmount(r.FormValue("minAmount"))
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"minAmount": {"must be a valid decimal number"}})
+	}
+	maxAmount, err := parseOptionalAmount(r.FormValue("maxAmount"))
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"maxAmount": {"must be a valid decimal number"}})
+	}
+
+	var priority int
+	if priorityRaw := r.FormValue("priority"); priorityRaw != "" {
+		priority, err = strconv.Atoi(priorityRaw)
+		if err != nil {
+			return InvalidRequestData(map[string][]string{"priority": {"must be a valid integer"}})
+		}
+	}
+
+	ruleFC, isValid, errMessages := expenserule.New(
+		r.FormValue("matchType"),
+		r.FormValue("pattern"),
+		minAmount,
+		maxAmount,
+		r.FormValue("category"),
+		r.FormValue("paymentMethod"),
+		priority,
+	)
+	if !isValid {
+		validationErr := InvalidRequestData(errMessages)
+		app.emitActionTrail("create_expense_rule", false, &u, validationErr, map[string]interface{}{"inputForm": r.Form})
+		return validationErr
+	}
+
+	rule, err := app.expenseRule.Create(r.Context(), ruleFC, u.ID, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("create_expense_rule", false, &u, err, map[string]interface{}{"inputForm": r.Form})
+		return fmt.Errorf("failed to put expense rule: %w", err)
+	}
+
+	app.emitActionTrail("create_expense_rule", true, &u, nil, map[string]interface{}{"rule": rule})
+
+	return app.renderTempl(w, r, components.SingleExpenseRule(r.Context(), rule, u))
+}
+
+func (app *Application) deleteSingleExpenseRule(w http.ResponseWriter, r *http.Request, u user.User) error {
+	id := r.PathValue("id")
+
+	err := app.expenseRule.Delete(r.Context(), id, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("delete_expense_rule", false, &u, err, map[string]interface{}{"ID": id})
+		var notFoundErr *expenserule.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed deleting expense rule: %w", err)
+	}
+
+	app.emitActionTrail("delete_expense_rule", true, &u, nil, map[string]interface{}{"ID": id})
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// Finds the rule that would be applied to an expense with given name and amount,
+// so that the create expense form can preselect category and payment method.
+func (app *Application) matchExpenseRuleJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	name := strings.TrimSpace(r.FormValue("name"))
+	amount, _ := strconv.ParseFloat(strings.Replace(r.FormValue("amount"), ",", ".", 1), 64)
+
+	rules, err := app.expenseRule.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense rules: %w", err)
+	}
+
+	rule, found := expenserule.Match(rules, name, amount)
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"found":         found,
+		"category":      rule.Category,
+		"paymentMethod": rule.PaymentMethod,
+	})
+}
+
+func (app *Application) previewExpenseRuleJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	changes, err := app.findExpenseRuleChanges(r, u)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"changes": changes,
+	})
+}
+
+func (app *Application) applyExpenseRuleJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	changes, err := app.findExpenseRuleChanges(r, u)
+	if err != nil {
+		return err
+	}
+
+	for i, change := range changes {
+		expenseFU, isValid, errMessages := expense.NewFU(
+			change.Expense.SK,
+			change.Expense.Name,
+			change.Expense.Date,
+			change.NewCategory,
+			change.Expense.Amount,
+			change.NewPaymentMethod,
+		)
+		if !isValid {
+			err = InvalidRequestData(errMessages)
+		} else {
+			expenseFU.Version = change.Expense.Version
+			err = app.expense.Update(r.Context(), expenseFU, u.ActiveVault)
+		}
+		if err != nil {
+			app.emitActionTrail("apply_expense_rule", false, &u, err, map[string]interface{}{"ID": r.PathValue("id"), "updated": i})
+			return app.writeExpenseRulePartiallyApplied(w, change, i, len(changes), err)
+		}
+	}
+
+	app.emitActionTrail("apply_expense_rule", true, &u, nil, map[string]interface{}{"ID": r.PathValue("id"), "updated": len(changes)})
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"updated": len(changes),
+	})
+}
+
+// Reports how many expenses were updated before applying the rule to the
+// expense of change failed, since earlier updates are not rolled back.
+func (app *Application) writeExpenseRulePartiallyApplied(w http.ResponseWriter, change expenserule.Change, updated, total int, err error) error {
+	status, message := http.StatusInternalServerError, "failed to apply expense rule"
+
+	var conflictErr *expense.VersionConflictError
+	var notFoundErr *expense.NotFoundError
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &conflictErr), errors.As(err, &notFoundErr):
+		status, message = http.StatusConflict, "expense was changed by someone else in the meantime"
+	case errors.As(err, &apiErr):
+		status, message = apiErr.StatusCode, "expense would become invalid"
+	default:
+		app.logger.Error("failed to apply expense rule", "SK", change.Expense.SK, "error", err)
+	}
+
+	return writeJSON(w, status, map[string]any{
+		"message":    fmt.Sprintf("%s; %d of %d expenses were updated", message, updated, total),
+		"statusCode": status,
+		"updated":    updated,
+		"failedSK":   change.Expense.SK,
+	})
+}
+
+func (app *Application) findExpenseRuleChanges(r *http.Request, u user.User) ([]expenserule.Change, error) {
+	id := r.PathValue("id")
+
+	rule, err := app.expenseRule.FindOne(r.Context(), id, u.ActiveVault)
+	if err != nil {
+		var notFoundErr *expenserule.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, NewAPIError(http.StatusNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to find expense rule: %w", err)
+	}
+
+	from, to := r.FormValue("from"), r.FormValue("to")
+	if from == "" {
+		from = helpers.DaysAgo(ruleHistoryDays)
+	}
+	if to == "" {
+		to = helpers.DaysAgo(0)
+	}
+
+	days, err := helpers.DaysBetween(from, to)
+	if err != nil {
+		return nil, InvalidRequestData(map[string][]string{"from": {"must be a valid YYYY-MM-DD date"}, "to": {"must be a valid YYYY-MM-DD date"}})
+	}
+	if days < 0 {
+		return nil, InvalidRequestData(map[string][]string{"to": {"must not be before from"}})
+	}
+	if days > ruleHistoryDays {
+		return nil, InvalidRequestData(map[string][]string{"to": {fmt.Sprintf("rules apply to at most %d days of expenses", ruleHistoryDays)}})
+	}
+
+	expenses, err := app.expense.Query(r.Context(), from, to, []string{}, u.ActiveVault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expenses: %w", err)
+	}
+
+	return expenserule.Preview(rule, expenses), nil
+}
@@ -0,0 +1,207 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func newTestApplicationWithRule(t testing.TB, expenseStore *expense.InMemoryStore) (app *server.Application, ruleID string) {
+	t.Helper()
+	ruleStore := &expenserule.InMemoryStore{}
+	ruleFC, isValid, errMessages := expenserule.New(expenserule.MatchContains, "biedronka", nil, nil, "Groceries", expense.PaymentMethods[2], 0)
+	if !isValid {
+		t.Fatalf("didn't expect an error but got one: %v", errMessages)
+	}
+	rule, err := ruleStore.Create(context.Background(), ruleFC, "userID", "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	app = server.NewApplication(logger, expenseStore, &expensecategory.InMemoryStore{}, ruleStore, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
+	return app, rule.ID
+}
+
+func TestCreateExpenseWithRules(t *testing.T) {
+	t.Run("fills category and payment method from matching rule", func(t *testing.T) {
+		expenseStore := &expense.InMemoryStore{}
+		app, _ := newTestApplicationWithRule(t, expenseStore)
+
+		var param = url.Values{}
+		param.Set("amount", "21.37")
+		param.Set("name", "Biedronka Mokotów")
+		param.Set("date", "2024-01-01")
+		var payload = bytes.NewBufferString(param.Encode())
+
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/create", payload)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+
+		assertStatus(t, response.Code, http.StatusOK)
+
+		expenses, _ := expenseStore.Query(context.Background(), "2024-01-01", "2024-01-01", []string{}, "vaultID")
+		if len(expenses) != 1 {
+			t.Fatalf("expected 1 expense, got %d", len(expenses))
+		}
+		if expenses[0].Category != "Groceries" || expenses[0].PaymentMethod != expense.PaymentMethods[2] {
+			t.Errorf("expected rule to be applied, got %#v", expenses[0])
+		}
+	})
+
+	t.Run("keeps category chosen by user", func(t *testing.T) {
+		expenseStore := &expense.InMemoryStore{}
+		app, _ := newTestApplicationWithRule(t, expenseStore)
+
+		var param = url.Values{}
+		param.Set("amount", "21.37")
+		param.Set("name", "Biedronka Mokotów")
+		param.Set("category", "Party")
+		param.Set("paymentMethod", expense.PaymentMethods[0])
+		param.Set("date", "2024-01-01")
+		var payload = bytes.NewBufferString(param.Encode())
+
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/create", payload)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+
+		assertStatus(t, response.Code, http.StatusOK)
+
+		expenses, _ := expenseStore.Query(context.Background(), "2024-01-01", "2024-01-01", []string{}, "vaultID")
+		if len(expenses) != 1 || expenses[0].Category != "Party" {
+			t.Errorf("expected category chosen by user to be kept, got %#v", expenses)
+		}
+	})
+}
+
+func TestMatchExpenseRule(t *testing.T) {
+	app, _ := newTestApplicationWithRule(t, &expense.InMemoryStore{})
+
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/expenserules/match?name=Biedronka&amount=10", nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+
+	assertStatus(t, response.Code, http.StatusOK)
+
+	var got struct {
+		Found    bool   `json:"found"`
+		Category string `json:"category"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !got.Found || got.Category != "Groceries" {
+		t.Errorf("expected Groceries rule to match, got %#v", got)
+	}
+}
+
+func TestApplyExpenseRule(t *testing.T) {
+	expenseStore := &expense.InMemoryStore{}
+	app, ruleID := newTestApplicationWithRule(t, expenseStore)
+
+	expenseFC, _, _ := expense.New("Biedronka Mokotów", "2024-01-01", "Food", 21.37, expense.PaymentMethods[0])
+	if _, err := expenseStore.Create(context.Background(), expenseFC, "userID", "vaultID"); err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/expenserules/"+ruleID+"/apply?from=2024-01-01&to=2024-01-31", nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+
+	assertStatus(t, response.Code, http.StatusOK)
+
+	expenses, _ := expenseStore.Query(context.Background(), "2024-01-01", "2024-01-01", []string{}, "vaultID")
+	if len(expenses) != 1 {
+		t.Fatalf("expected 1 expense, got %d", len(expenses))
+	}
+	if expenses[0].Category != "Groceries" || expenses[0].PaymentMethod != expense.PaymentMethods[2] || expenses[0].Version != 2 {
+		t.Errorf("expected rule to be applied, got %#v", expenses[0])
+	}
+}
+
+func TestApplyExpenseRuleValidatesDates(t *testing.T) {
+	app, ruleID := newTestApplicationWithRule(t, &expense.InMemoryStore{})
+
+	for _, query := range []string{"?from=2024-13-01&to=2024-01-31", "?from=2024-02-01&to=2024-01-31", "?from=2020-01-01&to=2024-01-31"} {
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expenserules/"+ruleID+"/apply"+query, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	}
+}
+
+// conflictingUpdateStore fails updates after the first one with a conflict.
+type conflictingUpdateStore struct {
+	*expense.InMemoryStore
+	updates int
+}
+
+func (s *conflictingUpdateStore) Update(ctx context.Context, expenseFU expense.Expense, vaultID string) error {
+	s.updates++
+	if s.updates > 1 {
+		return &expense.VersionConflictError{SK: expenseFU.SK, Err: errors.New("version mismatch")}
+	}
+	return s.InMemoryStore.Update(ctx, expenseFU, vaultID)
+}
+
+func TestApplyExpenseRuleReportsUpdatedOnConflict(t *testing.T) {
+	expenseStore := &conflictingUpdateStore{InMemoryStore: &expense.InMemoryStore{}}
+	for _, date := range []string{"2024-01-01", "2024-01-02"} {
+		expenseFC, _, _ := expense.New("Biedronka Mokotów", date, "Food", 21.37, expense.PaymentMethods[0])
+		if _, err := expenseStore.Create(context.Background(), expenseFC, "userID", "vaultID"); err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+	}
+
+	ruleStore := &expenserule.InMemoryStore{}
+	ruleFC, _, _ := expenserule.New(expenserule.MatchContains, "biedronka", nil, nil, "Groceries", expense.PaymentMethods[2], 0)
+	rule, err := ruleStore.Create(context.Background(), ruleFC, "userID", "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	app := server.NewApplication(logger, expenseStore, &expensecategory.InMemoryStore{}, ruleStore, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
+
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/expenserules/"+rule.ID+"/apply?from=2024-01-01&to=2024-01-31", nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+
+	assertStatus(t, response.Code, http.StatusConflict)
+
+	var got struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got.Updated != 1 {
+		t.Errorf("expected 1 updated expense to be reported, got %d", got.Updated)
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// Creates given expenses one by one, removing the already created ones when
+// any of them fails, e.g. due to the monthly expense limit. Installments of a
+// purchase don't fit in a single transaction together with their sums.
+func (app *Application) createExpenses(ctx context.Context, expenses []expense.Expense, u user.User) error {
+	for i, exp := range expenses {
+		_, err := app.expense.Create(ctx, exp, u.ID, u.ActiveVault)
+		if err == nil {
+			continue
+		}
+		for _, created := range expenses[:i] {
+			if deleteErr := app.expense.Delete(ctx, created.SK, u.ActiveVault); deleteErr != nil {
+				app.logger.Error("failed to remove installment after failed create", "SK", created.SK, "error", deleteErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Finds all installments of the purchase exp belongs to, querying a year at
+// a time to stay within the maximum query range.
+func (app *Application) findInstallments(ctx context.Context, exp expense.Expense, vaultID string) ([]expense.Expense, error) {
+	from, to, err := exp.InstallmentRange()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute installment range: %w", err)
+	}
+
+	var expenses []expense.Expense
+	for from <= to {
+		yearLater, err := helpers.AddMonths(from, 11)
+		if err != nil {
+			return nil, err
+		}
+		_, chunkTo, err := helpers.GetFirstAndLastDayOfMonth(yearLater)
+		if err != nil {
+			return nil, err
+		}
+		chunkTo = min(chunkTo, to)
+
+		found, err := app.expense.Query(ctx, from, chunkTo, []string{}, vaultID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query installments: %w", err)
+		}
+		expenses = append(expenses, found...)
+
+		if from, err = helpers.NextDay(chunkTo); err != nil {
+			return nil, err
+		}
+	}
+
+	return expense.InstallmentsOf(exp, expenses), nil
+}
+
+// Applies name, category, amount, payment method and status of changes to
+// every installment of the purchase edited, keeping their dates. Like
+// createExpenses, already updated installments are restored when any of them
+// fails.
+func (app *Application) updateInstallments(ctx context.Context, edited, changes expense.Expense, vaultID string) error {
+	if edited.Version != changes.Version {
+		return &expense.VersionConflictError{SK: edited.SK, Current: edited}
+	}
+
+	installments, err := app.findInstallments(ctx, edited, vaultID)
+	if err != nil {
+		return err
+	}
+
+	for i, installment := range expense.EditInstallments(installments, edited, changes) {
+		err := app.expense.Update(ctx, installment, vaultID)
+		if err == nil {
+			continue
+		}
+		for _, previous := range installments[:i] {
+			previous.Version++
+			if restoreErr := app.expense.Update(ctx, previous, vaultID); restoreErr != nil {
+				app.logger.Error("failed to restore installment after failed update", "SK", previous.SK, "error", restoreErr)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Deletes given installments one by one, as long as none of them has refunds.
+// Like createExpenses, already deleted installments are created again when
+// any of them fails.
+func (app *Application) deleteInstallments(ctx context.Context, installments []expense.Expense, vaultID string) error {
+	for _, installment := range installments {
+		if len(installment.Refunds) > 0 {
+			return &expense.HasRefundsError{SK: installment.SK}
+		}
+	}
+
+	for i, installment := range installments {
+		err := app.expense.Delete(ctx, installment.SK, vaultID)
+		if err == nil {
+			continue
+		}
+		for _, deleted := range installments[:i] {
+			if _, restoreErr := app.expense.Create(ctx, deleted, deleted.CreatedBy, vaultID); restoreErr != nil {
+				app.logger.Error("failed to restore installment after failed delete", "SK", deleted.SK, "error", restoreErr)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (app *Application) deleteInstallmentsJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	sk := r.PathValue("SK")
+
+	exp, err := app.expense.FindOne(r.Context(), sk, u.ActiveVault)
+	var installments []expense.Expense
+	if err == nil {
+		installments, err = app.findInstallments(r.Context(), exp, u.ActiveVault)
+	}
+	if err == nil {
+		err = app.deleteInstallments(r.Context(), installments, u.ActiveVault)
+	}
+	if err != nil {
+		app.emitActionTrail("delete_installments", false, &u, err, map[string]interface{}{"SK": sk})
+		var notFoundErr *expense.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		var hasRefundsErr *expense.HasRefundsError
+		if errors.As(err, &hasRefundsErr) {
+			return NewAPIError(http.StatusConflict, err)
+		}
+		return fmt.Errorf("failed to delete installments: %w", err)
+	}
+
+	app.emitActionTrail("delete_installments", true, &u, nil, map[string]interface{}{"SK": sk, "count": len(installments)})
+
+	from, to, selectedCategories := queryFilters(r)
+	selectedCategories, err = app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	expenses, err := app.expense.Query(r.Context(), from, to, selectedCategories, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expenses: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	users, err := app.user.FindAllByIDs(r.Context(), extractUserIDs(expenses, categories))
+	if err != nil {
+		return fmt.Errorf("failed to find matching users for expenses & expense categories: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"expenses":   expenses,
+		"categories": categories,
+		"users":      users,
+	})
+}
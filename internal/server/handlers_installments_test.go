@@ -0,0 +1,168 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func TestInstallments(t *testing.T) {
+	newApp := func() (*server.Application, *expense.InMemoryStore) {
+		store := &expense.InMemoryStore{}
+		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		return server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{}), store
+	}
+
+	send := func(t *testing.T, app *server.Application, method, path string, form url.Values, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	createLaptop := func(t *testing.T, app *server.Application, store *expense.InMemoryStore) []expense.Expense {
+		t.Helper()
+		response := send(t, app, http.MethodPost, "/expense/create", url.Values{
+			"name":          {"Laptop"},
+			"date":          {"2024-01-31"},
+			"category":      {"Electronics"},
+			"amount":        {"100"},
+			"installments":  {"3"},
+			"paymentMethod": {expense.PaymentMethods[0]},
+		}, nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		return queryYear(t, store)
+	}
+
+	t.Run("creates an expense in every month", func(t *testing.T) {
+		app, store := newApp()
+		installments := createLaptop(t, app, store)
+
+		if len(installments) != 3 {
+			t.Fatalf("expected 3 installments, got %#v", installments)
+		}
+		sums, err := store.GetSums(context.Background(), expense.GranularityMonth, "2024-01-01", "2024-12-31", "vaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		got := map[string]float64{}
+		for _, s := range sums {
+			got[s.Period] = s.Sum
+		}
+		if got["2024-01"] != 33.34 || got["2024-02"] != 33.33 || got["2024-03"] != 33.33 {
+			t.Errorf("unexpected monthly sums: %v", got)
+		}
+	})
+
+	t.Run("edits all installments of the purchase", func(t *testing.T) {
+		app, store := newApp()
+		second := createLaptop(t, app, store)[1]
+
+		response := send(t, app, http.MethodPut, "/expense/edit/"+second.SK, url.Values{
+			"name":            {"Work laptop"},
+			"date":            {second.Date},
+			"category":        {"Work"},
+			"amount":          {"40"},
+			"paymentMethod":   {second.PaymentMethod},
+			"allInstallments": {"true"},
+		}, http.Header{"If-Match": {`"` + strconv.Itoa(second.Version) + `"`}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		for _, installment := range queryYear(t, store) {
+			if installment.Name != "Work laptop" || installment.Category != "Work" || installment.Amount != 40 {
+				t.Errorf("expected installment to be edited, got %#v", installment)
+			}
+		}
+	})
+
+	t.Run("keeps installment amounts when editing other fields", func(t *testing.T) {
+		app, store := newApp()
+		second := createLaptop(t, app, store)[1]
+
+		response := send(t, app, http.MethodPut, "/expense/edit/"+second.SK, url.Values{
+			"name":            {"Work laptop"},
+			"date":            {second.Date},
+			"category":        {second.Category},
+			"amount":          {strconv.FormatFloat(second.Amount, 'f', 2, 64)},
+			"paymentMethod":   {second.PaymentMethod},
+			"allInstallments": {"true"},
+		}, http.Header{"If-Match": {`"` + strconv.Itoa(second.Version) + `"`}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var total float64
+		for _, installment := range queryYear(t, store) {
+			total += installment.Amount
+		}
+		if math.Round(total*100) != 10000 {
+			t.Errorf("expected purchase total to stay 100, got %.2f", total)
+		}
+	})
+
+	t.Run("deletes all installments of the purchase", func(t *testing.T) {
+		app, store := newApp()
+		last := createLaptop(t, app, store)[2]
+
+		assertStatus(t, send(t, app, http.MethodDelete, "/expense/installments/"+last.SK, nil, nil).Code, http.StatusOK)
+		if left := queryYear(t, store); len(left) != 0 {
+			t.Errorf("expected no installments left, got %#v", left)
+		}
+	})
+
+	t.Run("keeps all installments when one of them has refunds", func(t *testing.T) {
+		app, store := newApp()
+		installments := createLaptop(t, app, store)
+		refunded := installments[len(installments)-1]
+
+		assertStatus(t, send(t, app, http.MethodPost, "/expense/"+refunded.SK+"/refund", url.Values{"amount": {"10"}, "date": {"2024-12-01"}}, nil).Code, http.StatusOK)
+		assertStatus(t, send(t, app, http.MethodDelete, "/expense/installments/"+installments[0].SK, nil, nil).Code, http.StatusConflict)
+		if left := queryYear(t, store); len(left) != len(installments)+1 {
+			t.Errorf("expected installments and the refund to be kept, got %#v", left)
+		}
+	})
+
+	t.Run("rejects invalid installment count", func(t *testing.T) {
+		app, _ := newApp()
+		response := send(t, app, http.MethodPost, "/expense/create", url.Values{
+			"name":          {"Laptop"},
+			"date":          {"2024-01-31"},
+			"category":      {"Electronics"},
+			"amount":        {"100"},
+			"installments":  {strconv.Itoa(expense.InstallmentsMaxCount + 1)},
+			"paymentMethod": {expense.PaymentMethods[0]},
+		}, nil)
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+}
+
+func queryYear(t testing.TB, store *expense.InMemoryStore) []expense.Expense {
+	t.Helper()
+	expenses, err := store.Query(context.Background(), "2024-01-01", "2024-12-31", []string{}, "vaultID")
+	if err != nil {
+		t.Fatalf("didn't expect an error but got one: %v", err)
+	}
+	return expenses
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+const notificationsLimit = 20
+
+func (app *Application) getNotificationsJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	notifications, err := app.notification.FindLatest(r.Context(), u.ID, notificationsLimit)
+	if err != nil {
+		return fmt.Errorf("failed to query notifications: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"notifications": notifications,
+		"unread":        notification.CountUnread(notifications),
+	})
+}
+
+func (app *Application) markNotificationRead(w http.ResponseWriter, r *http.Request, u user.User) error {
+	id := r.PathValue("id")
+
+	err := app.notification.MarkRead(r.Context(), id, u.ID)
+	if err != nil {
+		var notFoundErr *notification.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
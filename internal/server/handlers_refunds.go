@@ -0,0 +1,110 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// Records a refund of the expense with given SK, dated today unless the date
+// is given, and attributed to the refund's month unless configured otherwise.
+func (app *Application) createRefundJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	sk := r.PathValue("SK")
+
+	amount, err := strconv.ParseFloat(strings.Replace(r.FormValue("amount"), ",", ".", 1), 64)
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"amount": {"must be a valid decimal number"}})
+	}
+	date := r.FormValue("date")
+	if date == "" {
+		date = helpers.DaysAgo(0)
+	}
+	attributeTo := r.FormValue("attributeTo")
+	if attributeTo == "" {
+		attributeTo = expense.RefundToRefundMonth
+	}
+
+	original, err := app.expense.FindOne(r.Context(), sk, u.ActiveVault)
+	if err != nil {
+		var notFoundErr *expense.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed to find expense to refund: %w", err)
+	}
+
+	var refunded float64
+	for _, refundSK := range original.Refunds {
+		earlier, err := app.expense.FindOne(r.Context(), refundSK, u.ActiveVault)
+		if err != nil {
+			return fmt.Errorf("failed to find earlier refund: %w", err)
+		}
+		refunded -= earlier.Amount
+	}
+
+	refund, isValid, errMessages := expense.NewRefund(original, refunded, strings.TrimSpace(r.FormValue("name")), date, amount, attributeTo)
+	if !isValid {
+		validationErr := InvalidRequestData(errMessages)
+		app.emitActionTrail("create_refund", false, &u, validationErr, map[string]interface{}{"SK": sk, "inputForm": r.Form})
+		return validationErr
+	}
+
+	_, err = app.expense.Create(r.Context(), refund, u.ID, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("create_refund", false, &u, err, map[string]interface{}{"SK": sk, "inputForm": r.Form})
+
+		var maxCountErr *expense.MaxMonthExpenseCountExceededError
+		if errors.As(err, &maxCountErr) {
+			return NewAPIError(http.StatusForbidden, err)
+		}
+		var notFoundErr *expense.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		var maxRefundsErr *expense.MaxRefundsExceededError
+		if errors.As(err, &maxRefundsErr) {
+			return InvalidRequestData(map[string][]string{"refund": {maxRefundsErr.Error()}})
+		}
+		var exceedsErr *expense.RefundExceedsOriginalError
+		if errors.As(err, &exceedsErr) {
+			return InvalidRequestData(map[string][]string{"amount": {exceedsErr.Error()}})
+		}
+
+		return fmt.Errorf("failed to put refund: %w", err)
+	}
+
+	app.emitActionTrail("create_refund", true, &u, nil, map[string]interface{}{"SK": sk, "inputForm": r.Form})
+
+	from, to, selectedCategories := queryFilters(r)
+	selectedCategories, err = app.expandCategoryFilter(r.Context(), selectedCategories, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	expenses, err := app.expense.Query(r.Context(), from, to, selectedCategories, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expenses: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	users, err := app.user.FindAllByIDs(r.Context(), extractUserIDs(expenses, categories))
+	if err != nil {
+		return fmt.Errorf("failed to find matching users for expenses & expense categories: %w", err)
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"expenses":   expenses,
+		"categories": categories,
+		"users":      users,
+	})
+}
@@ -0,0 +1,192 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/budget"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func TestCreateRefund(t *testing.T) {
+	setup := func(t *testing.T) (*server.Application, *expense.InMemoryStore, expense.Expense) {
+		t.Helper()
+		store := &expense.InMemoryStore{}
+		expenseFC, _, _ := expense.New("Shoes", "2024-01-10", "Clothes", 200, expense.PaymentMethods[0])
+		original, err := store.Create(context.Background(), expenseFC, "userID", "vaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		app := server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
+		return app, store, original
+	}
+
+	refund := func(t *testing.T, app *server.Application, sk string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/"+sk+"/refund", bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	deleteExpense := func(t *testing.T, app *server.Application, sk string) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodDelete, "/expense/"+sk, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	monthlySums := func(t *testing.T, store *expense.InMemoryStore) map[string]float64 {
+		t.Helper()
+		sums, err := store.GetSums(context.Background(), expense.GranularityMonth, "2024-01-01", "2024-12-31", "vaultID")
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		got := map[string]float64{}
+		for _, s := range sums {
+			got[s.Period] = s.Sum
+		}
+		return got
+	}
+
+	t.Run("reduces sums of the original month when configured", func(t *testing.T) {
+		app, store, original := setup(t)
+
+		response := refund(t, app, original.SK, url.Values{"amount": {"50"}, "date": {"2024-02-05"}, "attributeTo": {expense.RefundToOriginalMonth}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		if got := monthlySums(t, store); got["2024-01"] != 150 || got["2024-02"] != 0 {
+			t.Errorf("unexpected monthly sums: %v", got)
+		}
+	})
+
+	t.Run("reduces sums of the refund month by default", func(t *testing.T) {
+		app, store, original := setup(t)
+
+		response := refund(t, app, original.SK, url.Values{"amount": {"50"}, "date": {"2024-02-05"}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		if got := monthlySums(t, store); got["2024-01"] != 200 || got["2024-02"] != -50 {
+			t.Errorf("unexpected monthly sums: %v", got)
+		}
+	})
+
+	t.Run("rejects refund exceeding the original amount", func(t *testing.T) {
+		app, _, original := setup(t)
+		response := refund(t, app, original.SK, url.Values{"amount": {"250"}, "date": {"2024-02-05"}})
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("moves refund with the original when its date changes", func(t *testing.T) {
+		app, store, original := setup(t)
+
+		response := refund(t, app, original.SK, url.Values{"amount": {"50"}, "date": {"2024-02-05"}, "attributeTo": {expense.RefundToOriginalMonth}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		form := url.Values{
+			"name":          {original.Name},
+			"date":          {"2024-02-01"},
+			"category":      {original.Category},
+			"amount":        {"200"},
+			"paymentMethod": {original.PaymentMethod},
+		}
+		response = httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPut, "/expense/edit/"+original.SK, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("If-Match", `"`+strconv.Itoa(original.Version)+`"`)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		if got := monthlySums(t, store); got["2024-01"] != 0 || got["2024-02"] != 150 {
+			t.Errorf("unexpected monthly sums: %v", got)
+		}
+		expenses, _ := store.Query(context.Background(), "2024-01-01", "2024-12-31", []string{}, "vaultID")
+		for _, exp := range expenses {
+			if exp.Refund != nil && exp.Refund.SK != original.MovedSK("2024-02-01") {
+				t.Errorf("expected refund to point at moved expense, got %q", exp.Refund.SK)
+			}
+		}
+	})
+
+	t.Run("refuses to delete expense with refunds", func(t *testing.T) {
+		app, store, original := setup(t)
+
+		assertStatus(t, refund(t, app, original.SK, url.Values{"amount": {"50"}, "date": {"2024-02-05"}}).Code, http.StatusOK)
+		assertStatus(t, deleteExpense(t, app, original.SK).Code, http.StatusConflict)
+
+		expenses, _ := store.Query(context.Background(), "2024-02-05", "2024-02-05", []string{}, "vaultID")
+		if len(expenses) != 1 {
+			t.Fatalf("expected the refund, got %#v", expenses)
+		}
+		assertStatus(t, deleteExpense(t, app, expenses[0].SK).Code, http.StatusOK)
+		assertStatus(t, deleteExpense(t, app, original.SK).Code, http.StatusOK)
+	})
+
+	t.Run("rejects refunds exceeding the original amount together", func(t *testing.T) {
+		app, _, original := setup(t)
+		assertStatus(t, refund(t, app, original.SK, url.Values{"amount": {"150"}, "date": {"2024-02-05"}}).Code, http.StatusOK)
+		assertStatus(t, refund(t, app, original.SK, url.Values{"amount": {"60"}, "date": {"2024-02-06"}}).Code, http.StatusBadRequest)
+		assertStatus(t, refund(t, app, original.SK, url.Values{"amount": {"50"}, "date": {"2024-02-06"}}).Code, http.StatusOK)
+	})
+
+	t.Run("keeps refunds within the original when editing", func(t *testing.T) {
+		app, store, original := setup(t)
+		assertStatus(t, refund(t, app, original.SK, url.Values{"amount": {"150"}, "date": {"2024-02-05"}}).Code, http.StatusOK)
+		expenses, _ := store.Query(context.Background(), "2024-02-05", "2024-02-05", []string{}, "vaultID")
+		if len(expenses) != 1 {
+			t.Fatalf("expected the refund, got %#v", expenses)
+		}
+		refunded := expenses[0]
+
+		edit := func(t *testing.T, exp expense.Expense, form url.Values) *httptest.ResponseRecorder {
+			t.Helper()
+			response := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPut, "/expense/edit/"+exp.SK, bytes.NewBufferString(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.Header.Set("If-Match", `"`+strconv.Itoa(exp.Version)+`"`)
+			addTokenCookie(t, request)
+			app.ServeHTTP(response, request)
+			return response
+		}
+		form := func(exp expense.Expense, amount, category string) url.Values {
+			return url.Values{"name": {exp.Name}, "date": {exp.Date}, "category": {category}, "amount": {amount}, "paymentMethod": {exp.PaymentMethod}}
+		}
+
+		assertStatus(t, edit(t, refunded, form(refunded, "190", refunded.Category)).Code, http.StatusBadRequest)
+		assertStatus(t, edit(t, refunded, form(refunded, "150", "Shoes")).Code, http.StatusBadRequest)
+		assertStatus(t, edit(t, original, form(original, "100", original.Category)).Code, http.StatusBadRequest)
+		assertStatus(t, edit(t, original, form(original, "200", "Shoes")).Code, http.StatusOK)
+
+		moved, _ := store.FindOne(context.Background(), refunded.SK, "vaultID")
+		if moved.Category != "Shoes" {
+			t.Errorf("expected refund to follow category of the original, got %q", moved.Category)
+		}
+	})
+
+	t.Run("returns not found for missing expense", func(t *testing.T) {
+		app, _, _ := setup(t)
+		response := refund(t, app, "2024-01-10::missing", url.Values{"amount": {"50"}})
+		assertStatus(t, response.Code, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kkstas/tener/internal/components"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// ReportDefaultMonths is the number of months, up to the current one, covered
+// by the report unless `from` and `to` are given.
+const ReportDefaultMonths = 6
+
+func (app *Application) renderReportsPage(w http.ResponseWriter, r *http.Request, u user.User) error {
+	report, err := app.buildReport(r, u)
+	if err != nil {
+		return err
+	}
+	comparison, err := app.buildComparison(r, u)
+	if err != nil {
+		return err
+	}
+	return app.renderTempl(w, r, components.ReportsPage(r.Context(), report, comparison, u))
+}
+
+// Returns the pivot table of categories by month between `from` and `to`
+// months, read from monthly sums.
+func (app *Application) getReportJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	report, err := app.buildReport(r, u)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, report)
+}
+
+func (app *Application) buildReport(r *http.Request, u user.User) (expense.Report, error) {
+	to := r.FormValue("to")
+	if to == "" {
+		to = helpers.DaysAgo(0)[:7]
+	}
+	from := r.FormValue("from")
+	if from == "" && helpers.IsValidYYYYMM(to) {
+		firstMonth, err := helpers.AddMonths(to+"-01", 1-ReportDefaultMonths)
+		if err != nil {
+			return expense.Report{}, err
+		}
+		from = firstMonth[:7]
+	}
+	if isValid, errMessages := expense.ValidateReportPeriod(from, to); !isValid {
+		return expense.Report{}, InvalidRequestData(errMessages)
+	}
+
+	sums, err := app.expense.GetSums(r.Context(), expense.GranularityMonth, from+"-01", to+"-01", u.ActiveVault)
+	if err != nil {
+		return expense.Report{}, fmt.Errorf("failed to find monthly sums: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return expense.Report{}, fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	report, err := expense.NewReport(from, to, sums)
+	if err != nil {
+		return expense.Report{}, fmt.Errorf("failed to build report: %w", err)
+	}
+	return report.WithColors(expensecategory.ColorPicker(categories)), nil
+}
+
+// Returns per category sums of months between `compareFrom` and `compareTo`
+// next to the previous period and the same months last year.
+func (app *Application) getComparisonJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	comparison, err := app.buildComparison(r, u)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, comparison)
+}
+
+func (app *Application) buildComparison(r *http.Request, u user.User) (expense.Comparison, error) {
+	from, to := r.FormValue("compareFrom"), r.FormValue("compareTo")
+	if to == "" {
+		to = helpers.DaysAgo(0)[:7]
+	}
+	if from == "" {
+		from = to
+	}
+	if isValid, errMessages := expense.ValidateReportPeriod(from, to); !isValid {
+		renamed := map[string][]string{}
+		for key, renamedKey := range map[string]string{"from": "compareFrom", "to": "compareTo"} {
+			if len(errMessages[key]) > 0 {
+				renamed[renamedKey] = errMessages[key]
+			}
+		}
+		return expense.Comparison{}, InvalidRequestData(renamed)
+	}
+
+	previous, lastYear, err := expense.ComparisonPeriods(from, to)
+	if err != nil {
+		return expense.Comparison{}, err
+	}
+	sums, err := app.expense.GetSums(r.Context(), expense.GranularityMonth, min(previous.From, lastYear.From)+"-01", to+"-01", u.ActiveVault)
+	if err != nil {
+		return expense.Comparison{}, fmt.Errorf("failed to find monthly sums: %w", err)
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return expense.Comparison{}, fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	comparison, err := expense.NewComparison(from, to, sums)
+	if err != nil {
+		return expense.Comparison{}, fmt.Errorf("failed to build comparison: %w", err)
+	}
+	return comparison.WithColors(expensecategory.ColorPicker(categories)), nil
+}
@@ -0,0 +1,84 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+func TestReports(t *testing.T) {
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		app := newTestApplicationWithCategoryTree(t)
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("returns report of current month by default", func(t *testing.T) {
+		response := get(t, "/reports/data")
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var report expense.Report
+		if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		month := helpers.DaysAgo(0)[:7]
+		if len(report.Months) != 6 || report.Months[5] != month || report.Total != 550 {
+			t.Errorf("unexpected report: %#v", report)
+		}
+		if len(report.Rows) != 3 || report.Rows[0].Category != "Food" || report.Rows[0].Color == "" {
+			t.Errorf("unexpected rows: %#v", report.Rows)
+		}
+	})
+
+	t.Run("returns 400 for too long period", func(t *testing.T) {
+		assertStatus(t, get(t, "/reports/data?from=2020-01&to=2026-01").Code, http.StatusBadRequest)
+	})
+
+	t.Run("renders reports page", func(t *testing.T) {
+		assertStatus(t, get(t, "/reports").Code, http.StatusOK)
+	})
+}
+
+func TestComparison(t *testing.T) {
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		app := newTestApplicationWithCategoryTree(t)
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("compares current month by default", func(t *testing.T) {
+		response := get(t, "/reports/compare")
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var comparison expense.Comparison
+		if err := json.NewDecoder(response.Body).Decode(&comparison); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		month := helpers.DaysAgo(0)[:7]
+		if comparison.Current.From != month || comparison.Current.To != month {
+			t.Errorf("unexpected current period: %#v", comparison.Current)
+		}
+		if comparison.Total.Current != 550 || comparison.Total.Previous != 0 || comparison.Total.PreviousDelta.Percent != nil {
+			t.Errorf("unexpected total: %#v", comparison.Total)
+		}
+		if len(comparison.Rows) != 3 || comparison.Rows[0].Category != "Food" || comparison.Rows[0].Color == "" {
+			t.Errorf("unexpected rows: %#v", comparison.Rows)
+		}
+	})
+
+	t.Run("returns 400 for invalid period", func(t *testing.T) {
+		assertStatus(t, get(t, "/reports/compare?compareFrom=2026-10&compareTo=2026-09").Code, http.StatusBadRequest)
+	})
+}
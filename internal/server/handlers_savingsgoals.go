@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kkstas/tener/internal/components"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+func (app *Application) renderSavingsGoalsPage(w http.ResponseWriter, r *http.Request, u user.User) error {
+	goals, err := app.savingsGoal.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query savings goals: %w", err)
+	}
+
+	progress, err := app.savingsGoalsProgress(r.Context(), goals, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	categories, err := app.expenseCategory.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expense categories: %w", err)
+	}
+
+	return app.renderTempl(w, r, components.SavingsGoalsPage(r.Context(), progress, categories, u))
+}
+
+// Returns progress and projected completion of every savings goal.
+func (app *Application) getSavingsGoalsOverviewJSON(w http.ResponseWriter, r *http.Request, u user.User) error {
+	goals, err := app.savingsGoal.FindAll(r.Context(), u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query savings goals: %w", err)
+	}
+
+	progress, err := app.savingsGoalsProgress(r.Context(), goals, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, map[string]any{
+		"goals": progress,
+	})
+}
+
+func (app *Application) renderSavingsGoalPage(w http.ResponseWriter, r *http.Request, u user.User) error {
+	goal, err := app.findSavingsGoal(r.Context(), r.PathValue("id"), u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	contributions, err := app.savingsGoal.FindContributions(r.Context(), goal.ID, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query savings goal contributions: %w", err)
+	}
+
+	progress, err := app.savingsGoalsProgress(r.Context(), []savingsgoal.Goal{goal}, u.ActiveVault)
+	if err != nil {
+		return err
+	}
+
+	return app.renderTempl(w, r, components.SavingsGoalPage(r.Context(), progress[0], contributions, u))
+}
+
+func (app *Application) createSavingsGoal(w http.ResponseWriter, r *http.Request, u user.User) error {
+	targetAmount, err := strconv.ParseFloat(strings.Replace(r.FormValue("targetAmount"), ",", ".", 1), 64)
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"targetAmount": {"must be a valid decimal number"}})
+	}
+
+	goalFC, isValid, errMessages := savingsgoal.New(r.FormValue("name"), targetAmount, r.FormValue("targetDate"), r.FormValue("category"))
+	if !isValid {
+		validationErr := InvalidRequestData(errMessages)
+		app.emitActionTrail("create_savings_goal", false, &u, validationErr, map[string]interface{}{"inputForm": r.Form})
+		return validationErr
+	}
+
+	goal, err := app.savingsGoal.Create(r.Context(), goalFC, u.ID, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("create_savings_goal", false, &u, err, map[string]interface{}{"inputForm": r.Form})
+		return fmt.Errorf("failed to put savings goal: %w", err)
+	}
+
+	app.emitActionTrail("create_savings_goal", true, &u, nil, map[string]interface{}{"goal": goal})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (app *Application) deleteSavingsGoal(w http.ResponseWriter, r *http.Request, u user.User) error {
+	id := r.PathValue("id")
+
+	err := app.savingsGoal.Delete(r.Context(), id, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("delete_savings_goal", false, &u, err, map[string]interface{}{"ID": id})
+		var notFoundErr *savingsgoal.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed deleting savings goal: %w", err)
+	}
+
+	app.emitActionTrail("delete_savings_goal", true, &u, nil, map[string]interface{}{"ID": id})
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (app *Application) addSavingsGoalContribution(w http.ResponseWriter, r *http.Request, u user.User) error {
+	amount, err := strconv.ParseFloat(strings.Replace(r.FormValue("amount"), ",", ".", 1), 64)
+	if err != nil {
+		return InvalidRequestData(map[string][]string{"amount": {"must be a valid decimal number"}})
+	}
+
+	contributionFC, isValid, errMessages := savingsgoal.NewContribution(r.PathValue("id"), amount, r.FormValue("date"), r.FormValue("note"))
+	if !isValid {
+		validationErr := InvalidRequestData(errMessages)
+		app.emitActionTrail("add_savings_goal_contribution", false, &u, validationErr, map[string]interface{}{"inputForm": r.Form})
+		return validationErr
+	}
+
+	contribution, err := app.savingsGoal.AddContribution(r.Context(), contributionFC, u.ID, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("add_savings_goal_contribution", false, &u, err, map[string]interface{}{"inputForm": r.Form})
+		var notFoundErr *savingsgoal.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed to put savings goal contribution: %w", err)
+	}
+
+	app.emitActionTrail("add_savings_goal_contribution", true, &u, nil, map[string]interface{}{"contribution": contribution})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (app *Application) deleteSavingsGoalContribution(w http.ResponseWriter, r *http.Request, u user.User) error {
+	goalID, SK := r.PathValue("id"), r.PathValue("SK")
+
+	err := app.savingsGoal.DeleteContribution(r.Context(), goalID, SK, u.ActiveVault)
+	if err != nil {
+		app.emitActionTrail("delete_savings_goal_contribution", false, &u, err, map[string]interface{}{"ID": goalID, "SK": SK})
+		var notFoundErr *savingsgoal.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed deleting savings goal contribution: %w", err)
+	}
+
+	app.emitActionTrail("delete_savings_goal_contribution", true, &u, nil, map[string]interface{}{"ID": goalID, "SK": SK})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (app *Application) findSavingsGoal(ctx context.Context, id, vaultID string) (savingsgoal.Goal, error) {
+	goal, err := app.savingsGoal.FindOne(ctx, id, vaultID)
+	if err != nil {
+		var notFoundErr *savingsgoal.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return savingsgoal.Goal{}, NewAPIError(http.StatusNotFound, err)
+		}
+		return savingsgoal.Goal{}, fmt.Errorf("failed to find savings goal: %w", err)
+	}
+	return goal, nil
+}
+
+// Summarizes given goals from their contributions and, for goals with a linked
+// category, monthly sums of that category since the oldest of the goals.
+func (app *Application) savingsGoalsProgress(ctx context.Context, goals []savingsgoal.Goal, vaultID string) ([]savingsgoal.Progress, error) {
+	today := helpers.DaysAgo(0)
+
+	oldestLinked := ""
+	for _, goal := range goals {
+		if goal.Category != "" && (oldestLinked == "" || goal.CreatedAt[:7] < oldestLinked) {
+			oldestLinked = goal.CreatedAt[:7]
+		}
+	}
+
+	monthlySums := []expense.MonthlySum{}
+	if oldestLinked != "" {
+		monthsAgo, err := helpers.MonthsBetween(oldestLinked, today[:7])
+		if err != nil {
+			return nil, fmt.Errorf("failed to count months since %s: %w", oldestLinked, err)
+		}
+		monthlySums, err = app.expense.GetMonthlySums(ctx, monthsAgo, vaultID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find monthly sums: %w", err)
+		}
+	}
+
+	progress := make([]savingsgoal.Progress, 0, len(goals))
+	for _, goal := range goals {
+		contributions, err := app.savingsGoal.FindContributions(ctx, goal.ID, vaultID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query savings goal contributions: %w", err)
+		}
+		progress = append(progress, savingsgoal.Summarize(goal, contributions, monthlySums, today))
+	}
+
+	return progress, nil
+}
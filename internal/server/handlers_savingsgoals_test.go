@@ -0,0 +1,91 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func TestSavingsGoals(t *testing.T) {
+	post := func(t *testing.T, app *server.Application, path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	getOverview := func(t *testing.T, app *server.Application) []savingsgoal.Progress {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/savings/overview", nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var got struct {
+			Goals []savingsgoal.Progress `json:"goals"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return got.Goals
+	}
+
+	t.Run("sums contributions and linked category expenses", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := post(t, app, "/savings/create", url.Values{"name": {"Vacation"}, "targetAmount": {"1000"}, "targetDate": {helpers.DaysAgo(-400)}, "category": {"Food"}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		goals := getOverview(t, app)
+		if len(goals) != 1 {
+			t.Fatalf("expected one goal, got %#v", goals)
+		}
+
+		response = post(t, app, "/savings/"+goals[0].GoalID+"/contributions", url.Values{"amount": {"100"}, "date": {helpers.DaysAgo(0)}})
+		assertStatus(t, response.Code, http.StatusOK)
+
+		got := getOverview(t, app)[0]
+		if got.Saved != 400 || got.Remaining != 600 || got.ProjectedMonth == "" || !got.OnTrack {
+			t.Errorf("unexpected progress: %#v", got)
+		}
+	})
+
+	t.Run("returns bad request for invalid goal", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		response := post(t, app, "/savings/create", url.Values{"name": {"Vacation"}, "targetAmount": {"-1"}, "targetDate": {helpers.DaysAgo(-30)}})
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("returns not found for contribution to missing goal", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		response := post(t, app, "/savings/missing/contributions", url.Values{"amount": {"100"}, "date": {helpers.DaysAgo(0)}})
+		assertStatus(t, response.Code, http.StatusNotFound)
+	})
+
+	t.Run("does not delete goal as contribution", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		assertStatus(t, post(t, app, "/savings/create", url.Values{"name": {"Vacation"}, "targetAmount": {"1000"}, "targetDate": {helpers.DaysAgo(-400)}}).Code, http.StatusOK)
+		goalID := getOverview(t, app)[0].GoalID
+
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodDelete, "/savings/x/contributions/goal::"+goalID, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+
+		assertStatus(t, response.Code, http.StatusNotFound)
+		if goals := getOverview(t, app); len(goals) != 1 {
+			t.Errorf("expected goal to be kept, got %#v", goals)
+		}
+	})
+}
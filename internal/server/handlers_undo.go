@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/undo"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// offerUndo stores the undo record of a destructive action and triggers the
+// `undoAvailable` event, showing the undo toast. Failing to store it does not
+// fail the action itself.
+func (app *Application) offerUndo(w http.ResponseWriter, r *http.Request, u user.User, record undo.Record) {
+	if err := app.undo.Put(r.Context(), record, u.ID); err != nil {
+		app.logger.Error("failed to put undo record", "error", err)
+		return
+	}
+
+	trigger, err := json.Marshal(map[string]any{
+		"undoAvailable": map[string]any{
+			"id":      record.ID,
+			"label":   record.Describe(),
+			"seconds": int(undo.Window.Seconds()),
+		},
+	})
+	if err != nil {
+		app.logger.Error("failed to encode undo trigger", "error", err)
+		return
+	}
+	w.Header().Set("HX-Trigger", string(trigger))
+}
+
+// Restores the state from before the last destructive action of the user, as
+// long as it is still within the undo window.
+func (app *Application) undoLastAction(w http.ResponseWriter, r *http.Request, u user.User) error {
+	id := r.PathValue("id")
+
+	record, err := app.undo.Take(r.Context(), id, u.ID)
+	if err != nil {
+		var notFoundErr *undo.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+		return fmt.Errorf("failed to take undo record: %w", err)
+	}
+	if record.VaultID != u.ActiveVault {
+		return NewAPIError(http.StatusNotFound, &undo.NotFoundError{ID: id})
+	}
+
+	err = app.applyUndo(r, record)
+	if err != nil {
+		// the record was taken before applying it, so that it is applied once
+		if restoreErr := app.undo.Restore(r.Context(), record, u.ID); restoreErr != nil {
+			app.logger.Error("failed to restore undo record", "error", restoreErr)
+		}
+		app.emitActionTrail("undo", false, &u, err, map[string]interface{}{"ID": id, "action": record.Action})
+		return err
+	}
+
+	app.emitActionTrail("undo", true, &u, nil, map[string]interface{}{"ID": id, "action": record.Action})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (app *Application) applyUndo(r *http.Request, record undo.Record) error {
+	ctx, vaultID := r.Context(), record.VaultID
+
+	switch record.Action {
+	case undo.ActionDeleteExpense:
+		_, err := app.expense.Create(ctx, *record.Expense, record.Expense.CreatedBy, vaultID)
+		var maxCountErr *expense.MaxMonthExpenseCountExceededError
+		if errors.As(err, &maxCountErr) {
+			return NewAPIError(http.StatusForbidden, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore expense: %w", err)
+		}
+
+	case undo.ActionEditExpense:
+		previous := *record.Expense
+		previous.SK = record.EditedSK
+		previous.Version = record.EditedVersion
+		err := app.expense.Update(ctx, previous, vaultID)
+		var notFoundErr *expense.NotFoundError
+		var conflictErr *expense.VersionConflictError
+		if errors.As(err, &notFoundErr) || errors.As(err, &conflictErr) {
+			return NewAPIError(http.StatusConflict, errors.New("expense was changed since, so the edit cannot be undone"))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revert expense: %w", err)
+		}
+
+	case undo.ActionDeleteCategory:
+		category := *record.Category
+		err := app.expenseCategory.Create(ctx, category, category.CreatedBy, vaultID)
+		var alreadyExistsErr *expensecategory.AlreadyExistsError
+		if errors.As(err, &alreadyExistsErr) {
+			return NewAPIError(http.StatusConflict, err)
+		}
+		var parentNotFoundErr *expensecategory.ParentNotFoundError
+		if errors.As(err, &parentNotFoundErr) {
+			return NewAPIError(http.StatusConflict, errors.New("parent category was deleted since, so the deletion cannot be undone"))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore expense category: %w", err)
+		}
+		if err := app.expenseCategory.UpdateMetadata(ctx, category, vaultID); err != nil {
+			return fmt.Errorf("failed to restore expense category metadata: %w", err)
+		}
+
+	case undo.ActionEditCategory:
+		err := app.expenseCategory.UpdateMetadata(ctx, *record.Category, vaultID)
+		var notFoundErr *expensecategory.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusConflict, errors.New("expense category was deleted since, so the edit cannot be undone"))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revert expense category: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func TestUndo(t *testing.T) {
+	// undo records are kept per user, so every request reuses the same token
+	tokenRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	addTokenCookie(t, tokenRequest)
+	cookie := tokenRequest.Header.Get("cookie")
+
+	send := func(t *testing.T, app *server.Application, method, path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("cookie", cookie)
+		if version := form.Get("version"); version != "" {
+			request.Header.Set("If-Match", `"`+version+`"`)
+		}
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	undoID := func(t *testing.T, response *httptest.ResponseRecorder) string {
+		t.Helper()
+		var trigger struct {
+			UndoAvailable struct {
+				ID string `json:"id"`
+			} `json:"undoAvailable"`
+		}
+		if err := json.Unmarshal([]byte(response.Header().Get("HX-Trigger")), &trigger); err != nil || trigger.UndoAvailable.ID == "" {
+			t.Fatalf("expected undoAvailable trigger, got %q", response.Header().Get("HX-Trigger"))
+		}
+		return trigger.UndoAvailable.ID
+	}
+
+	findFood := func(t *testing.T, app *server.Application) expense.Expense {
+		t.Helper()
+		for _, exp := range getExpenses(t, app) {
+			if exp.Category == "Food" {
+				return exp
+			}
+		}
+		t.Fatal("expected Food expense")
+		return expense.Expense{}
+	}
+
+	t.Run("restores deleted expense with its sums", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		food := findFood(t, app)
+
+		response := send(t, app, http.MethodDelete, "/expense/"+food.SK, nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		id := undoID(t, response)
+		if got := getSumsTotals(t, app, "")["Food"]; got != 0 {
+			t.Fatalf("got Food total %.2f after delete, want 0", got)
+		}
+
+		assertStatus(t, send(t, app, http.MethodPost, "/undo/"+id, nil).Code, http.StatusOK)
+		if restored := findFood(t, app); restored.SK != food.SK || restored.Amount != 300 {
+			t.Errorf("got %#v, want restored %#v", restored, food)
+		}
+		if got := getSumsTotals(t, app, "")["Food"]; got != 300 {
+			t.Errorf("got Food total %.2f after undo, want 300", got)
+		}
+
+		assertStatus(t, send(t, app, http.MethodPost, "/undo/"+id, nil).Code, http.StatusNotFound)
+	})
+
+	t.Run("reverts edited expense unless it changed since", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		food := findFood(t, app)
+		form := url.Values{
+			"name":          {food.Name},
+			"date":          {food.Date},
+			"category":      {"Food"},
+			"paymentMethod": {food.PaymentMethod},
+			"amount":        {"10"},
+			"version":       {strconv.Itoa(food.Version)},
+		}
+
+		response := send(t, app, http.MethodPut, "/expense/edit/"+food.SK, form)
+		assertStatus(t, response.Code, http.StatusOK)
+		assertStatus(t, send(t, app, http.MethodPost, "/undo/"+undoID(t, response), nil).Code, http.StatusOK)
+		if got := findFood(t, app).Amount; got != 300 {
+			t.Errorf("got amount %.2f after undo, want 300", got)
+		}
+
+		form.Set("version", strconv.Itoa(findFood(t, app).Version))
+		response = send(t, app, http.MethodPut, "/expense/edit/"+food.SK, form)
+		assertStatus(t, response.Code, http.StatusOK)
+		id := undoID(t, response)
+
+		form.Set("amount", "20")
+		otherUserResponse := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPut, "/expense/edit/"+food.SK, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("If-Match", `"`+strconv.Itoa(findFood(t, app).Version)+`"`)
+		addTokenCookie(t, request)
+		app.ServeHTTP(otherUserResponse, request)
+		assertStatus(t, otherUserResponse.Code, http.StatusOK)
+
+		assertStatus(t, send(t, app, http.MethodPost, "/undo/"+id, nil).Code, http.StatusConflict)
+		if got := findFood(t, app).Amount; got != 20 {
+			t.Errorf("got amount %.2f, want other user's 20", got)
+		}
+	})
+
+	t.Run("restores deleted category", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := send(t, app, http.MethodDelete, "/expensecategories/Food?keepOrphans=true", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		assertStatus(t, send(t, app, http.MethodPost, "/undo/"+undoID(t, response), nil).Code, http.StatusOK)
+
+		response = send(t, app, http.MethodGet, "/expensecategories/orphans", nil)
+		var body struct {
+			Orphans []any `json:"orphans"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body.Orphans) != 0 {
+			t.Errorf("expected no orphaned categories after undo, got %v", body.Orphans)
+		}
+	})
+	t.Run("does not offer undo when category had children or budget", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := send(t, app, http.MethodDelete, "/expensecategories/Transport?keepOrphans=true", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		if trigger := response.Header().Get("HX-Trigger"); trigger != "" {
+			t.Errorf("expected no undo after reparenting children, got %q", trigger)
+		}
+
+		assertStatus(t, send(t, app, http.MethodPost, "/budgets/create", url.Values{"category": {"Food"}, "amount": {"100"}}).Code, http.StatusOK)
+		response = send(t, app, http.MethodDelete, "/expensecategories/Food?keepOrphans=true", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		if trigger := response.Header().Get("HX-Trigger"); trigger != "" {
+			t.Errorf("expected no undo after deleting budget, got %q", trigger)
+		}
+	})
+
+	t.Run("returns conflict when parent of deleted category is gone", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := send(t, app, http.MethodDelete, "/expensecategories/Fuel?keepOrphans=true", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		id := undoID(t, response)
+
+		assertStatus(t, send(t, app, http.MethodDelete, "/expensecategories/Transport?keepOrphans=true", nil).Code, http.StatusOK)
+		assertStatus(t, send(t, app, http.MethodPost, "/undo/"+id, nil).Code, http.StatusConflict)
+	})
+
+	t.Run("keeps action undoable when undoing it fails", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := send(t, app, http.MethodDelete, "/expensecategories/Food?keepOrphans=true", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		id := undoID(t, response)
+
+		assertStatus(t, send(t, app, http.MethodPost, "/expensecategories/create", url.Values{"name": {"Food"}}).Code, http.StatusOK)
+		for range 2 {
+			assertStatus(t, send(t, app, http.MethodPost, "/undo/"+id, nil).Code, http.StatusConflict)
+		}
+	})
+}
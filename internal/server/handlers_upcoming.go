@@ -0,0 +1,66 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/kkstas/tener/internal/components"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/model/user"
+)
+
+// Lists planned expenses due within the next expense.UpcomingDays days,
+// together with overdue ones from as many days back that were not cleared.
+func (app *Application) renderUpcomingExpensesPage(w http.ResponseWriter, r *http.Request, u user.User) error {
+	today := helpers.DaysAgo(0)
+
+	expenses, err := app.expense.Query(r.Context(), helpers.DaysAgo(expense.UpcomingDays), helpers.DaysAgo(-expense.UpcomingDays), []string{}, u.ActiveVault)
+	if err != nil {
+		return fmt.Errorf("failed to query expenses: %w", err)
+	}
+
+	planned := []expense.Expense{}
+	for _, exp := range expenses {
+		if exp.IsPlanned() {
+			planned = append(planned, exp)
+		}
+	}
+	sort.Slice(planned, func(i, j int) bool { return planned[i].SK < planned[j].SK })
+
+	return app.renderTempl(w, r, components.UpcomingExpensesPage(r.Context(), planned, today, u))
+}
+
+func (app *Application) clearExpense(w http.ResponseWriter, r *http.Request, u user.User) error {
+	sk := r.PathValue("SK")
+
+	exp, err := app.expense.FindOne(r.Context(), sk, u.ActiveVault)
+	if err == nil && exp.Status != expense.StatusCleared {
+		exp.Status = expense.StatusCleared
+		err = app.expense.Update(r.Context(), exp, u.ActiveVault)
+	}
+	if err != nil {
+		app.emitActionTrail("clear_expense", false, &u, err, map[string]interface{}{"SK": sk})
+
+		var notFoundErr *expense.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return NewAPIError(http.StatusNotFound, err)
+		}
+
+		var conflictErr *expense.VersionConflictError
+		if errors.As(err, &conflictErr) {
+			return NewAPIError(http.StatusConflict, err)
+		}
+
+		return fmt.Errorf("failed to clear expense: %w", err)
+	}
+
+	app.emitActionTrail("clear_expense", true, &u, nil, map[string]interface{}{"SK": sk})
+	app.alertBudgetThresholds(r.Context(), u, exp.Date, exp.Category)
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
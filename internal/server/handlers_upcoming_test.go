@@ -0,0 +1,104 @@
+package server_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/server"
+)
+
+func TestUpcomingExpenses(t *testing.T) {
+	send := func(t *testing.T, app *server.Application, method, path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	findRent := func(t *testing.T, app *server.Application) expense.Expense {
+		t.Helper()
+		for _, exp := range getExpenses(t, app) {
+			if exp.Name == "Rent" {
+				return exp
+			}
+		}
+		t.Fatal("expected Rent expense")
+		return expense.Expense{}
+	}
+
+	t.Run("sums planned expenses apart until cleared", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		var food expense.Expense
+		for _, exp := range getExpenses(t, app) {
+			if exp.Category == "Food" {
+				food = exp
+			}
+		}
+		form := url.Values{
+			"name":          {"Rent"},
+			"date":          {food.Date},
+			"category":      {"Food"},
+			"amount":        {"300"},
+			"paymentMethod": {food.PaymentMethod},
+			"status":        {expense.StatusPlanned},
+		}
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPut, "/expense/edit/"+food.SK, bytes.NewBufferString(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("If-Match", `"`+strconv.Itoa(food.Version)+`"`)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		totals := getSumsTotals(t, app, "")
+		if totals["Food"] != 0 || totals[expense.PlannedLabel] != 300 {
+			t.Errorf("got totals %v, want no Food and Planned 300", totals)
+		}
+
+		response = send(t, app, http.MethodGet, "/expense/upcoming", nil)
+		assertStatus(t, response.Code, http.StatusOK)
+		if !strings.Contains(response.Body.String(), "Rent") {
+			t.Error("expected planned expense to be listed as upcoming")
+		}
+
+		assertStatus(t, send(t, app, http.MethodPost, "/expense/"+findRent(t, app).SK+"/clear", nil).Code, http.StatusOK)
+
+		if got := findRent(t, app).Status; got != expense.StatusCleared {
+			t.Errorf("got status %q, want %q", got, expense.StatusCleared)
+		}
+		totals = getSumsTotals(t, app, "")
+		if totals["Food"] != 300 || totals[expense.PlannedLabel] != 0 {
+			t.Errorf("got totals %v, want Food 300 and no planned", totals)
+		}
+	})
+
+	t.Run("rejects invalid status", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+
+		response := send(t, app, http.MethodPost, "/expense/create", url.Values{
+			"name":          {"Rent"},
+			"date":          {helpers.DaysAgo(0)},
+			"category":      {"Food"},
+			"amount":        {"1200"},
+			"paymentMethod": {expense.PaymentMethods[0]},
+			"status":        {"scheduled"},
+		})
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("returns not found when clearing missing expense", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		assertStatus(t, send(t, app, http.MethodPost, "/expense/"+helpers.DaysAgo(0)+"::missing/clear", nil).Code, http.StatusNotFound)
+	})
+}
@@ -10,8 +10,14 @@ import (
 	"os"
 	"testing"
 
+	"github.com/kkstas/tener/internal/model/budget"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
 	"github.com/kkstas/tener/internal/server"
 )
@@ -40,7 +46,7 @@ func TestLogin(t *testing.T) {
 
 		userStore := &user.InMemoryStore{}
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore)
+		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
 
 		userFC, isValid, errMessages := user.New("John", "Doe", email, password)
 		if !isValid {
@@ -96,7 +102,7 @@ func TestLogin(t *testing.T) {
 		userStore := &user.InMemoryStore{}
 
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore)
+		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
 
 		userFC, isValid, errMessages := user.New("John", "Doe", email, validPassword)
 		if !isValid {
@@ -157,7 +163,7 @@ func TestRegister(t *testing.T) {
 
 		userStore := &user.InMemoryStore{}
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore)
+		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
 		app.ServeHTTP(response, request)
 
 		assertStatus(t, response.Code, http.StatusOK)
@@ -187,7 +193,7 @@ func TestRegister(t *testing.T) {
 
 		userStore := &user.InMemoryStore{}
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore).ServeHTTP(response, request)
+		server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore).ServeHTTP(response, request)
 
 		assertStatus(t, response.Code, http.StatusBadRequest)
 	})
@@ -208,7 +214,7 @@ func TestRegister(t *testing.T) {
 
 		userStore := &user.InMemoryStore{}
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore).ServeHTTP(response, request)
+		server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore).ServeHTTP(response, request)
 
 		assertStatus(t, response.Code, http.StatusBadRequest)
 	})
@@ -228,7 +234,7 @@ func TestRegister(t *testing.T) {
 
 		userStore := &user.InMemoryStore{}
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore)
+		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
 
 		userFC, isValid, errMessages := user.New(validFirstName, validLastName, validEmail, validPassword)
 		if !isValid {
@@ -267,7 +273,7 @@ func TestLogout(t *testing.T) {
 
 		userStore := &user.InMemoryStore{}
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, userStore)
+		app := server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, userStore)
 
 		app.ServeHTTP(response, request)
 		assertStatus(t, response.Code, http.StatusSeeOther)
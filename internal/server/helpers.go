@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -43,6 +45,32 @@ func queryFilters(r *http.Request) (from, to string, selectedCategories []string
 	return from, to, selectedCategories
 }
 
+// Expands selected categories with their subcategories, so that filtering by a
+// parent category also matches expenses in any of its children.
+func (app *Application) expandCategoryFilter(ctx context.Context, selectedCategories []string, vaultID string) ([]string, error) {
+	if len(selectedCategories) == 0 {
+		return selectedCategories, nil
+	}
+	categories, err := app.expenseCategory.FindAll(ctx, vaultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expense categories: %w", err)
+	}
+	return expensecategory.ExpandSelection(categories, selectedCategories), nil
+}
+
+// Parses optional decimal amount form value, allowing comma as a decimal separator.
+func parseOptionalAmount(raw string) (*float64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
+
 func extractUserIDs(expenses []expense.Expense, categories []expensecategory.Category) []string {
 	uniqueUserIDs := make(map[string]bool)
 
@@ -110,3 +138,22 @@ func writeJSON(w http.ResponseWriter, status int, v any) error {
 	w.WriteHeader(status)
 	return json.NewEncoder(w).Encode(v)
 }
+
+// Formats expense version as a strong ETag.
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Parses the expense version the client expects from the If-Match header.
+func parseIfMatch(r *http.Request) (int, bool) {
+	value := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-Match")), "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, false
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 0 {
+		return 0, false
+	}
+	return version, true
+}
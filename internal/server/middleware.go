@@ -1,12 +1,19 @@
 package server
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/kkstas/tener/internal/auth"
+	"github.com/kkstas/tener/internal/model/idempotency"
 	"github.com/kkstas/tener/internal/model/user"
 	"github.com/kkstas/tener/internal/url"
 )
@@ -53,6 +60,22 @@ func (app *Application) toggleRegisterMiddleware(fn APIFunc) APIFunc {
 	}
 }
 
+// requireAdmin lets through only users whose email is listed in the
+// comma-separated ADMIN_EMAILS env variable.
+func requireAdmin(fn func(http.ResponseWriter, *http.Request, user.User) error) func(http.ResponseWriter, *http.Request, user.User) error {
+	return func(w http.ResponseWriter, r *http.Request, u user.User) error {
+		admins := strings.Split(os.Getenv("ADMIN_EMAILS"), ",")
+		for i := range admins {
+			admins[i] = strings.TrimSpace(admins[i])
+		}
+		if u.Email == "" || !slices.Contains(admins, u.Email) {
+			return NewAPIError(http.StatusForbidden, errors.New("admin access required"))
+		}
+
+		return fn(w, r, u)
+	}
+}
+
 func (app *Application) withUser(fn func(http.ResponseWriter, *http.Request, user.User) error) APIFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		token, err := r.Cookie("token")
@@ -73,6 +96,98 @@ func (app *Application) withUser(fn func(http.ResponseWriter, *http.Request, use
 	}
 }
 
+// idempotent replays the stored response of a request made with the same
+// Idempotency-Key header instead of running fn again. Requests without the
+// header are passed through.
+func (app *Application) idempotent(fn func(http.ResponseWriter, *http.Request, user.User) error) func(http.ResponseWriter, *http.Request, user.User) error {
+	return func(w http.ResponseWriter, r *http.Request, u user.User) error {
+		key := r.Header.Get(idempotency.HeaderName)
+		if key == "" {
+			return fn(w, r, u)
+		}
+		if len(key) > idempotency.KeyMaxLength {
+			return NewAPIError(http.StatusBadRequest, fmt.Errorf("%s header cannot be longer than %d characters", idempotency.HeaderName, idempotency.KeyMaxLength))
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := idempotency.New(key, r.Method, r.URL.Path, body)
+		err = app.idempotency.Claim(r.Context(), record, u.ID)
+		if err != nil {
+			var claimedErr *idempotency.AlreadyClaimedError
+			if !errors.As(err, &claimedErr) {
+				return fmt.Errorf("failed to claim idempotency key: %w", err)
+			}
+			existing := claimedErr.Existing
+			switch {
+			case !existing.Matches(r.Method, r.URL.Path):
+				return NewAPIError(http.StatusUnprocessableEntity, fmt.Errorf("%s was already used for %s %s", idempotency.HeaderName, existing.Method, existing.Path))
+			case !existing.SameBody(record):
+				return NewAPIError(http.StatusUnprocessableEntity, fmt.Errorf("%s was already used with a different request body", idempotency.HeaderName))
+			case existing.InProgress():
+				return NewAPIError(http.StatusConflict, fmt.Errorf("request with this %s is still being processed", idempotency.HeaderName))
+			}
+			for name, value := range existing.Header {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(existing.StatusCode)
+			_, err := w.Write(existing.Body)
+			return err
+		}
+
+		rec := &recordingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		err = fn(rec, r, u)
+		if err != nil || rec.statusCode >= http.StatusInternalServerError {
+			if releaseErr := app.idempotency.Release(r.Context(), key, u.ID); releaseErr != nil {
+				app.logger.Error("failed to release idempotency key", "error", releaseErr)
+			}
+			return err
+		}
+
+		header := map[string]string{}
+		for _, name := range idempotency.ReplayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		if rec.body.Len() > idempotency.BodyMaxSize {
+			err = fmt.Errorf("response of %d bytes exceeds %d bytes", rec.body.Len(), idempotency.BodyMaxSize)
+		} else {
+			err = app.idempotency.Complete(r.Context(), record.Complete(rec.statusCode, header, rec.body.Bytes()), u.ID)
+		}
+		if err != nil {
+			// a claim left in progress would reject retries until it expires
+			app.logger.Error("failed to store idempotent response", "error", err)
+			if releaseErr := app.idempotency.Release(r.Context(), key, u.ID); releaseErr != nil {
+				app.logger.Error("failed to release idempotency key", "error", releaseErr)
+			}
+		}
+		return nil
+	}
+}
+
+// recordingResponseWriter keeps a copy of the response written through it.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rrw *recordingResponseWriter) WriteHeader(code int) {
+	rrw.statusCode = code
+	rrw.ResponseWriter.WriteHeader(code)
+}
+
+func (rrw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rrw.body.Write(b)
+	return rrw.ResponseWriter.Write(b)
+}
+
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
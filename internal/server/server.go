@@ -6,9 +6,16 @@ import (
 	"net/http"
 
 	"github.com/kkstas/tener/assets"
+	"github.com/kkstas/tener/internal/model/budget"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
+	"github.com/kkstas/tener/internal/notify"
 )
 
 type expenseStore interface {
@@ -18,12 +25,70 @@ type expenseStore interface {
 	FindOne(ctx context.Context, SK, vaultID string) (expense.Expense, error)
 	Query(ctx context.Context, from, to string, categories []string, vaultID string) ([]expense.Expense, error)
 	GetMonthlySums(ctx context.Context, monthsAgo int, vaultID string) ([]expense.MonthlySum, error)
+	GetMonthlySumsBy(ctx context.Context, gb expense.GroupBy, monthsAgo int, vaultID string) ([]expense.MonthlySum, error)
+	GetSums(ctx context.Context, g expense.Granularity, from, to, vaultID string) ([]expense.PeriodSum, error)
+	Suggest(ctx context.Context, name, vaultID string) (expense.Suggestion, error)
+	Recategorize(ctx context.Context, from, to, cursor string, limit int, vaultID string) (expense.RecategorizeResult, error)
+	CountByCategory(ctx context.Context, category, vaultID string) (int, error)
+	CountCategories(ctx context.Context, vaultID string) (map[string]int, error)
+	ReconcileSums(ctx context.Context, vaultID string, repair bool) (expense.ReconcileResult, error)
+	GetUsage(ctx context.Context, date, vaultID string) (expense.Usage, error)
+	SetMonthLimit(ctx context.Context, limit int, vaultID string) error
 }
 
 type expenseCategoryStore interface {
 	Create(ctx context.Context, categoryFC expensecategory.Category, userID, vaultID string) error
 	Delete(ctx context.Context, name, vaultID string) error
+	FindOne(ctx context.Context, name, vaultID string) (expensecategory.Category, error)
 	FindAll(ctx context.Context, vaultID string) ([]expensecategory.Category, error)
+	StartMigration(ctx context.Context, name string, migration expensecategory.Migration, vaultID string) error
+	UpdateMigration(ctx context.Context, name string, migration expensecategory.Migration, vaultID string) error
+	FinishMigration(ctx context.Context, name, vaultID string) error
+	UpdateMetadata(ctx context.Context, categoryFU expensecategory.Category, vaultID string) error
+}
+
+type expenseRuleStore interface {
+	Create(ctx context.Context, ruleFC expenserule.Rule, userID, vaultID string) (expenserule.Rule, error)
+	Delete(ctx context.Context, id, vaultID string) error
+	FindOne(ctx context.Context, id, vaultID string) (expenserule.Rule, error)
+	FindAll(ctx context.Context, vaultID string) ([]expenserule.Rule, error)
+}
+
+type budgetStore interface {
+	Put(ctx context.Context, budgetFC budget.Budget, userID, vaultID string) (budget.Budget, error)
+	Delete(ctx context.Context, category, vaultID string) error
+	Move(ctx context.Context, from, to, vaultID string) error
+	FindAll(ctx context.Context, vaultID string) ([]budget.Budget, error)
+	MarkAlerted(ctx context.Context, category, month string, threshold int, vaultID string) (bool, error)
+	ClearAlerted(ctx context.Context, category, month string, threshold int, vaultID string) error
+}
+
+type notificationStore interface {
+	Create(ctx context.Context, n notification.Notification, userID string) error
+	FindLatest(ctx context.Context, userID string, limit int) ([]notification.Notification, error)
+	MarkRead(ctx context.Context, id, userID string) error
+}
+
+type savingsGoalStore interface {
+	Create(ctx context.Context, goalFC savingsgoal.Goal, userID, vaultID string) (savingsgoal.Goal, error)
+	Delete(ctx context.Context, id, vaultID string) error
+	FindOne(ctx context.Context, id, vaultID string) (savingsgoal.Goal, error)
+	FindAll(ctx context.Context, vaultID string) ([]savingsgoal.Goal, error)
+	AddContribution(ctx context.Context, contributionFC savingsgoal.Contribution, userID, vaultID string) (savingsgoal.Contribution, error)
+	DeleteContribution(ctx context.Context, goalID, SK, vaultID string) error
+	FindContributions(ctx context.Context, goalID, vaultID string) ([]savingsgoal.Contribution, error)
+}
+
+type idempotencyStore interface {
+	Claim(ctx context.Context, r idempotency.Record, userID string) error
+	Complete(ctx context.Context, r idempotency.Record, userID string) error
+	Release(ctx context.Context, key, userID string) error
+}
+
+type undoStore interface {
+	Put(ctx context.Context, r undo.Record, userID string) error
+	Take(ctx context.Context, id, userID string) (undo.Record, error)
+	Restore(ctx context.Context, r undo.Record, userID string) error
 }
 
 type userStore interface {
@@ -31,25 +96,58 @@ type userStore interface {
 	Delete(ctx context.Context, id string) error
 	FindOneByID(ctx context.Context, id string) (user.User, error)
 	FindOneByEmail(ctx context.Context, email string) (user.User, error)
-	FindAll(ctx context.Context) ([]user.User, error)
+	FindVaultMembers(ctx context.Context, vaultID string) ([]user.User, error)
 	FindAllByIDs(ctx context.Context, ids []string) (map[string]user.User, error)
 }
 
 type Application struct {
 	expense         expenseStore
 	expenseCategory expenseCategoryStore
+	expenseRule     expenseRuleStore
+	budget          budgetStore
+	notification    notificationStore
+	notifier        *notify.Notifier
+	savingsGoal     savingsGoalStore
+	idempotency     idempotencyStore
+	undo            undoStore
 	user            userStore
 	logger          *slog.Logger
+	// streamAggregation leaves budget alerts to the stream worker, which
+	// evaluates them once it has applied sums of the expense.
+	streamAggregation bool
 	http.Handler
 }
 
-func NewApplication(logger *slog.Logger, expenseStore expenseStore, expenseCategoryStore expenseCategoryStore, userStore userStore) *Application {
+func NewApplication(
+	logger *slog.Logger,
+	expenseStore expenseStore,
+	expenseCategoryStore expenseCategoryStore,
+	expenseRuleStore expenseRuleStore,
+	budgetStore budgetStore,
+	notificationStore notificationStore,
+	savingsGoalStore savingsGoalStore,
+	idempotencyStore idempotencyStore,
+	undoStore undoStore,
+	userStore userStore,
+	channels ...notify.Channel,
+) *Application {
 	app := new(Application)
 
 	app.logger = logger
 
 	app.expense = expenseStore
+	if s, ok := expenseStore.(interface{ StreamAggregation() bool }); ok {
+		app.streamAggregation = s.StreamAggregation()
+	}
 	app.expenseCategory = expenseCategoryStore
+	app.expenseRule = expenseRuleStore
+	app.budget = budgetStore
+	app.notification = notificationStore
+	app.savingsGoal = savingsGoalStore
+	app.idempotency = idempotencyStore
+	app.undo = undoStore
+
+	app.notifier = notify.New(append([]notify.Channel{notify.NewInAppChannel(notificationStore)}, channels...)...)
 	app.user = userStore
 
 	mux := http.NewServeMux()
@@ -65,14 +163,60 @@ func NewApplication(logger *slog.Logger, expenseStore expenseStore, expenseCateg
 
 	mux.HandleFunc("GET    /home", app.make(app.withUser(app.renderHomePage)))
 	mux.HandleFunc("GET    /expense/all", app.make(app.withUser(app.getExpensesJSON)))
-	mux.HandleFunc("POST   /expense/create", app.make(app.withUser(app.createSingleExpenseJSON)))
-	mux.HandleFunc("PUT    /expense/edit/{SK}", app.make(app.withUser(app.updateSingleExpenseJSON)))
-	mux.HandleFunc("DELETE /expense/{SK}", app.make(app.withUser(app.deleteSingleExpenseJSON)))
+	mux.HandleFunc("POST   /expense/create", app.make(app.withUser(app.idempotent(app.createSingleExpenseJSON))))
+	mux.HandleFunc("PUT    /expense/edit/{SK}", app.make(app.withUser(app.idempotent(app.updateSingleExpenseJSON))))
+	mux.HandleFunc("DELETE /expense/{SK}", app.make(app.withUser(app.idempotent(app.deleteSingleExpenseJSON))))
 	mux.HandleFunc("GET    /expense/sums", app.make(app.withUser(app.getMonthlySumsJSON)))
+	mux.HandleFunc("GET    /expense/rollups", app.make(app.withUser(app.getSumsJSON)))
+	mux.HandleFunc("GET    /expense/usage", app.make(app.withUser(app.getExpenseUsageJSON)))
+	mux.HandleFunc("GET    /expense/suggest", app.make(app.withUser(app.suggestExpenseJSON)))
+	mux.HandleFunc("GET    /expense/upcoming", app.make(app.withUser(app.renderUpcomingExpensesPage)))
+	mux.HandleFunc("POST   /expense/{SK}/clear", app.make(app.withUser(app.idempotent(app.clearExpense))))
+	mux.HandleFunc("POST   /expense/{SK}/refund", app.make(app.withUser(app.idempotent(app.createRefundJSON))))
+	mux.HandleFunc("DELETE /expense/installments/{SK}", app.make(app.withUser(app.idempotent(app.deleteInstallmentsJSON))))
 
 	mux.HandleFunc("GET    /expensecategories", app.make(app.withUser(app.renderExpenseCategoriesPage)))
-	mux.HandleFunc("POST   /expensecategories/create", app.make(app.withUser(app.createAndRenderSingleExpenseCategory)))
-	mux.HandleFunc("DELETE /expensecategories/{name}", app.make(app.withUser(app.deleteSingleExpenseCategory)))
+	mux.HandleFunc("GET    /expensecategories/orphans", app.make(app.withUser(app.getOrphanedExpenseCategoriesJSON)))
+	mux.HandleFunc("POST   /expensecategories/create", app.make(app.withUser(app.idempotent(app.createAndRenderSingleExpenseCategory))))
+	mux.HandleFunc("POST   /expensecategories/{name}/edit", app.make(app.withUser(app.idempotent(app.updateAndRenderSingleExpenseCategory))))
+	mux.HandleFunc("DELETE /expensecategories/{name}", app.make(app.withUser(app.idempotent(app.deleteSingleExpenseCategory))))
+	mux.HandleFunc("POST   /expensecategories/{name}/migrate", app.make(app.withUser(app.idempotent(app.startExpenseCategoryMigration))))
+	mux.HandleFunc("POST   /expensecategories/{name}/migrate/step", app.make(app.withUser(app.idempotent(app.stepExpenseCategoryMigration))))
+
+	mux.HandleFunc("GET    /expenserules", app.make(app.withUser(app.renderExpenseRulesPage)))
+	mux.HandleFunc("GET    /expenserules/match", app.make(app.withUser(app.matchExpenseRuleJSON)))
+	mux.HandleFunc("POST   /expenserules/create", app.make(app.withUser(app.idempotent(app.createAndRenderSingleExpenseRule))))
+	mux.HandleFunc("DELETE /expenserules/{id}", app.make(app.withUser(app.idempotent(app.deleteSingleExpenseRule))))
+	mux.HandleFunc("GET    /expenserules/{id}/preview", app.make(app.withUser(app.previewExpenseRuleJSON)))
+	mux.HandleFunc("POST   /expenserules/{id}/apply", app.make(app.withUser(app.idempotent(app.applyExpenseRuleJSON))))
+
+	mux.HandleFunc("GET    /budgets", app.make(app.withUser(app.renderBudgetsPage)))
+	mux.HandleFunc("GET    /budgets/overview", app.make(app.withUser(app.getBudgetOverviewJSON)))
+	mux.HandleFunc("GET    /budgets/progress", app.make(app.withUser(app.renderBudgetProgress)))
+	mux.HandleFunc("POST   /budgets/create", app.make(app.withUser(app.idempotent(app.createAndRenderSingleBudget))))
+	mux.HandleFunc("DELETE /budgets/{category}", app.make(app.withUser(app.idempotent(app.deleteSingleBudget))))
+
+	mux.HandleFunc("GET    /reports", app.make(app.withUser(app.renderReportsPage)))
+	mux.HandleFunc("GET    /reports/data", app.make(app.withUser(app.getReportJSON)))
+	mux.HandleFunc("GET    /reports/compare", app.make(app.withUser(app.getComparisonJSON)))
+
+	mux.HandleFunc("GET    /savings", app.make(app.withUser(app.renderSavingsGoalsPage)))
+	mux.HandleFunc("GET    /savings/overview", app.make(app.withUser(app.getSavingsGoalsOverviewJSON)))
+	mux.HandleFunc("POST   /savings/create", app.make(app.withUser(app.idempotent(app.createSavingsGoal))))
+	mux.HandleFunc("GET    /savings/{id}", app.make(app.withUser(app.renderSavingsGoalPage)))
+	mux.HandleFunc("DELETE /savings/{id}", app.make(app.withUser(app.idempotent(app.deleteSavingsGoal))))
+	mux.HandleFunc("POST   /savings/{id}/contributions", app.make(app.withUser(app.idempotent(app.addSavingsGoalContribution))))
+	mux.HandleFunc("DELETE /savings/{id}/contributions/{SK}", app.make(app.withUser(app.idempotent(app.deleteSavingsGoalContribution))))
+
+	mux.HandleFunc("GET    /notifications", app.make(app.withUser(app.getNotificationsJSON)))
+	mux.HandleFunc("POST   /notifications/{id}/read", app.make(app.withUser(app.idempotent(app.markNotificationRead))))
+
+	mux.HandleFunc("POST   /undo/{id}", app.make(app.withUser(app.idempotent(app.undoLastAction))))
+
+	mux.HandleFunc("GET    /admin/sums", app.make(app.withUser(requireAdmin(app.reconcileSumsJSON))))
+	mux.HandleFunc("POST   /admin/sums/repair", app.make(app.withUser(requireAdmin(app.idempotent(app.repairSumsJSON)))))
+	mux.HandleFunc("GET    /admin/expenselimit", app.make(app.withUser(requireAdmin(app.getExpenseLimitJSON))))
+	mux.HandleFunc("PUT    /admin/expenselimit", app.make(app.withUser(requireAdmin(app.idempotent(app.setExpenseLimitJSON)))))
 
 	app.Handler = app.logHTTP(secureHeaders(mux))
 
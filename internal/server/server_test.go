@@ -3,7 +3,10 @@ package server_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -16,13 +19,25 @@ import (
 	"github.com/kkstas/tener/assets"
 	"github.com/kkstas/tener/internal/auth"
 	"github.com/kkstas/tener/internal/database"
+	"github.com/kkstas/tener/internal/helpers"
+	"github.com/kkstas/tener/internal/model/budget"
 	"github.com/kkstas/tener/internal/model/expense"
 	"github.com/kkstas/tener/internal/model/expensecategory"
+	"github.com/kkstas/tener/internal/model/expenserule"
+	"github.com/kkstas/tener/internal/model/idempotency"
+	"github.com/kkstas/tener/internal/model/notification"
+	"github.com/kkstas/tener/internal/model/savingsgoal"
+	"github.com/kkstas/tener/internal/model/undo"
 	"github.com/kkstas/tener/internal/model/user"
 	"github.com/kkstas/tener/internal/server"
 	u "github.com/kkstas/tener/internal/url"
 )
 
+func TestMain(m *testing.M) {
+	os.Setenv("TOKEN_SECRET", "gHg8v3-XKj9XO8M-6gpjzW0n1xn7UZTBICIY1FcjyPw")
+	os.Exit(m.Run())
+}
+
 func TestHomeHandler(t *testing.T) {
 	t.Run("responds with html", func(t *testing.T) {
 		response := httptest.NewRecorder()
@@ -138,6 +153,7 @@ func TestCreateExpense(t *testing.T) {
 			param.Set("category", "food")
 			param.Set("name", "some name")
 			param.Set("date", "2024-01-01")
+			param.Set("allowDuplicate", "true")
 			var payload = bytes.NewBufferString(param.Encode())
 			response := httptest.NewRecorder()
 			request := httptest.NewRequest(http.MethodPost, "/expense/create", payload)
@@ -157,6 +173,151 @@ func TestCreateExpense(t *testing.T) {
 		res := createExpense()
 		assertStatus(t, res.Code, http.StatusForbidden)
 	})
+
+	t.Run("returns status conflict for possible duplicate unless allowed", func(t *testing.T) {
+		store := &expense.InMemoryStore{}
+		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		app := server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
+
+		createExpense := func(name, date string, allowDuplicate bool) *httptest.ResponseRecorder {
+			var param = url.Values{}
+			param.Set("paymentMethod", expense.PaymentMethods[0])
+			param.Set("amount", "120,50")
+			param.Set("category", "food")
+			param.Set("name", name)
+			param.Set("date", date)
+			if allowDuplicate {
+				param.Set("allowDuplicate", "true")
+			}
+			var payload = bytes.NewBufferString(param.Encode())
+			response := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/expense/create", payload)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			addTokenCookie(t, request)
+			app.ServeHTTP(response, request)
+			return response
+		}
+
+		assertStatus(t, createExpense("Dinner at Pasta Bar", "2024-05-10", false).Code, http.StatusOK)
+
+		res := createExpense("dinner pasta bar", "2024-05-11", false)
+		assertStatus(t, res.Code, http.StatusConflict)
+
+		var body struct {
+			Duplicates []expense.Expense `json:"duplicates"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body.Duplicates) != 1 || body.Duplicates[0].Name != "Dinner at Pasta Bar" {
+			t.Errorf("expected existing expense to be returned as duplicate, got %#v", body.Duplicates)
+		}
+
+		assertStatus(t, createExpense("dinner pasta bar", "2024-05-11", true).Code, http.StatusOK)
+
+		expenses, _ := store.Query(context.Background(), "2024-05-10", "2024-05-11", []string{}, "vaultID")
+		if len(expenses) != 2 {
+			t.Errorf("expected 2 expenses after override, got %d", len(expenses))
+		}
+	})
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	store := &expense.InMemoryStore{}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	app := server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
+
+	// keys are scoped to a user, so every request reuses the same token
+	tokenRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	addTokenCookie(t, tokenRequest)
+	cookie := tokenRequest.Header.Get("cookie")
+
+	createExpense := func(t *testing.T, key, amount string) *httptest.ResponseRecorder {
+		t.Helper()
+		var param = url.Values{}
+		param.Set("paymentMethod", expense.PaymentMethods[0])
+		param.Set("amount", amount)
+		param.Set("category", "food")
+		param.Set("name", "some name")
+		param.Set("date", "2024-01-01")
+		param.Set("allowDuplicate", "true")
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/create", bytes.NewBufferString(param.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Idempotency-Key", key)
+		request.Header.Set("cookie", cookie)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("replays original response instead of creating expense again", func(t *testing.T) {
+		first := createExpense(t, "create-once", "1.99")
+		assertStatus(t, first.Code, http.StatusOK)
+
+		replayed := createExpense(t, "create-once", "1.99")
+		assertStatus(t, replayed.Code, http.StatusOK)
+		if replayed.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("expected response to be marked as replayed")
+		}
+		if replayed.Body.String() != first.Body.String() {
+			t.Errorf("got body %q, want %q", replayed.Body.String(), first.Body.String())
+		}
+		expenses, _ := store.Query(context.Background(), "2024-01-01", "2024-01-01", []string{}, "vaultID")
+		if len(expenses) != 1 {
+			t.Errorf("got %d expenses, want 1", len(expenses))
+		}
+	})
+
+	t.Run("does not store failed responses", func(t *testing.T) {
+		assertStatus(t, createExpense(t, "retry-after-fix", "abc").Code, http.StatusBadRequest)
+		response := createExpense(t, "retry-after-fix", "2.99")
+		assertStatus(t, response.Code, http.StatusOK)
+		if response.Header().Get("Idempotent-Replayed") != "" {
+			t.Error("didn't expect failed response to be replayed")
+		}
+	})
+
+	t.Run("rejects key reused for another request", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodDelete, "/expense/some-SK", nil)
+		request.Header.Set("Idempotency-Key", "create-once")
+		request.Header.Set("cookie", cookie)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("rejects key reused with another request body", func(t *testing.T) {
+		assertStatus(t, createExpense(t, "create-once", "5.99").Code, http.StatusUnprocessableEntity)
+	})
+}
+
+// completeFailingStore can't store responses.
+type completeFailingStore struct {
+	idempotency.InMemoryStore
+}
+
+func (s *completeFailingStore) Complete(ctx context.Context, r idempotency.Record, userID string) error {
+	return errors.New("unavailable")
+}
+
+func TestIdempotencyKeyReleasedWhenResponseIsNotStored(t *testing.T) {
+	store := &expense.InMemoryStore{}
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	app := server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &completeFailingStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
+
+	tokenRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	addTokenCookie(t, tokenRequest)
+
+	for range 2 {
+		param := url.Values{"paymentMethod": {expense.PaymentMethods[0]}, "amount": {"1.99"}, "category": {"food"}, "name": {"some name"}, "date": {"2024-01-01"}, "allowDuplicate": {"true"}}
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/expense/create", bytes.NewBufferString(param.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Idempotency-Key", "create-once")
+		request.Header.Set("cookie", tokenRequest.Header.Get("cookie"))
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+	}
 }
 
 func TestUpdateExpense(t *testing.T) {
@@ -186,11 +347,184 @@ func TestUpdateExpense(t *testing.T) {
 		response := httptest.NewRecorder()
 		request := httptest.NewRequest(http.MethodPut, u.Create(context.Background(), "expense", "edit", SK), payload)
 		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("If-Match", `"1"`)
 		addTokenCookie(t, request)
 
 		logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		server.NewApplication(logger, &store, &expensecategory.InMemoryStore{}, &user.InMemoryStore{}).ServeHTTP(response, request)
+		server.NewApplication(logger, &store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{}).ServeHTTP(response, request)
 		assertStatus(t, response.Code, http.StatusOK)
+		if etag := response.Header().Get("ETag"); etag != `"2"` {
+			t.Errorf("expected ETag of the next version, got %q", etag)
+		}
+	})
+
+	t.Run("rejects edits of stale copies with the current one", func(t *testing.T) {
+		app := newTestApplicationWithCategoryTree(t)
+		exp := getExpenses(t, app)[0]
+
+		edit := func(t *testing.T, ifMatch string) *httptest.ResponseRecorder {
+			t.Helper()
+			form := url.Values{"name": {"edited"}, "date": {exp.Date}, "category": {exp.Category}, "amount": {"10"}, "paymentMethod": {exp.PaymentMethod}}
+			response := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPut, "/expense/edit/"+exp.SK, bytes.NewBufferString(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if ifMatch != "" {
+				request.Header.Set("If-Match", ifMatch)
+			}
+			addTokenCookie(t, request)
+			app.ServeHTTP(response, request)
+			return response
+		}
+
+		assertStatus(t, edit(t, "").Code, http.StatusPreconditionRequired)
+		assertStatus(t, edit(t, `"1"`).Code, http.StatusOK)
+
+		response := edit(t, `"1"`)
+		assertStatus(t, response.Code, http.StatusConflict)
+		var body struct {
+			Current expense.Expense `json:"current"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Current.Name != "edited" || body.Current.Version != 2 || response.Header().Get("ETag") != `"2"` {
+			t.Errorf("expected current copy at version 2, got %#v", body.Current)
+		}
+	})
+}
+
+func getExpenses(t testing.TB, app *server.Application) []expense.Expense {
+	t.Helper()
+	response := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/expense/all", nil)
+	addTokenCookie(t, request)
+	app.ServeHTTP(response, request)
+	assertStatus(t, response.Code, http.StatusOK)
+
+	var body struct {
+		Expenses []expense.Expense `json:"expenses"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return body.Expenses
+}
+
+func TestGetSums(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+	today := helpers.DaysAgo(0)
+
+	getSums := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/expense/rollups"+query, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("returns sums of given granularity and categories", func(t *testing.T) {
+		response := getSums(t, "?granularity=day&categories=Transport&from="+today+"&to="+today)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var body struct {
+			Granularity string              `json:"granularity"`
+			Sums        []expense.PeriodSum `json:"sums"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Granularity != "day" || len(body.Sums) != 2 {
+			t.Fatalf("expected 2 daily sums of Transport subcategories, got %#v", body)
+		}
+		for _, s := range body.Sums {
+			if s.Period != today || s.Category == "Food" {
+				t.Errorf("got unexpected sum %#v", s)
+			}
+		}
+	})
+
+	t.Run("returns status bad request for invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?granularity=quarter",
+			"?from=2026-13-01",
+			"?from=2026-10-02&to=2026-10-01",
+			"?granularity=day&from=2024-01-01&to=2026-01-01",
+		} {
+			assertStatus(t, getSums(t, query).Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestMonthlySumsGroupBy(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+
+	t.Run("sums up by payment method", func(t *testing.T) {
+		totals := getSumsTotals(t, app, "?groupBy=paymentMethod")
+		if len(totals) != 1 || totals[expense.PaymentMethods[0]] != 550 {
+			t.Errorf("got unexpected totals %v", totals)
+		}
+	})
+
+	t.Run("sums up by member with their names", func(t *testing.T) {
+		totals := getSumsTotals(t, app, "?groupBy=user")
+		if len(totals) != 1 || totals[validFirstName+" "+validLastName] != 550 {
+			t.Errorf("got unexpected totals %v", totals)
+		}
+	})
+
+	t.Run("returns status bad request for unknown grouping", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/expense/sums?groupBy=name", nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+}
+
+func TestMonthlySumsForecast(t *testing.T) {
+	app := newTestApplicationWithCategoryTree(t)
+
+	getChartData := func(t *testing.T, query string) expense.ChartData {
+		t.Helper()
+		response := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/expense/sums"+query, nil)
+		addTokenCookie(t, request)
+		app.ServeHTTP(response, request)
+		assertStatus(t, response.Code, http.StatusOK)
+
+		var chartData expense.ChartData
+		if err := json.NewDecoder(response.Body).Decode(&chartData); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return chartData
+	}
+
+	t.Run("includes forecast of current month", func(t *testing.T) {
+		forecast := getChartData(t, "").Forecast
+		if forecast == nil || forecast.Date != helpers.DaysAgo(0) || forecast.SpentToDate != 550 || forecast.Total < 550 {
+			t.Fatalf("unexpected forecast %#v", forecast)
+		}
+		if len(forecast.Categories) != 3 {
+			t.Errorf("expected forecast of 3 categories, got %#v", forecast.Categories)
+		}
+	})
+
+	t.Run("forecasts top level categories", func(t *testing.T) {
+		forecast := getChartData(t, "?level=top").Forecast
+		if forecast == nil || len(forecast.Categories) != 2 || forecast.SpentToDate != 550 {
+			t.Fatalf("unexpected forecast %#v", forecast)
+		}
+	})
+
+	t.Run("includes forecast of sums by payment method", func(t *testing.T) {
+		forecast := getChartData(t, "?groupBy=paymentMethod").Forecast
+		if forecast == nil || forecast.SpentToDate != 550 {
+			t.Fatalf("unexpected forecast %#v", forecast)
+		}
+		if len(forecast.Categories) != 0 {
+			t.Errorf("expected no per category forecast for other grouping, got %#v", forecast.Categories)
+		}
 	})
 }
 
@@ -203,7 +537,7 @@ func assertStatus(t testing.TB, got, want int) {
 
 func newTestApplication() *server.Application {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-	return server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &user.InMemoryStore{})
+	return server.NewApplication(logger, &expense.InMemoryStore{}, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{})
 }
 
 func newTestApplicationWithDDB(t testing.TB, expenseLimit int) (app *server.Application, cancelFunc func()) {
@@ -222,7 +556,7 @@ func newTestApplicationWithDDB(t testing.TB, expenseLimit int) (app *server.Appl
 	store := expense.NewDDBStoreWithExpenseMonthLimit(tableName, client, expenseLimit)
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-	return server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &user.InMemoryStore{}), cancelFunc
+	return server.NewApplication(logger, store, &expensecategory.InMemoryStore{}, &expenserule.InMemoryStore{}, &budget.InMemoryStore{}, &notification.InMemoryStore{}, &savingsgoal.InMemoryStore{}, &idempotency.InMemoryStore{}, &undo.InMemoryStore{}, &user.InMemoryStore{}), cancelFunc
 }
 
 func addTokenCookie(t testing.TB, r *http.Request) {
@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// HandleLambdaEvent processes a batch of stream records delivered to Lambda.
+// It reports the first failed record, so that Lambda retries the batch from it
+// on, which requires ReportBatchItemFailures enabled on the event source
+// mapping.
+func (w *Worker) HandleLambdaEvent(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	records := make([]Record, 0, len(event.Records))
+	for _, r := range event.Records {
+		records = append(records, Record{
+			EventID:        r.EventID,
+			SequenceNumber: r.Change.SequenceNumber,
+			Keys:           fromLambdaItem(r.Change.Keys),
+			OldImage:       fromLambdaItem(r.Change.OldImage),
+			NewImage:       fromLambdaItem(r.Change.NewImage),
+		})
+	}
+
+	processed, err := w.Process(ctx, records)
+	if err != nil {
+		w.logger.Error("failed to process stream event", "error", err)
+		return events.DynamoDBEventResponse{
+			BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: records[processed].SequenceNumber}},
+		}, nil
+	}
+
+	return events.DynamoDBEventResponse{}, nil
+}
+
+func fromLambdaItem(item map[string]events.DynamoDBAttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	converted := make(map[string]types.AttributeValue, len(item))
+	for name, av := range item {
+		converted[name] = fromLambdaAttributeValue(av)
+	}
+	return converted
+}
+
+func fromLambdaAttributeValue(av events.DynamoDBAttributeValue) types.AttributeValue {
+	switch av.DataType() {
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: av.Binary()}
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: av.Boolean()}
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: av.BinarySet()}
+	case events.DataTypeList:
+		list := []types.AttributeValue{}
+		for _, v := range av.List() {
+			list = append(list, fromLambdaAttributeValue(v))
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case events.DataTypeMap:
+		return &types.AttributeValueMemberM{Value: fromLambdaItem(av.Map())}
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: av.Number()}
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: av.NumberSet()}
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: av.String()}
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: av.StringSet()}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
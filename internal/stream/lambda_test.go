@@ -0,0 +1,119 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/kkstas/tener/internal/model/expense"
+	"github.com/kkstas/tener/internal/stream"
+)
+
+type fakeExpenseStore struct {
+	changes []expense.StreamChange
+	vaults  []string
+	failOn  string
+}
+
+func (s *fakeExpenseStore) ApplyStreamChange(ctx context.Context, change expense.StreamChange, vaultID string) error {
+	if change.EventID == s.failOn {
+		return errors.New("failed")
+	}
+	s.changes = append(s.changes, change)
+	s.vaults = append(s.vaults, vaultID)
+	return nil
+}
+
+type fakeBudgetAlerter struct {
+	alerted []expense.Expense
+}
+
+func (a *fakeBudgetAlerter) AlertBudgetThresholds(ctx context.Context, exp expense.Expense, vaultID string) {
+	a.alerted = append(a.alerted, exp)
+}
+
+func expenseImage(category, amount string) map[string]events.DynamoDBAttributeValue {
+	return map[string]events.DynamoDBAttributeValue{
+		"PK":       events.NewStringAttribute("expense::vaultID"),
+		"SK":       events.NewStringAttribute("2026-10-19::createdAt"),
+		"date":     events.NewStringAttribute("2026-10-19"),
+		"category": events.NewStringAttribute(category),
+		"amount":   events.NewNumberAttribute(amount),
+	}
+}
+
+func record(eventID, sequenceNumber, pk string, oldImage, newImage map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventID: eventID,
+		Change: events.DynamoDBStreamRecord{
+			SequenceNumber: sequenceNumber,
+			Keys:           map[string]events.DynamoDBAttributeValue{"PK": events.NewStringAttribute(pk), "SK": events.NewStringAttribute("SK")},
+			OldImage:       oldImage,
+			NewImage:       newImage,
+		},
+	}
+}
+
+func TestHandleLambdaEvent(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("applies expense changes and skips other items", func(t *testing.T) {
+		store := &fakeExpenseStore{}
+		alerter := &fakeBudgetAlerter{}
+		worker := stream.NewWorker(logger, store, alerter)
+
+		response, err := worker.HandleLambdaEvent(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+			record("1", "100", "expense::vaultID", nil, expenseImage("Food", "12.5")),
+			record("2", "200", "monthlysum::vaultID", nil, nil),
+			record("3", "300", "expense::vaultID", expenseImage("Food", "12.5"), expenseImage("Fuel", "20")),
+		}})
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if len(response.BatchItemFailures) != 0 {
+			t.Errorf("didn't expect failures, got %#v", response.BatchItemFailures)
+		}
+
+		if len(store.changes) != 2 {
+			t.Fatalf("expected 2 applied changes, got %#v", store.changes)
+		}
+		created, updated := store.changes[0], store.changes[1]
+		if created.Old != nil || created.New == nil || created.New.Amount != 12.5 || store.vaults[0] != "vaultID" {
+			t.Errorf("unexpected created expense change: %#v", created)
+		}
+		if updated.Old.Category != "Food" || updated.New.Category != "Fuel" || updated.New.Amount != 20 {
+			t.Errorf("unexpected updated expense change: %#v %#v", updated.Old, updated.New)
+		}
+		if len(alerter.alerted) != 2 || alerter.alerted[0].Category != "Food" || alerter.alerted[1].Category != "Fuel" {
+			t.Errorf("expected budget alerts for both written expenses, got %#v", alerter.alerted)
+		}
+	})
+
+	t.Run("reports first failed record", func(t *testing.T) {
+		store := &fakeExpenseStore{failOn: "2"}
+		alerter := &fakeBudgetAlerter{}
+		worker := stream.NewWorker(logger, store, alerter)
+
+		response, err := worker.HandleLambdaEvent(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+			record("1", "100", "expense::vaultID", nil, expenseImage("Food", "1")),
+			record("2", "200", "expense::vaultID", nil, expenseImage("Food", "2")),
+			record("3", "300", "expense::vaultID", nil, expenseImage("Food", "3")),
+		}})
+		if err != nil {
+			t.Fatalf("didn't expect an error but got one: %v", err)
+		}
+		if len(response.BatchItemFailures) != 1 || response.BatchItemFailures[0].ItemIdentifier != "200" {
+			t.Errorf("expected failure of record 200, got %#v", response.BatchItemFailures)
+		}
+		if len(store.changes) != 1 {
+			t.Errorf("expected processing to stop at failed record, got %#v", store.changes)
+		}
+		if len(alerter.alerted) != 1 {
+			t.Errorf("expected budget alerts only for applied changes, got %#v", alerter.alerted)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// Poller reads the stream directly and passes its records to the worker, for
+// development against dynamodb-local, where Lambda triggers are unavailable.
+type Poller struct {
+	client    *dynamodbstreams.Client
+	streamARN string
+	worker    *Worker
+	interval  time.Duration
+	// iterators holds the next iterator of every open shard.
+	iterators map[string]*string
+	// done holds shards that were read until closed.
+	done map[string]bool
+}
+
+func NewPoller(client *dynamodbstreams.Client, streamARN string, worker *Worker, interval time.Duration) *Poller {
+	return &Poller{
+		client:    client,
+		streamARN: streamARN,
+		worker:    worker,
+		interval:  interval,
+		iterators: map[string]*string{},
+		done:      map[string]bool{},
+	}
+}
+
+// Run polls the stream until the context is cancelled. Changes made before it
+// started are skipped; shards created while it runs are read from the start.
+func (p *Poller) Run(ctx context.Context) error {
+	if err := p.discoverShards(ctx, streamstypes.ShardIteratorTypeLatest); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := p.discoverShards(ctx, streamstypes.ShardIteratorTypeTrimHorizon); err != nil {
+			p.worker.logger.Error("failed to discover stream shards", "error", err)
+			continue
+		}
+		for shardID := range p.iterators {
+			if err := p.poll(ctx, shardID); err != nil {
+				p.worker.logger.Error("failed to poll stream shard", "error", err, "shardID", shardID)
+			}
+		}
+	}
+}
+
+func (p *Poller) discoverShards(ctx context.Context, iteratorType streamstypes.ShardIteratorType) error {
+	var lastShardID *string
+	for {
+		output, err := p.client.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn:             &p.streamARN,
+			ExclusiveStartShardId: lastShardID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe stream: %w", err)
+		}
+
+		for _, shard := range output.StreamDescription.Shards {
+			shardID := *shard.ShardId
+			if _, open := p.iterators[shardID]; open || p.done[shardID] {
+				continue
+			}
+			iterator, err := p.client.GetShardIterator(ctx, &dynamodbstreams.GetShardIteratorInput{
+				StreamArn:         &p.streamARN,
+				ShardId:           shard.ShardId,
+				ShardIteratorType: iteratorType,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get iterator of shard %s: %w", shardID, err)
+			}
+			p.iterators[shardID] = iterator.ShardIterator
+		}
+
+		lastShardID = output.StreamDescription.LastEvaluatedShardId
+		if lastShardID == nil {
+			return nil
+		}
+	}
+}
+
+// Reads new records of the shard. When processing fails, the iterator isn't
+// advanced, so the records are read again on the next poll.
+func (p *Poller) poll(ctx context.Context, shardID string) error {
+	output, err := p.client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{ShardIterator: p.iterators[shardID]})
+	if err != nil {
+		var expiredErr *streamstypes.ExpiredIteratorException
+		if errors.As(err, &expiredErr) {
+			delete(p.iterators, shardID)
+		}
+		return fmt.Errorf("failed to get records: %w", err)
+	}
+
+	records := make([]Record, 0, len(output.Records))
+	for _, r := range output.Records {
+		records = append(records, Record{
+			EventID:        *r.EventID,
+			SequenceNumber: *r.Dynamodb.SequenceNumber,
+			Keys:           fromStreamsItem(r.Dynamodb.Keys),
+			OldImage:       fromStreamsItem(r.Dynamodb.OldImage),
+			NewImage:       fromStreamsItem(r.Dynamodb.NewImage),
+		})
+	}
+
+	if _, err := p.worker.Process(ctx, records); err != nil {
+		return err
+	}
+
+	if output.NextShardIterator == nil {
+		delete(p.iterators, shardID)
+		p.done[shardID] = true
+		return nil
+	}
+	p.iterators[shardID] = output.NextShardIterator
+	return nil
+}
+
+func fromStreamsItem(item map[string]streamstypes.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	converted := make(map[string]types.AttributeValue, len(item))
+	for name, av := range item {
+		converted[name] = fromStreamsAttributeValue(av)
+	}
+	return converted
+}
+
+func fromStreamsAttributeValue(av streamstypes.AttributeValue) types.AttributeValue {
+	switch v := av.(type) {
+	case *streamstypes.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: v.Value}
+	case *streamstypes.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *streamstypes.AttributeValueMemberBS:
+		return &types.AttributeValueMemberBS{Value: v.Value}
+	case *streamstypes.AttributeValueMemberL:
+		list := make([]types.AttributeValue, 0, len(v.Value))
+		for _, el := range v.Value {
+			list = append(list, fromStreamsAttributeValue(el))
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case *streamstypes.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: fromStreamsItem(v.Value)}
+	case *streamstypes.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *streamstypes.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: v.Value}
+	case *streamstypes.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *streamstypes.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: v.Value}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
@@ -0,0 +1,98 @@
+// Package stream maintains data derived from table items, such as monthly sums
+// and name stats of expenses, from the table's stream of item changes, and
+// sends budget alerts once sums are up to date.
+package stream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/kkstas/tener/internal/model/expense"
+)
+
+// Record is a single item change read from the stream.
+type Record struct {
+	EventID        string
+	SequenceNumber string
+	Keys           map[string]types.AttributeValue
+	OldImage       map[string]types.AttributeValue
+	NewImage       map[string]types.AttributeValue
+}
+
+type expenseStore interface {
+	ApplyStreamChange(ctx context.Context, change expense.StreamChange, vaultID string) error
+}
+
+type budgetAlerter interface {
+	AlertBudgetThresholds(ctx context.Context, exp expense.Expense, vaultID string)
+}
+
+type Worker struct {
+	expense      expenseStore
+	budgetAlerts budgetAlerter
+	logger       *slog.Logger
+}
+
+func NewWorker(logger *slog.Logger, expenseStore expenseStore, budgetAlerts budgetAlerter) *Worker {
+	return &Worker{expense: expenseStore, budgetAlerts: budgetAlerts, logger: logger}
+}
+
+// Process applies records in order, stopping at the first one that fails.
+// It returns the number of records processed successfully, so that the failed
+// one and the ones after it can be retried in order.
+func (w *Worker) Process(ctx context.Context, records []Record) (int, error) {
+	for i, record := range records {
+		if err := w.processRecord(ctx, record); err != nil {
+			return i, fmt.Errorf("failed to process stream record %s: %w", record.EventID, err)
+		}
+	}
+	return len(records), nil
+}
+
+func (w *Worker) processRecord(ctx context.Context, record Record) error {
+	var keys struct {
+		PK string `dynamodbav:"PK"`
+	}
+	if err := attributevalue.UnmarshalMap(record.Keys, &keys); err != nil {
+		return fmt.Errorf("failed to unmarshal keys: %w", err)
+	}
+
+	if vaultID, ok := expense.VaultIDFromPK(keys.PK); ok {
+		return w.processExpense(ctx, record, vaultID)
+	}
+
+	return nil
+}
+
+func (w *Worker) processExpense(ctx context.Context, record Record, vaultID string) error {
+	change := expense.StreamChange{EventID: record.EventID}
+
+	for _, image := range []struct {
+		item map[string]types.AttributeValue
+		dest **expense.Expense
+	}{{record.OldImage, &change.Old}, {record.NewImage, &change.New}} {
+		if len(image.item) == 0 {
+			continue
+		}
+		var exp expense.Expense
+		if err := attributevalue.UnmarshalMap(image.item, &exp); err != nil {
+			return fmt.Errorf("failed to unmarshal expense: %w", err)
+		}
+		*image.dest = &exp
+	}
+
+	if err := w.expense.ApplyStreamChange(ctx, change, vaultID); err != nil {
+		return err
+	}
+	// Alerts are evaluated only now, as they read sums just applied above.
+	if change.New != nil {
+		w.budgetAlerts.AlertBudgetThresholds(ctx, *change.New, vaultID)
+	}
+
+	w.logger.Debug("applied expense change", "eventID", record.EventID, "vaultID", vaultID)
+	return nil
+}
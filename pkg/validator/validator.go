@@ -3,6 +3,7 @@ package validator
 import (
 	"fmt"
 	"math"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -67,6 +68,22 @@ func IsTime(name, layout, dateString string) (bool, string, string) {
 	return true, "", ""
 }
 
+func IsRegexp(name, pattern string) (bool, string, string) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return false, name, "must be a valid regular expression"
+	}
+	return true, "", ""
+}
+
+var hexColorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func IsHexColor(name, color string) (bool, string, string) {
+	if !hexColorRegexp.MatchString(color) {
+		return false, name, "must be a color in #rrggbb format"
+	}
+	return true, "", ""
+}
+
 var (
 	validEmailLocalChars  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#$%&'*+-/=?^_`{|}~."
 	validEmailDomainChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-."
@@ -27,6 +27,32 @@ func TestIsNonZero(t *testing.T) {
 	})
 }
 
+func TestIsRegexp(t *testing.T) {
+	t.Run("returns false if pattern does not compile", func(t *testing.T) {
+		got, _, _ := validator.IsRegexp("pattern", "(biedronka")
+		if got {
+			t.Error("expected false for invalid regular expression")
+		}
+		got, _, _ = validator.IsRegexp("pattern", "(?i)^biedronka")
+		if !got {
+			t.Error("expected true for valid regular expression")
+		}
+	})
+}
+
+func TestIsHexColor(t *testing.T) {
+	for _, color := range []string{"#36a2eb", "#FF6384"} {
+		if got, _, _ := validator.IsHexColor("color", color); !got {
+			t.Errorf("expected true for %q", color)
+		}
+	}
+	for _, color := range []string{"", "36a2eb", "#36a2e", "#36a2ebff", "#zzzzzz", "red"} {
+		if got, _, _ := validator.IsHexColor("color", color); got {
+			t.Errorf("expected false for %q", color)
+		}
+	}
+}
+
 func TestIsValidAmountPrecision(t *testing.T) {
 	t.Run("returns false if amount has invalid precision", func(t *testing.T) {
 		got, _, _ := validator.IsAmountPrecision("name", 19.449)